@@ -0,0 +1,419 @@
+package specops
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// Analyze is an opt-in superset of Lint(), additionally returning Diagnostics
+// from heuristic analyses that are more prone to false positives. Diagnostics
+// are ordered by PC. Analyze only returns an error if compilation fails.
+//
+// The analyses are:
+//   - fn-without-function: an Fn() with arguments, the first element of which
+//     isn't an opcode that consumes them; i.e. any opcode that doesn't pop
+//     from the stack, or a non-opcode. Use Pipe() to signal that this is
+//     deliberate.
+//   - infinite-loop: a JUMP, of a PUSHed destination, back to an earlier
+//     JUMPDEST with no JUMPI, other JUMP, or halting opcode in between. Such a
+//     loop can only end by running out of gas. Loops with computed
+//     destinations aren't detected.
+//   - tag-in-fn: a JUMPDEST or Label anywhere within the arguments of an Fn()
+//     or Pipe(). As arguments are emitted in reverse, the tag's location is
+//     likely to differ from that implied by reading the code. Code that does
+//     this deliberately can ignore Diagnostics with this Rule.
+//   - repeated-sequence: a run of at least 4 instructions, including PUSHed
+//     values, that appears at least 3 times without overlap and without any
+//     JUMPDEST, JUMPI, or halting opcode; i.e. a candidate for extraction into
+//     a JUMP-based subroutine to reduce code size. Only the longest such runs
+//     are reported, not their subsequences.
+func (c Code) Analyze() ([]Diagnostic, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	diags := lint(disassemble(comp.bytecode))
+	for _, a := range analyses {
+		for _, d := range a.run(c, comp) {
+			d.Rule = a.name
+			diags = append(diags, d)
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].PC < diags[j].PC
+	})
+	return diags, nil
+}
+
+// An analysis inspects Code, along with its compilation, returning Diagnostics
+// with all but the Rule populated.
+type analysis struct {
+	name string
+	run  func(Code, *compilation) []Diagnostic
+}
+
+var analyses = []analysis{
+	{
+		name: "fn-without-function",
+		run:  analyseFnWithoutFunction,
+	},
+	{
+		name: "infinite-loop",
+		run:  analyseInfiniteLoops,
+	},
+	{
+		name: "tag-in-fn",
+		run:  analyseTagsInFn,
+	},
+	{
+		name: "repeated-sequence",
+		run:  analyseRepeatedSequences,
+	},
+}
+
+// analyseFnWithoutFunction reports every Fn() with arguments for which the
+// first Bytecoder isn't an opcode that pops from the stack. The Diagnostic PC
+// is that at which the Fn()'s code begins.
+func analyseFnWithoutFunction(c Code, comp *compilation) []Diagnostic {
+	var diags []Diagnostic
+	walkFlattened(c, func(bc types.Bytecoder, flatIdx int) {
+		f, ok := bc.(fnCall)
+		if !ok || len(f) < 2 || consumesArgs(f[0]) {
+			return
+		}
+		diags = append(diags, Diagnostic{
+			PC:      comp.pcOf(flatIdx),
+			Message: fmt.Sprintf("Fn() beginning with %s doesn't consume its arguments; use Pipe() if intentional", bytecoderString(f[0])),
+		})
+	})
+	return diags
+}
+
+// CheckFnArity is an opt-in check, separate from Analyze(), that returns a
+// Diagnostic, with Rule "fn-arity", for every Fn() beginning with an opcode
+// that pops from the stack, other than a DUP or SWAP, for which the remaining
+// arguments leave a different number of values on the stack to the number
+// popped; e.g. Fn(MSTORE, PUSH0) or Fn(ADD, PUSH(1), PUSH(2), PUSH(3)). Nested
+// Fn()s are accounted for by their net effect on the stack. Diagnostics are
+// ordered by PC, which is that at which the Fn()'s code begins.
+//
+// Values may be deliberately "piped" to an Fn() from earlier code, e.g.
+// Fn(RETURN, PUSH0) with the size already on the stack, so too few arguments
+// aren't necessarily a bug, which is why the check is opt-in. Fn()s with
+// arguments that have an unknowable effect on the stack, such as a JUMPDEST
+// or a stack.SetDepth(), are skipped. CheckFnArity only returns an error if
+// compilation fails.
+func (c Code) CheckFnArity() ([]Diagnostic, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	diags := analyseFnArity(c, comp)
+	for i := range diags {
+		diags[i].Rule = "fn-arity"
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].PC < diags[j].PC
+	})
+	return diags, nil
+}
+
+// analyseFnArity implements Code.CheckFnArity(), returning Diagnostics without
+// their Rule.
+func analyseFnArity(c Code, comp *compilation) []Diagnostic {
+	var diags []Diagnostic
+	walkFlattened(c, func(bc types.Bytecoder, flatIdx int) {
+		f, ok := bc.(fnCall)
+		if !ok || len(f) == 0 {
+			return
+		}
+		op, ok := f[0].(types.OpCode)
+		if !ok || !consumesArgs(op) || isDupOrSwap(vm.OpCode(op)) {
+			return
+		}
+		d := stackDeltas[vm.OpCode(op)]
+		got, ok := netArgValues(Code(f[1:]))
+		if !ok || got == int(d.pop) {
+			return
+		}
+		diags = append(diags, Diagnostic{
+			PC:      comp.pcOf(flatIdx),
+			Message: fmt.Sprintf("Fn(%v, …) expects %d argument value(s); got %d", op, d.pop, got),
+		})
+	})
+	return diags
+}
+
+// netArgValues returns the net number of values that the Code leaves on the
+// stack, and true, or false if this is unknowable; e.g. if it includes a
+// JUMPDEST or explicitly sets the stack depth.
+func netArgValues(c Code) (int, bool) {
+	var n int
+	for _, bc := range c.flatten() {
+		switch bc := bc.(type) {
+		case JUMPDEST, stack.SetDepth, retainDepth:
+			return 0, false
+
+		case Label:
+
+		case pushTag, pushTags, pushSize, pushWide, pushLinked, stack.Ref:
+			n++
+
+		case Inverted:
+			n += netDepthChange(vm.OpCode(bc))
+
+		case stack.FromTop:
+			n += netDepthChange(vm.OpCode(bc))
+
+		case stack.FromBottom:
+			n += netDepthChange(vm.OpCode(bc))
+
+		default:
+			code, err := bc.Bytecode()
+			if err != nil {
+				// Compiler hints, which don't modify the stack.
+				continue
+			}
+			for i := 0; i < len(code); i++ {
+				op := vm.OpCode(code[i])
+				if _, ok := stackDeltas[op]; !ok {
+					return 0, false
+				}
+				n += netDepthChange(op)
+				if op.IsPush() {
+					i += int(op - vm.PUSH0)
+				}
+			}
+		}
+	}
+	return n, true
+}
+
+// analyseInfiniteLoops reports every unconditional JUMP back to a JUMPDEST from
+// which there is no way out of the loop. The Diagnostic PC is that of the JUMP.
+func analyseInfiniteLoops(_ Code, comp *compilation) []Diagnostic {
+	names := make(map[int]string)
+	for name, pc := range comp.labels() {
+		names[pc] = name
+	}
+
+	instrs := disassemble(comp.bytecode)
+	idxOf := make(map[int]int) // PC to index in instrs
+	for i, in := range instrs {
+		idxOf[in.pc] = i
+	}
+
+	var diags []Diagnostic
+	for i, in := range instrs {
+		if in.op != vm.JUMP || i == 0 || !instrs[i-1].op.IsPush() {
+			continue
+		}
+		dest := new(uint256.Int).SetBytes(instrs[i-1].data)
+		if !dest.IsUint64() || dest.Uint64() > uint64(in.pc) {
+			continue
+		}
+		start, ok := idxOf[int(dest.Uint64())]
+		if !ok || instrs[start].op != vm.JUMPDEST || !loopsForever(instrs[start+1:i-1]) {
+			continue
+		}
+
+		target := fmt.Sprintf("JUMPDEST at pc %d", instrs[start].pc)
+		if name, ok := names[instrs[start].pc]; ok {
+			target = fmt.Sprintf("JUMPDEST(%q)", name)
+		}
+		diags = append(diags, Diagnostic{
+			PC:      in.pc,
+			Message: fmt.Sprintf("unconditional JUMP back to %s without any way to exit the loop", target),
+		})
+	}
+	return diags
+}
+
+// loopsForever returns whether the body of a loop, excluding the JUMPDEST and
+// the PUSH + JUMP back to it, lacks any instruction that could exit the loop.
+func loopsForever(body []instruction) bool {
+	for _, in := range body {
+		if _, ok := stackDeltas[in.op]; !ok {
+			return false // invalid opcodes halt
+		}
+		if in.op == vm.JUMPI || isTerminal(in.op) || in.truncated {
+			return false
+		}
+	}
+	return true
+}
+
+// pcOf returns the offset in the compiled bytecode of the flattened Code's
+// element at the index, or the length of the bytecode if it is out of range.
+func (c *compilation) pcOf(flatIdx int) int {
+	if flatIdx < len(c.pcs) {
+		return c.pcs[flatIdx]
+	}
+	return len(c.bytecode)
+}
+
+// consumesArgs returns whether bc is an opcode that pops at least one value
+// from the stack.
+func consumesArgs(bc types.Bytecoder) bool {
+	var op vm.OpCode
+	switch bc := bc.(type) {
+	case types.OpCode:
+		op = vm.OpCode(bc)
+	case Inverted:
+		// The specific DUP/SWAP<N> is irrelevant as they all pop.
+		op = vm.OpCode(bc)
+	case stack.FromTop:
+		op = vm.OpCode(bc)
+	case stack.FromBottom:
+		op = vm.OpCode(bc)
+	default:
+		return false
+	}
+	d, ok := stackDeltas[op]
+	return ok && d.pop > 0
+}
+
+// analyseTagsInFn reports every JUMPDEST and Label within the arguments of an
+// Fn() or Pipe(), including those nested in further Fn()s, which are only
+// reported once. The Diagnostic PC is that of the tag.
+func analyseTagsInFn(c Code, comp *compilation) []Diagnostic {
+	var diags []Diagnostic
+	reported := make(map[int]bool) // flattened index of tag
+	walkFlattened(c, func(bc types.Bytecoder, flatIdx int) {
+		var f fnCall
+		switch bc := bc.(type) {
+		case fnCall:
+			f = bc
+		case pipeCall:
+			f = bc.fnCall
+		default:
+			return
+		}
+
+		walkFlattened(Code(f.Bytecoders()), func(bc types.Bytecoder, idx int) {
+			switch bc.(type) {
+			case JUMPDEST, Label:
+			default:
+				return
+			}
+			if idx += flatIdx; !reported[idx] {
+				reported[idx] = true
+				diags = append(diags, Diagnostic{
+					PC:      comp.pcOf(idx),
+					Message: fmt.Sprintf("%s within Fn() arguments, which are emitted in reverse order", bytecoderString(bc)),
+				})
+			}
+		})
+	})
+	return diags
+}
+
+const (
+	minRepeatedSeqLen = 4
+	maxRepeatedSeqLen = 32
+	minSeqRepeats     = 3
+)
+
+// analyseRepeatedSequences reports every sequence of instructions that is
+// repeated often enough to be worth extracting into a subroutine, longest
+// first, excluding those that are part of an already-reported sequence. The
+// Diagnostic PC is that of the sequence's first occurrence.
+func analyseRepeatedSequences(_ Code, comp *compilation) []Diagnostic {
+	instrs := disassemble(comp.bytecode)
+	extractable := func(in instruction) bool {
+		_, ok := stackDeltas[in.op]
+		return ok && !in.truncated && in.op != vm.JUMPDEST && in.op != vm.JUMPI && !isTerminal(in.op)
+	}
+	// usable[i] is the number of consecutive extractable instructions from i
+	// onwards, excluding those already reported.
+	usable := make([]int, len(instrs)+1)
+	recount := func() {
+		for i := len(instrs) - 1; i >= 0; i-- {
+			if usable[i] > 0 {
+				usable[i] = usable[i+1] + 1
+			}
+		}
+	}
+	for i, in := range instrs {
+		if extractable(in) {
+			usable[i] = 1
+		}
+	}
+	recount()
+
+	var diags []Diagnostic
+	for n := maxRepeatedSeqLen; n >= minRepeatedSeqLen; n-- {
+		var keys []string
+		starts := make(map[string][]int) // indices into instrs
+		for i := 0; i+n <= len(instrs); i++ {
+			if usable[i] < n {
+				continue
+			}
+			last := instrs[i+n-1]
+			k := string(comp.bytecode[instrs[i].pc : last.pc+1+len(last.data)])
+			if _, ok := starts[k]; !ok {
+				keys = append(keys, k)
+			}
+			if s := starts[k]; len(s) == 0 || s[len(s)-1]+n <= i {
+				starts[k] = append(s, i)
+			}
+		}
+
+		for _, k := range keys {
+			// Earlier keys of the same length may have overlapped.
+			s := slices.DeleteFunc(starts[k], func(start int) bool {
+				return slices.Contains(usable[start:start+n], 0)
+			})
+			if len(s) < minSeqRepeats {
+				continue
+			}
+			pcs := make([]int, len(s))
+			for j, start := range s {
+				pcs[j] = instrs[start].pc
+				for x := start; x < start+n; x++ {
+					usable[x] = 0
+				}
+			}
+			seq := make([]string, n)
+			for j, in := range instrs[s[0] : s[0]+n] {
+				seq[j] = in.String()
+			}
+			diags = append(diags, Diagnostic{
+				PC:      pcs[0],
+				Message: fmt.Sprintf("sequence of %d instructions [%s] repeated %d times, at PCs %d; consider extracting it into a subroutine", n, strings.Join(seq, " "), len(s), pcs),
+			})
+		}
+		recount()
+	}
+	return diags
+}
+
+// walkFlattened calls visit for every Bytecoder in c, recursing into
+// BytecodeHolders in the same manner as Code.flatten(). The index passed to
+// visit is that of the first element of the flattened Code to be derived from
+// bc, which is equal to the length of the flattened Code so far if bc
+// produces nothing.
+func walkFlattened(c Code, visit func(bc types.Bytecoder, flatIdx int)) {
+	var walk func([]types.Bytecoder, int) int
+	walk = func(bcs []types.Bytecoder, idx int) int {
+		for _, bc := range bcs {
+			visit(bc, idx)
+			if h, ok := bc.(types.BytecodeHolder); ok {
+				idx = walk(h.Bytecoders(), idx)
+			} else {
+				idx++
+			}
+		}
+		return idx
+	}
+	walk(c, 0)
+}
@@ -0,0 +1,375 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestAnalyzeFnWithoutFunction(t *testing.T) {
+	const rule = "fn-without-function"
+
+	tests := []struct {
+		name string
+		code Code
+		want []Diagnostic
+	}{
+		{
+			name: "function-like opcode",
+			code: Code{Fn(MSTORE, PUSH0, CALLVALUE)},
+		},
+		{
+			name: "no arguments",
+			code: Code{Fn(CALLVALUE), POP},
+		},
+		{
+			name: "Inverted",
+			code: Code{PUSH0, PUSH(1), Fn(Inverted(SWAP1), Fn(ADD, Inverted(DUP1)))},
+		},
+		{
+			name: "non-consuming opcode",
+			code: Code{
+				PUSH0,                // 0
+				Fn(CALLER, PUSH(42)), // 1
+			},
+			want: []Diagnostic{{
+				PC:      1,
+				Rule:    rule,
+				Message: "Fn() beginning with CALLER doesn't consume its arguments; use Pipe() if intentional",
+			}},
+		},
+		{
+			name: "non-opcode",
+			code: Code{
+				JUMPDEST("x"), stack.SetDepth(0), // 0
+				Fn(JUMPDEST("y"), stack.SetDepth(0), PUSH("x")), // 1, 2
+			},
+			want: []Diagnostic{
+				{
+					PC:      1,
+					Rule:    rule,
+					Message: `Fn() beginning with JUMPDEST("y") doesn't consume its arguments; use Pipe() if intentional`,
+				},
+				{
+					PC:      2,
+					Rule:    "tag-in-fn",
+					Message: `JUMPDEST("y") within Fn() arguments, which are emitted in reverse order`,
+				},
+			},
+		},
+		{
+			name: "nested and after PUSH of label",
+			code: Code{
+				Fn(JUMP, PUSH("end")), // 0, 2
+				Code{
+					Fn(ADD, Fn(CALLER, CALLVALUE), PUSH0), // 3: PUSH0, CALLVALUE, CALLER, ADD
+				},
+				JUMPDEST("end"), stack.SetDepth(0),
+			},
+			want: []Diagnostic{{
+				PC:      4,
+				Rule:    rule,
+				Message: "Fn() beginning with CALLER doesn't consume its arguments; use Pipe() if intentional",
+			}},
+		},
+		{
+			name: "Pipe",
+			code: Code{Pipe(CALLER, PUSH(42))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Analyze()
+			if err != nil {
+				t.Fatalf("%T.Analyze() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.Analyze() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
+
+func TestCheckFnArity(t *testing.T) {
+	const rule = "fn-arity"
+
+	tests := []struct {
+		name string
+		code Code
+		want []Diagnostic
+	}{
+		{
+			name: "exact",
+			code: Code{
+				CALLVALUE, stack.Name("x"),
+				Fn(MSTORE, PUSH0, Fn(ADD, CALLER, stack.Ref("x"))),
+				Fn(RETURN, PUSH0, PUSH(32)),
+			},
+		},
+		{
+			name: "too few",
+			code: Code{
+				CALLER,
+				Fn(MSTORE, PUSH0), // 1: PUSH0, MSTORE
+			},
+			want: []Diagnostic{{
+				PC:      1,
+				Rule:    rule,
+				Message: "Fn(MSTORE, …) expects 2 argument value(s); got 1",
+			}},
+		},
+		{
+			name: "too many via nested Fn",
+			code: Code{
+				Fn(ADD, PUSH(1), Pipe(CALLER, CALLVALUE)), // 0: CALLVALUE, CALLER, PUSH1 1, ADD
+				POP, POP,
+			},
+			want: []Diagnostic{{
+				PC:      0,
+				Rule:    rule,
+				Message: "Fn(ADD, …) expects 2 argument value(s); got 3",
+			}},
+		},
+		{
+			name: "non-consuming, DUP, and unknowable skipped",
+			code: Code{
+				Pipe(CALLER, CALLVALUE),
+				Fn(DUP1, PUSH0),
+				Fn(JUMPI, PUSH("x"), Code{JUMPDEST("y"), stack.SetDepth(3)}),
+				JUMPDEST("x"), stack.SetDepth(3),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.CheckFnArity()
+			if err != nil {
+				t.Fatalf("%T.CheckFnArity() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.CheckFnArity() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
+
+func TestAnalyzeInfiniteLoop(t *testing.T) {
+	const rule = "infinite-loop"
+
+	tests := []struct {
+		name string
+		code Code
+		want []Diagnostic
+	}{
+		{
+			name: "no exit",
+			code: Code{
+				JUMPDEST("loop"), stack.SetDepth(0), // 0
+				PUSH(1), POP, // 1, 3
+				Fn(JUMP, PUSH("loop")), // 4 (PUSH0), 5
+			},
+			want: []Diagnostic{{
+				PC:      5,
+				Rule:    rule,
+				Message: `unconditional JUMP back to JUMPDEST("loop") without any way to exit the loop`,
+			}},
+		},
+		{
+			name: "empty body after other code",
+			code: Code{
+				CALLVALUE, POP, // 0, 1
+				JUMPDEST("spin"), stack.SetDepth(0), // 2
+				Fn(JUMP, PUSH("spin")), // 3, 5
+			},
+			want: []Diagnostic{{
+				PC:      5,
+				Rule:    rule,
+				Message: `unconditional JUMP back to JUMPDEST("spin") without any way to exit the loop`,
+			}},
+		},
+		{
+			name: "conditional exit",
+			code: Code{
+				JUMPDEST("loop"), stack.SetDepth(0),
+				Fn(JUMPI, PUSH("end"), CALLVALUE),
+				Fn(JUMP, PUSH("loop")),
+				JUMPDEST("end"), stack.SetDepth(0),
+				STOP,
+			},
+		},
+		{
+			name: "conditional loop",
+			code: Code{
+				JUMPDEST("loop"), stack.SetDepth(0),
+				Fn(JUMPI, PUSH("loop"), CALLVALUE),
+				STOP,
+			},
+		},
+		{
+			name: "forward jump",
+			code: Code{
+				Fn(JUMP, PUSH("end")),
+				JUMPDEST("end"), stack.SetDepth(0),
+				STOP,
+			},
+		},
+		{
+			name: "computed destination",
+			code: Code{
+				JUMPDEST("loop"), stack.SetDepth(0),
+				PUSH("loop"), DUP1, POP, JUMP,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Analyze()
+			if err != nil {
+				t.Fatalf("%T.Analyze() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.Analyze() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTagInFn(t *testing.T) {
+	const rule = "tag-in-fn"
+
+	tests := []struct {
+		name string
+		code Code
+		want []Diagnostic
+	}{
+		{
+			name: "outside Fn",
+			code: Code{
+				Fn(JUMP, PUSH("end")),
+				Label("mid"),
+				JUMPDEST("end"), stack.SetDepth(0),
+			},
+		},
+		{
+			name: "JUMPDEST argument",
+			code: Code{
+				Fn(JUMPI, PUSH("x"), Code{JUMPDEST("x"), stack.SetDepth(0), CALLVALUE}), // 0 (JUMPDEST), 1, 2, 3
+			},
+			want: []Diagnostic{{
+				PC:      0,
+				Rule:    rule,
+				Message: `JUMPDEST("x") within Fn() arguments, which are emitted in reverse order`,
+			}},
+		},
+		{
+			name: "nested Label in Pipe",
+			code: Code{
+				Pipe(CALLER, Fn(ISZERO, Code{PUSH0, Label("l")}), PUSH("l")), // 0, 2, 3, 4
+				POP, POP,
+			},
+			want: []Diagnostic{{
+				PC:      3,
+				Rule:    rule,
+				Message: `Label("l") within Fn() arguments, which are emitted in reverse order`,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Analyze()
+			if err != nil {
+				t.Fatalf("%T.Analyze() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.Analyze() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
+
+func TestAnalyzeIncludesLint(t *testing.T) {
+	code := Code{Fn(MSTORE8, PUSH0, PUSH(0x1234))}
+
+	want, err := code.Lint()
+	if err != nil {
+		t.Fatalf("%T.Lint() error %v", code, err)
+	}
+	got, err := code.Analyze()
+	if err != nil {
+		t.Fatalf("%T.Analyze() error %v", code, err)
+	}
+	if len(want) == 0 {
+		t.Fatalf("%T.Lint() returned no Diagnostics; bad test setup", code)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%T.Analyze() diff (-Lint() +got):\n%s", code, diff)
+	}
+}
+
+func TestAnalyzeRepeatedSequence(t *testing.T) {
+	const rule = "repeated-sequence"
+
+	// 4 instructions, 6 bytes
+	double := Code{PUSH(2), MUL, PUSH(1), ADD}
+
+	tests := []struct {
+		name string
+		code Code
+		want []Diagnostic
+	}{
+		{
+			name: "too few repeats",
+			code: Code{PUSH0, double, double, STOP},
+		},
+		{
+			name: "too short",
+			code: Code{PUSH0, Fn(MUL, PUSH(2)), Fn(MUL, PUSH(2)), Fn(MUL, PUSH(2)), STOP},
+		},
+		{
+			name: "repeated",
+			code: Code{PUSH0, double, CALLVALUE, double, double, STOP}, // 1, 8, 14
+			want: []Diagnostic{{
+				PC:      1,
+				Rule:    rule,
+				Message: "sequence of 4 instructions [PUSH1 0x02 MUL PUSH1 0x01 ADD] repeated 3 times, at PCs [1 8 14]; consider extracting it into a subroutine",
+			}},
+		},
+		{
+			name: "separated by JUMPDEST",
+			code: Code{
+				PUSH0,
+				PUSH(2), MUL, JUMPDEST("a"), stack.SetDepth(1), PUSH(1), ADD,
+				PUSH(2), MUL, JUMPDEST("b"), stack.SetDepth(1), PUSH(1), ADD,
+				PUSH(2), MUL, JUMPDEST("c"), stack.SetDepth(1), PUSH(1), ADD,
+				STOP,
+			},
+		},
+		{
+			name: "longest only",
+			code: Code{PUSH0, double, double, CALLVALUE, double, double, CALLVALUE, double, double, STOP}, // 1, 14, 27
+			want: []Diagnostic{{
+				PC:      1,
+				Rule:    rule,
+				Message: "sequence of 8 instructions [PUSH1 0x02 MUL PUSH1 0x01 ADD PUSH1 0x02 MUL PUSH1 0x01 ADD] repeated 3 times, at PCs [1 14 27]; consider extracting it into a subroutine",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Analyze()
+			if err != nil {
+				t.Fatalf("%T.Analyze() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.Analyze() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
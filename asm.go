@@ -0,0 +1,263 @@
+package specops
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// ParseAssembly parses a minimal text-assembly format into Code, with one
+// instruction per line. Blank lines and anything following `//` are ignored.
+//
+// An instruction is either an opcode mnemonic (e.g. ADD, DUP1, PUSH0) or PUSH
+// followed by a value, in decimal or 0x-prefixed hex. PUSH<n> is accepted as an
+// alias for PUSH, provided that the value fits in n bytes, but the compiled
+// width is always minimal, as with PUSH().
+//
+// The following directives are also supported, with case-sensitive names:
+//   - JUMPDEST <name> and LABEL <name>: JUMPDEST(name) and Label(name);
+//   - PUSH @<name> [@<name>...]: PUSH(name), or PUSH([]string{...}) if more
+//     than one name is provided;
+//   - PUSHWIDE @<name>: PUSHWide(name);
+//   - PUSHSIZE <a> <b>: PUSHSize(a, b);
+//   - SETDEPTH <n> and EXPECTDEPTH <n>: stack.SetDepth(n) and
+//     stack.ExpectDepth(n); and
+//   - RAW <0x-prefixed hex>: Raw bytes.
+//
+// A JUMPDEST without a name is the opcode itself.
+func ParseAssembly(src string) (Code, error) {
+	var code Code
+	for i, line := range strings.Split(src, "\n") {
+		bc, err := parseAssemblyLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if bc != nil {
+			code = append(code, bc)
+		}
+	}
+	return code, nil
+}
+
+// parseAssemblyLine parses a single line as described by ParseAssembly(),
+// returning a nil Bytecoder if the line is empty.
+func parseAssemblyLine(line string) (types.Bytecoder, error) {
+	if i := strings.Index(line, "//"); i != -1 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	mnemonic := strings.ToUpper(fields[0])
+	args := fields[1:]
+
+	if bc, ok, err := parseAssemblyDirective(mnemonic, args); ok || err != nil {
+		return bc, err
+	}
+
+	width := 32
+	switch {
+	case mnemonic == "PUSH":
+	case mnemonic == "PUSH0":
+		width = 0
+	case strings.HasPrefix(mnemonic, "PUSH"):
+		op := vm.StringToOp(mnemonic)
+		if !op.IsPush() {
+			return nil, fmt.Errorf("unknown opcode %q", fields[0])
+		}
+		width = int(op - vm.PUSH0)
+	default:
+		op := vm.StringToOp(mnemonic)
+		if op == vm.STOP && mnemonic != vm.STOP.String() {
+			return nil, fmt.Errorf("unknown opcode %q", fields[0])
+		}
+		if len(args) != 0 {
+			return nil, fmt.Errorf("%s takes no arguments; got %q", mnemonic, args)
+		}
+		return types.OpCode(op), nil
+	}
+
+	if width == 0 {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("PUSH0 takes no arguments; got %q", args)
+		}
+		return PUSH0, nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s takes exactly 1 argument; got %d", mnemonic, len(args))
+	}
+	v, err := parseAssemblyValue(args[0], width)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", mnemonic, err)
+	}
+	return PUSH(*v), nil
+}
+
+// parseAssemblyDirective parses the directives described by ParseAssembly(),
+// returning false if the mnemonic and arguments aren't one.
+func parseAssemblyDirective(mnemonic string, args []string) (types.Bytecoder, bool, error) {
+	nArgs := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%s takes exactly %d argument(s); got %d", mnemonic, n, len(args))
+		}
+		return nil
+	}
+
+	switch mnemonic {
+	case "JUMPDEST":
+		if len(args) == 0 {
+			return nil, false, nil // the opcode
+		}
+		if err := nArgs(1); err != nil {
+			return nil, true, err
+		}
+		return JUMPDEST(args[0]), true, nil
+
+	case "LABEL":
+		if err := nArgs(1); err != nil {
+			return nil, true, err
+		}
+		return Label(args[0]), true, nil
+
+	case "PUSH":
+		if len(args) == 0 || !strings.HasPrefix(args[0], "@") {
+			return nil, false, nil // a value
+		}
+		names := make([]string, len(args))
+		for i, a := range args {
+			if !strings.HasPrefix(a, "@") || len(a) == 1 {
+				return nil, true, fmt.Errorf("PUSH of labels requires all arguments to be @-prefixed names; got %q", a)
+			}
+			names[i] = a[1:]
+		}
+		if len(names) == 1 {
+			return PUSH(names[0]), true, nil
+		}
+		return PUSH(names), true, nil
+
+	case "PUSHWIDE":
+		if err := nArgs(1); err != nil {
+			return nil, true, err
+		}
+		if a := args[0]; !strings.HasPrefix(a, "@") || len(a) == 1 {
+			return nil, true, fmt.Errorf("PUSHWIDE requires an @-prefixed name; got %q", a)
+		}
+		return PUSHWide(args[0][1:]), true, nil
+
+	case "PUSHSIZE":
+		if err := nArgs(2); err != nil {
+			return nil, true, err
+		}
+		return PUSHSize(args[0], args[1]), true, nil
+
+	case "SETDEPTH", "EXPECTDEPTH":
+		if err := nArgs(1); err != nil {
+			return nil, true, err
+		}
+		d, err := strconv.ParseUint(args[0], 0, 0)
+		if err != nil {
+			return nil, true, fmt.Errorf("%s: %v", mnemonic, err)
+		}
+		if mnemonic == "SETDEPTH" {
+			return stack.SetDepth(d), true, nil
+		}
+		return stack.ExpectDepth(d), true, nil
+
+	case "RAW":
+		if err := nArgs(1); err != nil {
+			return nil, true, err
+		}
+		b, err := hexutil.Decode(args[0])
+		if err != nil {
+			return nil, true, fmt.Errorf("RAW: %v", err)
+		}
+		return Raw(b), true, nil
+	}
+	return nil, false, nil
+}
+
+// Marshal returns the Code in the text-assembly format accepted by
+// ParseAssembly(), one instruction per line, such that Unmarshal() of the
+// output compiles to the same bytecode as c. Fn() and Pipe() are flattened, in
+// the order in which their arguments are emitted, and the PUSHing of constants
+// is always rendered as PUSH with a hex value.
+//
+// Marshal returns an error if the Code contains any Bytecoder without an
+// equivalent in the text format, e.g. compiler hints other than SetDepth and
+// ExpectDepth, or Bytecoders from outside this package.
+func (c Code) Marshal() ([]byte, error) {
+	var out strings.Builder
+	for i, bc := range c.flatten() {
+		var line string
+		switch bc := bc.(type) {
+		case types.OpCode:
+			line = vm.OpCode(bc).String()
+		case types.StackPusher:
+			v := new(uint256.Int).SetBytes(bc.ToPush())
+			line = "PUSH " + v.Hex()
+		case JUMPDEST:
+			line = "JUMPDEST " + string(bc)
+		case Label:
+			line = "LABEL " + string(bc)
+		case pushTag:
+			line = "PUSH @" + string(bc)
+		case pushTags:
+			names := make([]string, len(bc))
+			for i, t := range bc {
+				names[i] = "@" + string(t)
+			}
+			line = "PUSH " + strings.Join(names, " ")
+		case pushWide:
+			line = "PUSHWIDE @" + string(bc)
+		case pushSize:
+			line = fmt.Sprintf("PUSHSIZE %s %s", bc[0], bc[1])
+		case stack.SetDepth:
+			line = fmt.Sprintf("SETDEPTH %d", bc)
+		case stack.ExpectDepth:
+			line = fmt.Sprintf("EXPECTDEPTH %d", bc)
+		case Raw:
+			if len(bc) == 0 {
+				continue
+			}
+			line = "RAW " + hexutil.Encode(bc)
+		default:
+			return nil, fmt.Errorf("%T[%d]: %s has no text-assembly equivalent", c, i, bytecoderString(bc))
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return []byte(out.String()), nil
+}
+
+// Unmarshal is equivalent to ParseAssembly(string(b)), typically used to
+// reload the output of Code.Marshal().
+func Unmarshal(b []byte) (Code, error) {
+	return ParseAssembly(string(b))
+}
+
+// parseAssemblyValue parses s as a non-negative integer, in decimal or
+// 0x-prefixed hex, that fits in the number of bytes.
+func parseAssemblyValue(s string, bytes int) (*uint256.Int, error) {
+	b, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	if b.Sign() < 0 {
+		return nil, fmt.Errorf("negative value %q", s)
+	}
+	if b.BitLen() > 8*bytes {
+		return nil, fmt.Errorf("value %q exceeds %d bytes", s, bytes)
+	}
+	v, _ := uint256.FromBig(b) // overflow precluded by BitLen() check
+	return v, nil
+}
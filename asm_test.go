@@ -0,0 +1,162 @@
+package specops
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestParseAssembly(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Code
+	}{
+		{
+			name: "empty",
+			src:  "\n  \n// nothing to see here\n",
+		},
+		{
+			name: "opcodes",
+			src:  "PUSH0\nDUP1\nadd // case insensitive\nSTOP",
+			want: Code{PUSH0, DUP1, ADD, STOP},
+		},
+		{
+			name: "push values",
+			src:  "PUSH 42\n PUSH 0x0102 \nPUSH1 0xff\nPUSH32 0",
+			want: Code{PUSH(42), PUSH(0x0102), PUSH(0xff), PUSH(0)},
+		},
+		{
+			name: "directives",
+			src: `
+PUSH @end
+jump
+LABEL data
+RAW 0xdecafbad
+JUMPDEST end
+SETDEPTH 0
+PUSH @data @end
+PUSHSIZE data end
+EXPECTDEPTH 2
+JUMPDEST // the opcode`,
+			want: Code{
+				Fn(JUMP, PUSH("end")),
+				Label("data"),
+				Raw{0xde, 0xca, 0xfb, 0xad},
+				JUMPDEST("end"), stack.SetDepth(0),
+				PUSH([]string{"data", "end"}),
+				PUSHSize("data", "end"),
+				stack.ExpectDepth(2),
+				Raw{byte(vm.JUMPDEST)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAssembly(tt.src)
+			if err != nil {
+				t.Fatalf("ParseAssembly(%q) error %v", tt.src, err)
+			}
+			gotBuf, err := got.Compile()
+			if err != nil {
+				t.Fatalf("ParseAssembly(%q).Compile() error %v", tt.src, err)
+			}
+			wantBuf, err := tt.want.Compile()
+			if err != nil {
+				t.Fatalf("%T.Compile() error %v", tt.want, err)
+			}
+			if !bytes.Equal(gotBuf, wantBuf) {
+				t.Errorf("ParseAssembly(%q).Compile() got %#x; want %#x", tt.src, gotBuf, wantBuf)
+			}
+		})
+	}
+}
+
+func TestParseAssemblyErrors(t *testing.T) {
+	tests := []struct {
+		src         string
+		errContains string
+	}{
+		{"NOPE", `line 1: unknown opcode "NOPE"`},
+		{"PUSH0\nPUSH33 1", `line 2: unknown opcode "PUSH33"`},
+		{"ADD 1", "takes no arguments"},
+		{"PUSH0 0", "takes no arguments"},
+		{"PUSH", "exactly 1 argument"},
+		{"PUSH 1 2", "exactly 1 argument"},
+		{"PUSH xyz", "invalid value"},
+		{"PUSH -1", "negative value"},
+		{"PUSH1 0x0100", "exceeds 1 bytes"},
+		{"PUSH 0x1" + strings.Repeat("0", 64), "exceeds 32 bytes"},
+		{"JUMPDEST a b", "exactly 1 argument"},
+		{"LABEL", "exactly 1 argument"},
+		{"PUSH @a b", "@-prefixed"},
+		{"PUSH @", "@-prefixed"},
+		{"PUSHSIZE a", "exactly 2 argument"},
+		{"PUSHWIDE a", "@-prefixed"},
+		{"PUSHWIDE @a @b", "exactly 1 argument"},
+		{"SETDEPTH -1", "SETDEPTH"},
+		{"EXPECTDEPTH x", "EXPECTDEPTH"},
+		{"RAW 0xz", "RAW"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseAssembly(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+			t.Errorf("ParseAssembly(%q) got err %v; want containing %q", tt.src, err, tt.errContains)
+		}
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	code := Code{
+		Fn(JUMPI, PUSH("end"), Fn(ISZERO, CALLVALUE)),
+		Fn(MSTORE, PUSH0, PUSHBytes(0, 0, 1)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+		Label("table"),
+		PUSH([]JUMPDEST{"end", "end"}),
+		PUSHSelector("foo()"),
+		PUSH(common.Address{19: 1}),
+		PUSHSize("table", "end"),
+		PUSHWide("table"),
+		Raw{},
+		Raw{0xfe},
+		JUMPDEST("end"), stack.SetDepth(0),
+		stack.ExpectDepth(0),
+		STOP,
+	}
+
+	text, err := code.Marshal()
+	if err != nil {
+		t.Fatalf("%T.Marshal() error %v", code, err)
+	}
+	t.Logf("%T.Marshal():\n%s", code, text)
+
+	got, err := Unmarshal(text)
+	if err != nil {
+		t.Fatalf("Unmarshal(%T.Marshal()) error %v", code, err)
+	}
+	gotBuf, err := got.Compile()
+	if err != nil {
+		t.Fatalf("Unmarshal(%T.Marshal()).Compile() error %v", code, err)
+	}
+	wantBuf, err := code.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", code, err)
+	}
+	if !bytes.Equal(gotBuf, wantBuf) {
+		t.Errorf("Unmarshal(%T.Marshal()).Compile() got %#x; want %#x", code, gotBuf, wantBuf)
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		code := Code{PUSH0, Inverted(DUP1)}
+		if _, err := code.Marshal(); err == nil {
+			t.Errorf("%T{..., Inverted(DUP1)}.Marshal() got nil error", code)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package specops
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/runopts"
+)
+
+// TB is the subset of testing.TB required by assertion helpers. It is defined
+// so as to avoid importing the testing package in non-test code.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Logf(format string, args ...any)
+}
+
+// AssertGasUnder runs the Code with the call data and options, reporting a test
+// failure if execution fails or uses more than maxGas. Gas is measured with
+// runopts.CaptureGasUsed() so excludes the intrinsic gas of the transaction.
+//
+// AssertGasUnder is intended for regression tests that lock in gas
+// optimisations, and logs the gas used to aid in lowering the threshold.
+func AssertGasUnder(tb TB, code Code, callData []byte, maxGas uint64, opts ...runopts.Option) {
+	tb.Helper()
+
+	gas := runopts.CaptureGasUsed()
+	if _, err := code.Run(callData, append(opts[:len(opts):len(opts)], gas)...); err != nil {
+		tb.Errorf("%T.Run(%#x) error %v", code, callData, err)
+		return
+	}
+	tb.Logf("%T.Run(%#x) used %d gas; threshold %d", code, callData, gas.Val, maxGas)
+	if gas.Val > maxGas {
+		tb.Errorf("%T.Run(%#x) used %d gas; want <= %d", code, callData, gas.Val, maxGas)
+	}
+}
+
+// AssertOps compiles the Code and reports an error to tb if the sequence of
+// opcodes differs from want, returning whether the two are equal. Immediate
+// values of PUSH<N> opcodes are ignored, but N isn't, so the width of each
+// PUSH is part of the asserted structure. The reported error includes a
+// line-by-line diff of the opcodes, in the style of cmp.Diff().
+func AssertOps(tb TB, code Code, want []vm.OpCode) bool {
+	tb.Helper()
+
+	compiled, err := code.Compile()
+	if err != nil {
+		tb.Errorf("%T.Compile() error %v", code, err)
+		return false
+	}
+	instrs := disassemble(compiled)
+	got := make([]vm.OpCode, len(instrs))
+	for i, in := range instrs {
+		got[i] = in.op
+	}
+
+	if diff := diffOps(want, got); diff != "" {
+		tb.Errorf("Compiled opcodes diff (-want +got):\n%s", diff)
+		return false
+	}
+	return true
+}
+
+// diffOps returns a line-by-line diff of the opcode sequences, based on their
+// longest common subsequence, or an empty string if they are equal.
+func diffOps(want, got []vm.OpCode) string {
+	edits := lcsEdits(want, got)
+	if len(edits) == len(want) && len(want) == len(got) {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, e := range edits {
+		var op vm.OpCode
+		if e.change == DiffRemoved {
+			op = want[e.i]
+		} else {
+			op = got[e.j]
+		}
+		b.WriteByte(e.change.marker())
+		b.WriteByte(' ')
+		b.WriteString(op.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
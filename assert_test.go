@@ -0,0 +1,134 @@
+package specops
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingTB records failures instead of reporting them.
+type recordingTB struct {
+	errs []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Logf(string, ...any) {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+var _ TB = (testing.TB)(nil)
+
+func TestAssertGasUnder(t *testing.T) {
+	code := Code{
+		PUSH0, PUSH0, ADD, POP, // 2 + 2 + 3 + 2
+		STOP,
+	}
+	const used = 9
+
+	tests := []struct {
+		code     Code
+		maxGas   uint64
+		wantFail bool
+	}{
+		{code: code, maxGas: used},
+		{code: code, maxGas: used + 1},
+		{code: code, maxGas: used - 1, wantFail: true},
+		{code: Code{INVALID}, maxGas: 1e9, wantFail: true},
+	}
+
+	for _, tt := range tests {
+		tb := new(recordingTB)
+		AssertGasUnder(tb, tt.code, nil, tt.maxGas)
+		if gotFail := len(tb.errs) > 0; gotFail != tt.wantFail {
+			t.Errorf("AssertGasUnder(%v, %d) failed = %t with %q; want %t", tt.code, tt.maxGas, gotFail, tb.errs, tt.wantFail)
+		}
+	}
+}
+
+func TestAssertOps(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, PUSH(0xdecaf)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+
+	tests := []struct {
+		name    string
+		code    Code
+		want    []vm.OpCode
+		wantErr string // empty if no error is expected
+		prefix  bool   // whether wantErr need only be a prefix of the error
+	}{
+		{
+			name: "equal",
+			code: code,
+			want: []vm.OpCode{vm.PUSH3, vm.PUSH0, vm.MSTORE, vm.PUSH1, vm.PUSH0, vm.RETURN},
+		},
+		{
+			name: "equal ignoring immediates",
+			code: Code{
+				Fn(MSTORE, PUSH0, PUSH(0xc0ffee)),
+				Fn(RETURN, PUSH0, PUSH(64)),
+			},
+			want: []vm.OpCode{vm.PUSH3, vm.PUSH0, vm.MSTORE, vm.PUSH1, vm.PUSH0, vm.RETURN},
+		},
+		{
+			name: "substitution, insertion, and deletion",
+			code: code,
+			want: []vm.OpCode{vm.PUSH2, vm.PUSH0, vm.MSTORE, vm.PUSH1, vm.RETURN, vm.STOP},
+			wantErr: `Compiled opcodes diff (-want +got):
+- PUSH2
++ PUSH3
+  PUSH0
+  MSTORE
+  PUSH1
++ PUSH0
+  RETURN
+- STOP
+`,
+		},
+		{
+			name:    "empty code",
+			code:    Code{},
+			want:    []vm.OpCode{vm.STOP},
+			wantErr: "Compiled opcodes diff (-want +got):\n- STOP\n",
+		},
+		{
+			name:    "compilation error",
+			code:    Code{Fn(JUMP, PUSH("missing"))},
+			wantErr: "specops.Code.Compile() error",
+			prefix:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := new(recordingTB)
+			got := AssertOps(tb, tt.code, tt.want)
+
+			if tt.wantErr == "" {
+				if !got || len(tb.errs) > 0 {
+					t.Errorf("AssertOps() got %t with errors %q; want true without errors", got, tb.errs)
+				}
+				return
+			}
+			if got || len(tb.errs) != 1 {
+				t.Fatalf("AssertOps() got %t with errors %q; want false with 1 error", got, tb.errs)
+			}
+			if tt.prefix {
+				if !strings.HasPrefix(tb.errs[0], tt.wantErr) {
+					t.Errorf("AssertOps() error %q; want prefix %q", tb.errs[0], tt.wantErr)
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.wantErr, tb.errs[0]); diff != "" {
+				t.Errorf("AssertOps() error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package specops
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// CompileWithAuxdata compiles the Code and appends a Solidity-style CBOR
+// metadata trailer ("auxdata") referencing the IPFS hash, for compatibility
+// with verification tooling that expects one. As with CompilePadded(), a STOP
+// is appended before the trailer if the code doesn't already end with a
+// terminating opcode, so the trailer is never executed.
+//
+// The trailer is exactly the following bytes, where N is len(ipfsHash) and L
+// is the total length of the CBOR map:
+//
+//	0xa1                        CBOR map with 1 pair
+//	0x64 "ipfs"                 text string of length 4
+//	<byte-string header for N>  0x40+N if N < 24, 0x58 N if N < 256, else 0x59 followed by N as uint16
+//	<ipfsHash>
+//	<L as big-endian uint16>
+//
+// Unlike solc, no compiler version (the "solc" key) is included. The
+// ipfsHash is typically a 34-byte multihash (0x1220 followed by a SHA-256
+// digest), in which case the trailer is 44 bytes, with L = 42. An error is
+// returned if the trailer's length can't be represented in 2 bytes.
+func (c Code) CompileWithAuxdata(ipfsHash []byte) ([]byte, error) {
+	aux, err := cborAuxdata(ipfsHash)
+	if err != nil {
+		return nil, err
+	}
+	code, err := c.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return append(terminated(code), aux...), nil
+}
+
+// CompileNoMetadata returns the compiled Code without any trailer, and is
+// guaranteed to remain equivalent to Compile() even if a trailer is ever added
+// by default. The bytes produced are exactly those of every Bytecoder in the
+// Code, in order, with labels resolved; no STOP, padding, nor metadata is
+// appended. It is intended for byte-exact reproduction of existing minimal
+// contracts, such as the EIP-1167 proxy.
+func (c Code) CompileNoMetadata() ([]byte, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	return comp.bytecode, nil
+}
+
+// cborAuxdata returns the trailer described by Code.CompileWithAuxdata().
+func cborAuxdata(ipfsHash []byte) ([]byte, error) {
+	const (
+		cborMap1   = 0xa1 // major type 5, 1 pair
+		cborText   = 0x60 // major type 3
+		cborBytes  = 0x40 // major type 2
+		cborUint8  = 24   // additional info: length in next byte
+		cborUint16 = 25   // additional info: length in next 2 bytes
+	)
+
+	buf := []byte{cborMap1, cborText | 4, 'i', 'p', 'f', 's'}
+	switch n := len(ipfsHash); {
+	case n < cborUint8:
+		buf = append(buf, cborBytes|byte(n))
+	case n <= 0xff:
+		buf = append(buf, cborBytes|cborUint8, byte(n))
+	default:
+		buf = append(buf, cborBytes|cborUint16)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	}
+	buf = append(buf, ipfsHash...)
+
+	if n := len(buf); n > 0xffff {
+		return nil, fmt.Errorf("CBOR auxdata of %d bytes exceeds maximum of %d", n, 0xffff)
+	}
+	return binary.BigEndian.AppendUint16(buf, uint16(len(buf))), nil
+}
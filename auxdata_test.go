@@ -0,0 +1,122 @@
+package specops
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestCompileWithAuxdata(t *testing.T) {
+	digest := sha256.Sum256([]byte("specops"))
+	multihash := append([]byte{0x12, 0x20}, digest[:]...)
+
+	// Using the same encoding as solc, without the "solc" version key.
+	wantIPFSTrailer := append(
+		hexutil.MustDecode("0xa1646970667358"+"22"),
+		append(multihash, 0x00, 0x2a)...,
+	)
+
+	tests := []struct {
+		name     string
+		code     Code
+		ipfsHash []byte
+		want     []byte
+	}{
+		{
+			name:     "terminated code with multihash",
+			code:     Code{RETURNDATASIZE, STOP},
+			ipfsHash: multihash,
+			want:     append([]byte{byte(vm.RETURNDATASIZE), byte(vm.STOP)}, wantIPFSTrailer...),
+		},
+		{
+			name:     "unterminated code has STOP appended",
+			code:     Code{RETURNDATASIZE},
+			ipfsHash: multihash,
+			want:     append([]byte{byte(vm.RETURNDATASIZE), byte(vm.STOP)}, wantIPFSTrailer...),
+		},
+		{
+			name:     "short hash",
+			code:     Code{INVALID},
+			ipfsHash: []byte{1, 2, 3},
+			want:     hexutil.MustDecode("0xfe" + "a1" + "6469706673" + "43" + "010203" + "000a"),
+		},
+		{
+			name:     "2-byte length",
+			code:     Code{INVALID},
+			ipfsHash: bytes.Repeat([]byte{0}, 300),
+			want: append(
+				hexutil.MustDecode("0xfe"+"a1"+"6469706673"+"59012c"),
+				append(make([]byte, 300), 0x01, 0x35)...,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.CompileWithAuxdata(tt.ipfsHash)
+			if err != nil {
+				t.Fatalf("%T.CompileWithAuxdata(%#x) error %v", tt.code, tt.ipfsHash, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.CompileWithAuxdata(%#x) diff (-want +got):\n%s", tt.code, tt.ipfsHash, diff)
+			}
+		})
+	}
+
+	t.Run("too long", func(t *testing.T) {
+		code := Code{STOP}
+		if _, err := code.CompileWithAuxdata(make([]byte, 0xffff)); err == nil {
+			t.Errorf("%T.CompileWithAuxdata([0xffff bytes]) got nil error; want non-nil", code)
+		}
+	})
+}
+
+func TestCompileNoMetadata(t *testing.T) {
+	impl := common.HexToAddress("bebebebebebebebebebebebebebebebebebebebe")
+
+	tests := []struct {
+		name string
+		code Code
+		want []byte
+	}{
+		{
+			name: "unterminated",
+			code: Code{PUSH0},
+			want: []byte{byte(vm.PUSH0)},
+		},
+		{
+			name: "EIP-1167 minimal proxy",
+			code: Code{
+				Fn(CALLDATACOPY, RETURNDATASIZE, RETURNDATASIZE, CALLDATASIZE),
+				RETURNDATASIZE,
+				Fn(DELEGATECALL, GAS, PUSH(impl), RETURNDATASIZE, CALLDATASIZE, RETURNDATASIZE, RETURNDATASIZE),
+				Fn(RETURNDATACOPY, DUP1, Inverted(DUP1), RETURNDATASIZE),
+				SWAP1, RETURNDATASIZE, SWAP2,
+				Fn(JUMPI, PUSH("return")),
+				REVERT,
+				JUMPDEST("return"), stack.SetDepth(2),
+				RETURN,
+			},
+			want: hexutil.MustDecode("0x363d3d373d3d3d363d73bebebebebebebebebebebebebebebebebebebebe5af43d82803e903d91602b57fd5bf3"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.CompileNoMetadata()
+			if err != nil {
+				t.Fatalf("%T.CompileNoMetadata() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.CompileNoMetadata() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package specops
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/arr4n/specops/internal/labels"
+)
+
+// A Section is a contiguous region of compiled bytecode, starting at a JUMPDEST
+// or Label and ending at the next one, in the same manner as
+// runopts.CaptureSectionGas(). Code preceding the first JUMPDEST or Label has
+// an empty Label.
+type Section struct {
+	Label       string
+	Start, Size int
+}
+
+// CompileWithBudget compiles the Code, returning an error if the bytecode is
+// longer than maxLen bytes. If the budget is exceeded, the bytecode is returned
+// along with the error and the Sections from which it is composed, ordered by
+// Start, to help in finding where to trim. Sections are only returned if the
+// budget is exceeded.
+func (c Code) CompileWithBudget(maxLen int) ([]byte, []Section, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+	code := comp.bytecode
+	if len(code) <= maxLen {
+		return code, nil, nil
+	}
+	return code, comp.sections(), fmt.Errorf("compiled code length %d exceeds budget of %d by %d", len(code), maxLen, len(code)-maxLen)
+}
+
+// CompileWithCodeSizeLimit is equivalent to CompileWithBudget() with the
+// EIP-170 limit of 24,576 bytes for deployed (i.e. runtime) code, above which
+// deployment fails. If the limit is exceeded, the error also lists the largest
+// Sections, which contribute most to the size.
+func (c Code) CompileWithCodeSizeLimit() ([]byte, []Section, error) {
+	return c.compileWithSizeLimit(params.MaxCodeSize, "EIP-170 code-size limit")
+}
+
+// CompileWithInitCodeSizeLimit is the initcode equivalent of
+// CompileWithCodeSizeLimit(), with the EIP-3860 limit of 49,152 bytes, above
+// which contract creation fails.
+func (c Code) CompileWithInitCodeSizeLimit() ([]byte, []Section, error) {
+	return c.compileWithSizeLimit(params.MaxInitCodeSize, "EIP-3860 initcode-size limit")
+}
+
+// maxSectionsInSizeError is the number of largest Sections listed in the error
+// returned by compileWithSizeLimit().
+const maxSectionsInSizeError = 3
+
+func (c Code) compileWithSizeLimit(limit int, name string) ([]byte, []Section, error) {
+	code, secs, err := c.CompileWithBudget(limit)
+	if err != nil && secs == nil {
+		return nil, nil, err // compilation error
+	}
+	if err == nil {
+		return code, nil, nil
+	}
+
+	largest := append([]Section{}, secs...)
+	sort.SliceStable(largest, func(i, j int) bool {
+		return largest[i].Size > largest[j].Size
+	})
+	if len(largest) > maxSectionsInSizeError {
+		largest = largest[:maxSectionsInSizeError]
+	}
+	desc := make([]string, len(largest))
+	for i, s := range largest {
+		desc[i] = fmt.Sprintf("%q at %d (%d bytes)", s.Label, s.Start, s.Size)
+	}
+	return code, secs, fmt.Errorf("compiled code length %d exceeds %s of %d by %d; largest sections: %s", len(code), name, limit, len(code)-limit, strings.Join(desc, ", "))
+}
+
+// sections returns the non-empty Sections of the compiled bytecode, ordered by
+// Start.
+func (c *compilation) sections() []Section {
+	ls := c.labels()
+	index := labels.New(ls)
+
+	starts := []int{0}
+	for _, offset := range ls {
+		starts = append(starts, offset)
+	}
+	sort.Ints(starts)
+
+	var secs []Section
+	for i, start := range starts {
+		end := len(c.bytecode)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		if end == start {
+			continue
+		}
+		name, _ := index.Nearest(uint64(start))
+		secs = append(secs, Section{
+			Label: name,
+			Start: start,
+			Size:  end - start,
+		})
+	}
+	return secs
+}
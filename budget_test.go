@@ -0,0 +1,83 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestCompileWithBudget(t *testing.T) {
+	code := Code{
+		PUSH0, POP, // 0, 1
+		Fn(JUMP, PUSH("main")),      // 2, 4
+		Label("data"), Raw("hello"), // 5
+		JUMPDEST("main"), stack.SetDepth(0), // 10
+		Fn(RETURN, PUSH0, PUSH0), // 11
+		Label("end"),
+	}
+	const size = 14
+
+	t.Run("within budget", func(t *testing.T) {
+		for _, max := range []int{size, size + 1} {
+			got, secs, err := code.CompileWithBudget(max)
+			if err != nil || len(got) != size || secs != nil {
+				t.Errorf("%T.CompileWithBudget(%d) got (%d bytes, %v, %v); want (%d bytes, nil, nil)", code, max, len(got), secs, err, size)
+			}
+		}
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		got, secs, err := code.CompileWithBudget(size - 1)
+		if err == nil {
+			t.Fatalf("%T.CompileWithBudget(%d) got nil error", code, size-1)
+		}
+		if len(got) != size {
+			t.Errorf("%T.CompileWithBudget(%d) returned %d bytes; want %d", code, size-1, len(got), size)
+		}
+
+		want := []Section{
+			{Label: "", Start: 0, Size: 5},
+			{Label: "data", Start: 5, Size: 5},
+			{Label: "main", Start: 10, Size: 4},
+		}
+		if diff := cmp.Diff(want, secs); diff != "" {
+			t.Errorf("%T.CompileWithBudget(%d) Sections diff (-want +got):\n%s", code, size-1, diff)
+		}
+	})
+}
+
+func TestCompileWithSizeLimits(t *testing.T) {
+	code := Code{
+		Label("big"), Raw(make([]byte, 20_000)),
+		Label("medium"), Raw(make([]byte, 4_000)),
+		Label("small"), Raw(make([]byte, 500)),
+		Label("tiny"), Raw(make([]byte, 100)),
+	}
+	const size = 24_600
+
+	t.Run("code size", func(t *testing.T) {
+		got, secs, err := code.CompileWithCodeSizeLimit()
+		if len(got) != size || len(secs) != 4 {
+			t.Errorf("%T.CompileWithCodeSizeLimit() got (%d bytes, %d Sections); want (%d, 4)", code, len(got), len(secs), size)
+		}
+		want := `compiled code length 24600 exceeds EIP-170 code-size limit of 24576 by 24; largest sections: "big" at 0 (20000 bytes), "medium" at 20000 (4000 bytes), "small" at 24000 (500 bytes)`
+		if err == nil || err.Error() != want {
+			t.Errorf("%T.CompileWithCodeSizeLimit() got err %v; want %q", code, err, want)
+		}
+	})
+
+	t.Run("initcode size", func(t *testing.T) {
+		got, secs, err := code.CompileWithInitCodeSizeLimit()
+		if err != nil || len(got) != size || secs != nil {
+			t.Errorf("%T.CompileWithInitCodeSizeLimit() got (%d bytes, %v, %v); want (%d bytes, nil, nil)", code, len(got), secs, err, size)
+		}
+
+		initcode := Code{Raw(make([]byte, 50_000))}
+		if _, _, err := initcode.CompileWithInitCodeSizeLimit(); err == nil || !strings.Contains(err.Error(), "exceeds EIP-3860 initcode-size limit of 49152 by 848") {
+			t.Errorf("%T.CompileWithInitCodeSizeLimit() of 50,000 bytes; got err %v", initcode, err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/arr4n/specops/runopts"
+)
+
+// Built is a handle on compiled Code, for the common pattern of compiling
+// once, inspecting the bytecode, and running it many times. See Code.Build().
+type Built struct {
+	bytecode []byte
+	hash     common.Hash
+	opts     []runopts.Option
+}
+
+// Build compiles the Code and returns a Built that runs it with the options.
+func (c Code) Build(opts ...runopts.Option) (*Built, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, fmt.Errorf("%T.Compile(): %v", c, err)
+	}
+	return &Built{
+		bytecode: comp.bytecode,
+		hash:     crypto.Keccak256Hash(comp.bytecode),
+		opts:     comp.withLabels(opts),
+	}, nil
+}
+
+// Bytecode returns a copy of the compiled bytecode.
+func (b *Built) Bytecode() []byte {
+	return common.CopyBytes(b.bytecode)
+}
+
+// Hash returns the Keccak256 hash of the compiled bytecode; i.e. the value
+// returned by EXTCODEHASH once deployed.
+func (b *Built) Hash() common.Hash {
+	return b.hash
+}
+
+// Size returns the length of the compiled bytecode.
+func (b *Built) Size() int {
+	return len(b.bytecode)
+}
+
+// Run is equivalent to Code.Run() without recompilation. The options passed to
+// Code.Build() are applied first, followed by those passed to Run().
+func (b *Built) Run(callData []byte, opts ...runopts.Option) (*core.ExecutionResult, error) {
+	all := append(b.opts[:len(b.opts):len(b.opts)], opts...)
+	return runBytecode(b.bytecode, callData, all...)
+}
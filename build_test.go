@@ -0,0 +1,70 @@
+package specops
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/arr4n/specops/runopts"
+)
+
+func TestBuild(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, CALLER),
+		Fn(RETURN, PUSH(12), PUSH(20)),
+	}
+	want, err := code.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", code, err)
+	}
+
+	built, err := code.Build(runopts.From(common.Address{'b', 'u', 'i', 'l', 't'}))
+	if err != nil {
+		t.Fatalf("%T.Build() error %v", code, err)
+	}
+
+	if got := built.Bytecode(); !bytes.Equal(got, want) {
+		t.Errorf("%T.Bytecode() got %#x; want %#x", built, got, want)
+	}
+	if got, want := built.Hash(), crypto.Keccak256Hash(want); got != want {
+		t.Errorf("%T.Hash() got %v; want %v", built, got, want)
+	}
+	if got, want := built.Size(), len(want); got != want {
+		t.Errorf("%T.Size() got %d; want %d", built, got, want)
+	}
+
+	built.Bytecode()[0]++ // MUST NOT modify the Built
+
+	tests := []struct {
+		name string
+		opts []runopts.Option
+		want common.Address
+	}{
+		{
+			name: "options from Build()",
+			want: common.Address{'b', 'u', 'i', 'l', 't'},
+		},
+		{
+			name: "options from Run() take precedence",
+			opts: []runopts.Option{runopts.From(common.Address{'r', 'u', 'n'})},
+			want: common.Address{'r', 'u', 'n'},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Repeated runs demonstrate that options don't accumulate.
+			for i := 0; i < 2; i++ {
+				res, err := built.Run(nil, tt.opts...)
+				if err != nil {
+					t.Fatalf("%T.Run() error %v", built, err)
+				}
+				if got := common.BytesToAddress(res.Return()); got != tt.want {
+					t.Errorf("%T.Run() returned CALLER %v; want %v", built, got, tt.want)
+				}
+			}
+		})
+	}
+}
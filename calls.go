@@ -0,0 +1,61 @@
+package specops
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/types"
+)
+
+// CALLChecked is equivalent to Fn(CALL, gas, addr, …) except that it first
+// REVERTs, with empty data, if there is no code at addr. This mirrors the
+// check performed by Solidity before high-level external calls, avoiding the
+// trivial success of calling an address without code (e.g. a misconfigured
+// proxy implementation).
+//
+// The addr Bytecoder is used for both the code-size check and the call so MUST
+// push exactly one value without side effects; e.g. PUSH(address) or
+// Inverted(DUP<N>). Note that a regular DUP<N> will refer to different stack
+// items in each position; see Fn() for argument ordering.
+func CALLChecked(gas, addr, value, argsOffset, argsSize, retOffset, retSize types.Bytecoder) types.BytecodeHolder {
+	return checkedCall(addr, Fn(CALL, gas, addr, value, argsOffset, argsSize, retOffset, retSize))
+}
+
+// CALLCODEChecked is the CALLCODE equivalent of CALLChecked().
+func CALLCODEChecked(gas, addr, value, argsOffset, argsSize, retOffset, retSize types.Bytecoder) types.BytecodeHolder {
+	return checkedCall(addr, Fn(CALLCODE, gas, addr, value, argsOffset, argsSize, retOffset, retSize))
+}
+
+// DELEGATECALLChecked is the DELEGATECALL equivalent of CALLChecked().
+func DELEGATECALLChecked(gas, addr, argsOffset, argsSize, retOffset, retSize types.Bytecoder) types.BytecodeHolder {
+	return checkedCall(addr, Fn(DELEGATECALL, gas, addr, argsOffset, argsSize, retOffset, retSize))
+}
+
+// STATICCALLChecked is the STATICCALL equivalent of CALLChecked().
+func STATICCALLChecked(gas, addr, argsOffset, argsSize, retOffset, retSize types.Bytecoder) types.BytecodeHolder {
+	return checkedCall(addr, Fn(STATICCALL, gas, addr, argsOffset, argsSize, retOffset, retSize))
+}
+
+// checkedCall returns the call, preceded by a check that there is code at
+// addr.
+func checkedCall(addr types.Bytecoder, call types.Bytecoder) types.BytecodeHolder {
+	return Code{
+		Fn(EXTCODESIZE, addr),
+		revertUnless(),
+		call,
+	}
+}
+
+// revertUnless returns Code that consumes the top of the stack and REVERTs,
+// with empty data, if it is zero. The jump over the REVERT is relative to the
+// PC so there are no JUMPDEST labels that would otherwise clash if the Code
+// were used more than once.
+func revertUnless() Code {
+	return Code{
+		// PUSH1 6, PC, ADD, JUMPI; i.e. jump to PC+6 if the value is non-zero.
+		Fn(JUMPI, Fn(ADD, PC, PUSH(6))),
+		Fn(REVERT, PUSH0, PUSH0),
+		// Not a specops JUMPDEST because it's never referenced by label and
+		// the stack depth is unchanged by the non-reverting branch.
+		Raw{byte(vm.JUMPDEST)},
+	}
+}
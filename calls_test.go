@@ -0,0 +1,88 @@
+package specops
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/arr4n/specops/revert"
+	"github.com/arr4n/specops/runopts"
+)
+
+func TestCheckedCalls(t *testing.T) {
+	withCode := common.Address{'c', 'o', 'd', 'e'}
+	noCode := common.Address{'n', 'o', 'n', 'e'}
+
+	const retVal = 42
+	callee, err := Code{
+		Fn(MSTORE, PUSH0, PUSH(retVal)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}.Compile()
+	if err != nil {
+		t.Fatalf("Compile() callee error %v", err)
+	}
+	alloc := runopts.GenesisAlloc(types.GenesisAlloc{
+		withCode: {Code: callee},
+	})
+
+	gas := PUSH(forwardGas)
+	returnWord := Code{
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+	callers := []struct {
+		name string
+		call func(addr common.Address) Code
+	}{
+		{
+			name: "CALLChecked",
+			call: func(a common.Address) Code {
+				return Code{CALLChecked(gas, PUSH(a), PUSH0, PUSH0, PUSH0, PUSH0, PUSH(32)), POP}
+			},
+		},
+		{
+			name: "CALLCODEChecked",
+			call: func(a common.Address) Code {
+				return Code{CALLCODEChecked(gas, PUSH(a), PUSH0, PUSH0, PUSH0, PUSH0, PUSH(32)), POP}
+			},
+		},
+		{
+			name: "DELEGATECALLChecked",
+			call: func(a common.Address) Code {
+				return Code{DELEGATECALLChecked(gas, PUSH(a), PUSH0, PUSH0, PUSH0, PUSH(32)), POP}
+			},
+		},
+		{
+			name: "STATICCALLChecked",
+			call: func(a common.Address) Code {
+				return Code{STATICCALLChecked(gas, PUSH(a), PUSH0, PUSH0, PUSH0, PUSH(32)), POP}
+			},
+		},
+	}
+
+	for _, c := range callers {
+		t.Run(c.name, func(t *testing.T) {
+			t.Run("with code", func(t *testing.T) {
+				code := Code{c.call(withCode), returnWord}
+				res, err := code.Run(nil, alloc)
+				if err != nil {
+					t.Fatalf("%T.Run() error %v", code, err)
+				}
+				want := make([]byte, 32)
+				want[31] = retVal
+				if got := res.ReturnData; !bytes.Equal(got, want) {
+					t.Errorf("%T.Run() got return data %#x; want %#x", code, got, want)
+				}
+			})
+
+			t.Run("without code", func(t *testing.T) {
+				code := Code{c.call(noCode), returnWord}
+				_, err := code.Run(nil, alloc)
+				if data, ok := revert.Data(err); !ok || len(data) != 0 {
+					t.Errorf("%T.Run() got err %v; want %T with empty data", code, err, &revert.Error{})
+				}
+			})
+		})
+	}
+}
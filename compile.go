@@ -2,9 +2,12 @@ package specops
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
+	"log/slog"
 	"math"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/core/vm"
 
@@ -22,6 +25,7 @@ func (Label) lazy()    {}
 func (pushTag) lazy()  {}
 func (pushTags) lazy() {}
 func (pushSize) lazy() {}
+func (pushWide) lazy() {}
 
 // A splice is a (possibly empty) buffer of bytecode, followed by a lazyLocator.
 // The location of a tag changes the size of pushTags{s} that refer to it, but
@@ -35,6 +39,9 @@ type splice struct {
 	// If pushTag{s}
 	tags     []*splice // All have `op` field of type `tagged`
 	reserved int       // Number of bytes reserved (including the PUSH); 1 + (1 or 2) per tag
+	// If PUSH0 is unavailable, in which case a zero value is pushed as
+	// PUSH1 0x00.
+	noPUSH0 bool
 }
 
 // setTags populates splice.tags with the each of the `tags`, sourced from the
@@ -42,12 +49,15 @@ type splice struct {
 func (s *splice) setTags(known map[tag]*splice, tags ...tag) error {
 	var wantN int
 	switch s.op.(type) {
-	case pushTag: // singular
+	case pushTag, pushWide: // singular
 		wantN = 1
 	case pushTags: // plural
 		wantN = len(tags)
 	case pushSize:
 		wantN = 2
+	case rjump, rjumpi, rjumpv:
+		_, ts, _ := asRelJump(s.op)
+		wantN = len(ts)
 	default:
 		return fmt.Errorf("BUG: %T.setTags() with unsupported %T op", s, s.op)
 	}
@@ -78,6 +88,9 @@ func (s *splice) bytesPerTag() int {
 		panic(fmt.Sprintf("BUG: %T.bytesPerTag() with %T; use bytesForSize()", s, pushSize{}))
 	}
 
+	if _, ok := s.op.(pushWide); ok {
+		return 2
+	}
 	for _, t := range s.tags {
 		if t.offset != nil && *t.offset >= 256 {
 			return 2
@@ -118,6 +131,10 @@ func (s *splice) extraBytesNeeded() int {
 	case pushSize:
 		return 1 + s.bytesForSize() - s.leadingZeroes()
 
+	case rjump, rjumpi, rjumpv:
+		op, ts, _ := asRelJump(s.op)
+		return relJumpSize(op, len(ts))
+
 	default:
 		return 1 + len(s.tags)*s.bytesPerTag() - s.leadingZeroes()
 	}
@@ -125,11 +142,34 @@ func (s *splice) extraBytesNeeded() int {
 }
 
 // leadingZeroes returns the number of bytes that PUSH() will strip from the
-// concatenated s.tags.offset values of pushTag{s}.
+// concatenated s.tags.offset values of pushTag{s}. If s.noPUSH0 is true then a
+// single zero byte is retained for a value of zero, to be pushed with PUSH1.
 func (s *splice) leadingZeroes() int {
+	n := s.allLeadingZeroes()
+	if !s.noPUSH0 || n == 0 {
+		return n
+	}
+
+	var width int
+	if _, ok := s.op.(pushSize); ok {
+		width = s.bytesForSize()
+	} else {
+		width = len(s.tags) * s.bytesPerTag()
+	}
+	if n == width {
+		return n - 1
+	}
+	return n
+}
+
+// allLeadingZeroes implements leadingZeroes(), assuming PUSH0 is available.
+func (s *splice) allLeadingZeroes() int {
 	if len(s.tags) == 0 {
 		return 0
 	}
+	if _, ok := s.op.(pushWide); ok {
+		return 0 // by definition
+	}
 
 	if _, ok := s.op.(pushSize); ok {
 		n := s.bytesForSize()
@@ -190,6 +230,13 @@ func (s *splice) leadingZeroes() int {
 type spliceConcat struct {
 	splices []*splice
 	allTags map[tag]*splice
+	// Number of passes performed by expand(), including the final one that
+	// made no changes.
+	expandPasses int
+	// If non-nil, each pass of expand() is logged.
+	log *slog.Logger
+	// If true, all splices push zero values as PUSH1 0x00.
+	noPUSH0 bool
 }
 
 // curr returns the last *splice in the spliceConcat.
@@ -207,6 +254,7 @@ func (s *spliceConcat) curr() *splice {
 func newSpliceBuffer(s *spliceConcat, op lazyLocator) (*bytes.Buffer, error) {
 	curr := s.curr()
 	curr.op = op
+	curr.noPUSH0 = s.noPUSH0
 
 	if l, ok := op.(tagged); ok {
 		if _, ok := s.allTags[l.tag()]; ok {
@@ -236,23 +284,134 @@ func (c Code) flatten() Code {
 }
 
 // Compile returns a compiled EVM contract with all special opcodes interpreted.
+// If any assertions were registered with Code.Assert(), they are all checked,
+// and their errors are returned together, as with errors.Join().
 func (c Code) Compile() ([]byte, error) {
+	return c.CompileContext(context.Background())
+}
+
+// CompileContext is equivalent to Compile() except that it returns ctx.Err() if
+// the Context is cancelled before compilation completes. Cancellation is
+// checked between passes of the (possibly quadratic) tag-expansion algorithm,
+// so is intended for protection against pathologically large Code, e.g. from
+// generators, rather than for fine-grained timing.
+func (c Code) CompileContext(ctx context.Context) ([]byte, error) {
+	comp, err := c.compileContext(ctx, compileOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return comp.bytecode, nil
+}
+
+// ExpandPasses compiles the Code and returns the number of passes that were
+// required to determine the size of every PUSH of a JUMPDEST or Label
+// location, including the final pass that made no changes. It is a diagnostic
+// for characterising the cost of compiling large or pathological Code, which
+// can grow quadratically with the number of such PUSHes.
+func (c Code) ExpandPasses() (int, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return 0, err
+	}
+	return comp.splices.expandPasses, nil
+}
+
+// Labels compiles the Code and returns the byte offset of every JUMPDEST and
+// Label, keyed by name. It is equivalent to the Labels field of the Metadata
+// returned by CompileWithMetadata().
+func (c Code) Labels() (map[string]int, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	return comp.labels(), nil
+}
+
+// A compilation carries the output of Code.compile(), along with metadata
+// derived during compilation.
+type compilation struct {
+	bytecode []byte
+	splices  *spliceConcat
+	// pcs is the offset in bytecode of each element of the flattened Code;
+	// see Code.flatten(). Elements that don't generate any bytecode have the
+	// offset of the next one that does.
+	pcs []int
+}
+
+// labels returns the byte offset of every JUMPDEST and Label, keyed by name.
+func (c *compilation) labels() map[string]int {
+	ls := make(map[string]int, len(c.splices.allTags))
+	for t, sp := range c.splices.allTags {
+		ls[string(t)] = *sp.offset
+	}
+	return ls
+}
+
+// compile implements Code.Compile(), returning the compiled bytecode along
+// with compilation metadata.
+func (c Code) compile() (*compilation, error) {
+	return c.compileContext(context.Background(), compileOptions{})
+}
+
+// CompileWithLogger is equivalent to Compile() except that it logs the
+// compiler's progress to l, at debug level: the stack depth after each
+// Bytecoder, every pass over PUSHes of JUMPDEST and Label locations, the
+// resolved location of each, and any Diagnostics that Lint() would return.
+// Compile() is equivalent to CompileWithLogger(nil), which logs nothing.
+func (c Code) CompileWithLogger(l *slog.Logger) ([]byte, error) {
+	comp, err := c.compileContext(context.Background(), compileOptions{log: l})
+	if err != nil {
+		return nil, err
+	}
+	if l != nil {
+		for _, d := range lint(disassemble(comp.bytecode)) {
+			l.Debug("diagnostic", "pc", d.PC, "rule", d.Rule, "message", d.Message)
+		}
+	}
+	return comp.bytecode, nil
+}
+
+// compileOptions modify the behaviour of Code.compileContext().
+type compileOptions struct {
+	// If non-nil, progress is logged as described by Code.CompileWithLogger().
+	log *slog.Logger
+	// If true, values resolved by the compiler (i.e. JUMPDEST and Label
+	// locations, and sizes) are never pushed with PUSH0, which would otherwise
+	// be used for zero; e.g. a JUMPDEST at the very start of the code.
+	noPUSH0 bool
+}
+
+// compileContext implements Code.CompileContext(), returning the compiled
+// bytecode along with compilation metadata.
+func (c Code) compileContext(ctx context.Context, opts compileOptions) (*compilation, error) {
 	flat := c.flatten()
+	l := opts.log
 
 	splices := &spliceConcat{
 		splices: []*splice{new(splice)},
 		allTags: make(map[tag]*splice),
+		log:     l,
+		noPUSH0: opts.noPUSH0,
 	}
 	buf := &splices.splices[0].buf
 
 	var (
 		stackDepth               uint
 		requireStackDepthSetting bool
+		assertions               []bytecodeAssertion
+		tableWidths              []tableWidth
+		names                    stackModel
+		trace                    depthTrace
+		locs                     = make([]spliceLocation, 0, len(flat))
 	)
 
 CodeLoop:
 	for i, raw := range flat {
 		use := raw
+		locs = append(locs, spliceLocation{
+			splice: len(splices.splices) - 1,
+			offset: buf.Len(),
+		})
 
 		posErr := func(format string, a ...any) error {
 			format = "%T[%d]: " + format
@@ -263,37 +422,90 @@ CodeLoop:
 		switch op := raw.(type) {
 		case stack.SetDepth:
 			stackDepth = uint(op)
+			names.setDepth(stackDepth)
+			trace.record(depthStep{desc: bytecoderString(op), set: true, depth: stackDepth})
+			if l != nil {
+				l.Debug("set depth", "index", i, "depth", stackDepth)
+			}
 			requireStackDepthSetting = false
 			continue CodeLoop
 
+		case retainDepth:
+			names.setDepth(stackDepth)
+			requireStackDepthSetting = false
+			continue CodeLoop
+
+		case stack.Model:
+			if err := names.assert(op); err != nil {
+				return nil, posErr("%v", err)
+			}
+			continue CodeLoop
+
+		case stack.Names:
+			if err := names.name(op); err != nil {
+				return nil, posErr("%v", err)
+			}
+			continue CodeLoop
+
+		case stack.Ref:
+			if op == "" {
+				return nil, posErr("%T with empty name", op)
+			}
+			idx, ok := names.find(string(op))
+			switch {
+			case !ok:
+				return nil, posErr("%T(%q) not on stack %s", op, string(op), formatStackNames(names.topFirst()))
+			case idx >= 16:
+				return nil, posErr("%T(%q) at depth %d from top; beyond reach of DUP16", op, string(op), idx+1)
+			}
+			use = types.OpCode(vm.DUP1 + vm.OpCode(idx))
+
 		case stack.ExpectDepth:
 			if got, want := stackDepth, uint(op); got != want {
-				return nil, posErr("stack depth %d when expecting %d", got, want)
+				return nil, posErr("stack depth %d when expecting %d; last changes: %v", got, want, &trace)
+			}
+			if l != nil {
+				l.Debug("expected depth", "index", i, "depth", stackDepth)
 			}
 			continue CodeLoop
 
-		case Inverted:
-			toInvert := types.OpCode(op)
-			// All DUP have the same upper nibble 0x8 and SWAP have 0x9.
-			base := toInvert & 0xf0
-			if base != vm.DUP1 && base != vm.SWAP1 {
-				return nil, fmt.Errorf("%T applied to non-DUP/SWAP opcode %v", op, toInvert)
+		case stack.ExpectDepthRuntime:
+			if got, want := stackDepth, uint(op); got != want {
+				return nil, posErr("stack depth %d when expecting %d; last changes: %v", got, want, &trace)
 			}
-			offset := toInvert - base
-
-			last := types.OpCode(min(16, stackDepth))
-			if base == SWAP1 {
-				last--
+			g, err := runtimeDepthGuard(stackDepth)
+			if err != nil {
+				return nil, posErr("%v", err)
 			}
-			if offset >= last {
-				return nil, posErr("%T(%v) with stack depth %d", op, vm.OpCode(op), last)
+			use = g
+
+		case bytecodeAssertion:
+			assertions = append(assertions, op)
+			continue CodeLoop
+
+		case tableWidth:
+			tableWidths = append(tableWidths, op)
+			continue CodeLoop
+
+		case Inverted:
+			inv, err := invert(vm.OpCode(op), stackDepth)
+			if err != nil {
+				return nil, posErr("%T(%v) %v", op, vm.OpCode(op), err)
 			}
+			use = inv
 
-			use = base + last - offset - 1
+		case stack.FromBottom:
+			inv, err := invert(vm.OpCode(op), stackDepth)
+			if err != nil {
+				return nil, posErr("%T(%v) %v", op, vm.OpCode(op), err)
+			}
+			use = inv
 
-			if b := use.(types.OpCode) & 0xf0; b != base {
-				panic(fmt.Sprintf("BUG: bad inversion %v -> %v", vm.OpCode(op), vm.OpCode(use.(types.OpCode))))
+		case stack.FromTop:
+			if !isDupOrSwap(vm.OpCode(op)) {
+				return nil, posErr("%T applied to non-DUP/SWAP opcode %v", op, vm.OpCode(op))
 			}
+			use = types.OpCode(op)
 
 		case lazyLocator:
 			b, err := newSpliceBuffer(splices, op)
@@ -302,9 +514,25 @@ CodeLoop:
 			}
 			buf = b
 
-			if _, ok := op.(tagged); !ok {
-				// Not a tag itself therefore must be pushing one to the stack.
+			switch op.(type) {
+			case tagged:
+
+			case rjump:
+
+			case rjumpi, rjumpv:
+				if stackDepth == 0 {
+					return nil, posErr("%s popping 1 value with stack depth 0", bytecoderString(op))
+				}
+				stackDepth--
+				names.apply(vm.POP)
+				trace.record(depthStep{desc: bytecoderString(op), pop: 1, depth: stackDepth})
+
+			default:
+				// Not a tag itself nor a jump therefore must be pushing one to
+				// the stack.
 				stackDepth++
+				names.push()
+				trace.record(depthStep{desc: bytecoderString(op), push: 1, depth: stackDepth})
 			}
 
 		} // end switch raw.(type)
@@ -319,7 +547,25 @@ CodeLoop:
 
 		case lazyLocator:
 
-		case Raw:
+		case eofCall:
+			call := raw.(eofCall)
+			if stackDepth < uint(call.inputs) {
+				return nil, posErr("%s popping %d values with stack depth %d", bytecoderString(call), call.inputs, stackDepth)
+			}
+			for i := uint8(0); i < call.inputs; i++ {
+				names.apply(vm.POP)
+			}
+			for i := uint8(0); i < call.outputs; i++ {
+				names.push()
+			}
+			stackDepth = stackDepth - uint(call.inputs) + uint(call.outputs)
+			trace.record(depthStep{desc: bytecoderString(call), pop: uint(call.inputs), push: uint(call.outputs), depth: stackDepth})
+
+			code, _ := call.Bytecode() // always returns nil error
+			buf.Write(code)
+
+		case Raw, stack.ExpectDepthRuntime:
+			// The guard of an ExpectDepthRuntime has no net effect on the stack.
 			code, _ := use.Bytecode() // always returns nil error
 			buf.Write(code)
 
@@ -339,6 +585,8 @@ CodeLoop:
 					return nil, posErr("Bytecode()[%d] popping %d values with stack depth %d", i, d.pop, stackDepth)
 				}
 				stackDepth += d.push - d.pop // we're not in Solidity anymore ;)
+				names.apply(op)
+				trace.record(depthStep{desc: op.String(), pop: d.pop, push: d.push, depth: stackDepth})
 
 				if op.IsPush() {
 					i += int(op - vm.PUSH0)
@@ -348,15 +596,103 @@ CodeLoop:
 			buf.Write(code)
 		}
 
+		if l != nil {
+			l.Debug("compiled", "index", i, "bytecoder", bytecoderString(raw), "depth", stackDepth)
+		}
 	} // end CodeLoop
 
 	if err := splices.reserve(); err != nil {
 		return nil, err
 	}
-	if err := splices.expand(); err != nil {
+	if err := splices.expand(ctx); err != nil {
+		return nil, err
+	}
+	code, err := splices.bytes()
+	if err != nil {
 		return nil, err
 	}
-	return splices.bytes()
+	comp := &compilation{
+		bytecode: code,
+		splices:  splices,
+		pcs:      splices.pcs(locs),
+	}
+	if l != nil {
+		ls := comp.labels()
+		tags := make([]string, 0, len(ls))
+		for t := range ls {
+			tags = append(tags, t)
+		}
+		sort.Strings(tags)
+		for _, t := range tags {
+			l.Debug("resolved label", "name", t, "offset", ls[t])
+		}
+	}
+	if len(tableWidths) > 0 {
+		offsets := comp.labels()
+		for _, w := range tableWidths {
+			if err := w.check(offsets); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := checkAssertions(code, assertions); err != nil {
+		return nil, err
+	}
+	return comp, nil
+}
+
+// isDupOrSwap returns whether op is DUP<N> or SWAP<N>.
+func isDupOrSwap(op vm.OpCode) bool {
+	// All DUP have the same upper nibble 0x8 and SWAP have 0x9.
+	base := op & 0xf0
+	return base == vm.DUP1 || base == vm.SWAP1
+}
+
+// invert returns the DUP<N> or SWAP<N> equivalent to Inverted(op) when the
+// stack has the specified depth.
+func invert(op vm.OpCode, depth uint) (types.OpCode, error) {
+	if !isDupOrSwap(op) {
+		return 0, fmt.Errorf("applied to non-DUP/SWAP opcode %v", op)
+	}
+	base := op & 0xf0
+	offset := op - base
+
+	last := vm.OpCode(min(16, depth))
+	if base == vm.SWAP1 {
+		last--
+	}
+	if offset >= last {
+		return 0, fmt.Errorf("with stack depth %d", last)
+	}
+
+	use := base + last - offset - 1
+	if b := use & 0xf0; b != base {
+		panic(fmt.Sprintf("BUG: bad inversion %v -> %v", op, use))
+	}
+	return types.OpCode(use), nil
+}
+
+// A spliceLocation is the position at which an element of flattened Code is
+// written, relative to the start of a splice.
+type spliceLocation struct {
+	splice, offset int
+}
+
+// pcs converts the spliceLocations to absolute offsets in the compiled
+// bytecode. It MUST NOT be called before s.reserve() nor s.expand().
+func (s *spliceConcat) pcs(locs []spliceLocation) []int {
+	starts := make([]int, len(s.splices))
+	var pc int
+	for i, sp := range s.splices {
+		starts[i] = pc
+		pc += sp.buf.Len() + sp.extraBytesNeeded()
+	}
+
+	pcs := make([]int, len(locs))
+	for i, l := range locs {
+		pcs[i] = starts[l.splice] + l.offset
+	}
+	return pcs
 }
 
 // reserve performs a single pass over all splices, recording a best-case
@@ -383,6 +719,11 @@ func (s *spliceConcat) reserve() error {
 				return err
 			}
 
+		case pushWide:
+			if err := sp.setTags(s.allTags, tag(op)); err != nil {
+				return err
+			}
+
 		case pushTags:
 			if err := sp.setTags(s.allTags, op...); err != nil {
 				return err
@@ -393,6 +734,15 @@ func (s *spliceConcat) reserve() error {
 				return err
 			}
 
+		case rjump, rjumpi, rjumpv:
+			_, ts, _ := asRelJump(op)
+			if n := len(ts); n == 0 || n > maxRJUMPVLabels {
+				return fmt.Errorf("%s with %d destinations; MUST be 1 to %d", bytecoderString(op), n, maxRJUMPVLabels)
+			}
+			if err := sp.setTags(s.allTags, ts...); err != nil {
+				return err
+			}
+
 		case nil:
 			if i+1 != len(s.splices) {
 				return fmt.Errorf("BUG: %T with nil op MUST be last", sp)
@@ -420,14 +770,19 @@ func (s *spliceConcat) reserve() error {
 // tags so there is no need to adjust them to account for expansion. Only after
 // expand() has returned will the pushed values be locked in.
 //
-// expand() MUST NOT be called before s.reserve().
+// expand() MUST NOT be called before s.reserve(). It returns ctx.Err() if the
+// Context is cancelled before any pass.
 //
 // TODO: is there a more efficient algorithm? A cursory glance suggests that
 // it's currently O(nm) for n PUSHs and m JUMPs, which is at least quadratic in
 // n. The interplay between expansion via PUSHs and shifting of JUMPs suggests
 // that this is best-possible, but perhaps early exiting is still possible.
-func (s *spliceConcat) expand() error {
+func (s *spliceConcat) expand(ctx context.Context) error {
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.expandPasses++
 		expand := 0
 		for _, sp := range s.splices {
 			switch sp.op.(type) {
@@ -446,6 +801,9 @@ func (s *spliceConcat) expand() error {
 			}
 		}
 
+		if s.log != nil {
+			s.log.Debug("expand pass", "pass", s.expandPasses, "bytes", expand)
+		}
 		if expand == 0 {
 			return nil
 		}
@@ -457,11 +815,9 @@ func (s *spliceConcat) expand() error {
 func (s *spliceConcat) bytes() ([]byte, error) {
 	code := new(bytes.Buffer)
 	for _, sp := range s.splices {
-		if _, err := sp.buf.WriteTo(code); err != nil {
-			// This should be impossible, but ignoring the error angers the
-			// linter.
-			return nil, fmt.Errorf("%T.bytes(): %T.buf.WriteTo(%T): %v", s, sp, code, err)
-		}
+		// Not using sp.buf.WriteTo() as it would drain the buffer, which is
+		// needed for derivation of metadata.
+		code.Write(sp.buf.Bytes())
 
 		switch op := sp.op.(type) {
 		case JUMPDEST:
@@ -477,12 +833,36 @@ func (s *spliceConcat) bytes() ([]byte, error) {
 				return nil, fmt.Errorf("pushing size between %q and %q; %d can't be represented with 2 bytes", op[0], op[1], diff)
 			}
 
-			bc, err := PUSHBytes(byte(diff>>8), byte(diff)).Bytecode()
+			bc, err := sp.push(byte(diff>>8), byte(diff))
 			if err != nil {
 				return nil, fmt.Errorf("pushing size %d between %q and %q: %v", diff, op[0], op[1], err)
 			}
 			code.Write(bc)
 
+		case pushWide:
+			off := *sp.tags[0].offset
+			if off > math.MaxUint16 {
+				return nil, fmt.Errorf("%s at %d can't be represented with 2 bytes", bytecoderString(op), off)
+			}
+			code.Write([]byte{byte(vm.PUSH2), byte(off >> 8), byte(off)})
+
+		case rjump, rjumpi, rjumpv:
+			jmp, ts, _ := asRelJump(op)
+			// Offsets are relative to the end of the instruction, which starts
+			// at the current length of the code.
+			end := code.Len() + sp.extraBytesNeeded()
+			code.WriteByte(byte(jmp))
+			if jmp == opRJUMPV {
+				code.WriteByte(byte(len(ts) - 1))
+			}
+			for i, t := range ts {
+				rel := *sp.tags[i].offset - end
+				if rel < math.MinInt16 || rel > math.MaxInt16 {
+					return nil, fmt.Errorf("%s: relative offset %d to %q can't be represented with 2 bytes", bytecoderString(op), rel, t)
+				}
+				code.Write([]byte{byte(rel >> 8), byte(rel)})
+			}
+
 		default:
 			// The leading zeroes will be stripped by PUSHBytes(), but we need
 			// them to simplify the binary-encoding loop.
@@ -495,7 +875,7 @@ func (s *spliceConcat) bytes() ([]byte, error) {
 				copy(full[i*n:(i+1)*n], buf[8-n:])
 			}
 
-			bc, err := PUSHBytes(full...).Bytecode()
+			bc, err := sp.push(full...)
 			if err != nil {
 				return nil, fmt.Errorf("pushing %T(%q): %v", sp.op, sp.op, err)
 			}
@@ -505,6 +885,19 @@ func (s *spliceConcat) bytes() ([]byte, error) {
 	return code.Bytes(), nil
 }
 
+// push returns the bytecode of PUSHBytes(bs...), substituting PUSH1 0x00 for
+// PUSH0 if s.noPUSH0 is true.
+func (s *splice) push(bs ...byte) ([]byte, error) {
+	bc, err := PUSHBytes(bs...).Bytecode()
+	if err != nil {
+		return nil, err
+	}
+	if s.noPUSH0 && len(bc) == 1 && bc[0] == byte(vm.PUSH0) {
+		return pushZeroLegacy{}.Bytecode()
+	}
+	return bc, nil
+}
+
 func absDiff(i, j int) int {
 	switch d := i - j; {
 	case d < 0:
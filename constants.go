@@ -0,0 +1,96 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/types"
+)
+
+// Well-known values, each of which PUSHes a single word.
+var (
+	// WordSize PUSHes 32, the number of bytes in an EVM word.
+	WordSize = PUSH(32)
+	// MaxUint256 PUSHes 2^256-1, computed as NOT(0) because it requires only 2
+	// bytes of code (at the cost of 5 gas) instead of the 33 bytes (and 3 gas)
+	// of the equivalent PUSH32. Use BitMask(256) for the latter.
+	MaxUint256 = Fn(NOT, PUSH0)
+	// AddressMask PUSHes 2^160-1, the mask for the 20 bytes of an address.
+	AddressMask = BitMask(8 * common.AddressLength)
+	// SelectorShift PUSHes 224, the number of bits by which the first word of
+	// call data must be right-shifted to isolate the 4-byte function selector.
+	SelectorShift = PUSH(256 - 32)
+)
+
+// BitMask returns a Bytecoder that PUSHes 2^bits-1; i.e. a value with the
+// least-significant `bits` bits set. BitMask panics if bits is not in
+// [1,256], in the same way that PUSH() panics on invalid arguments.
+func BitMask(bits int) types.Bytecoder {
+	if bits < 1 || bits > 256 {
+		panic(fmt.Sprintf("BitMask(%d) out of range [1,256]", bits))
+	}
+	mask := bytes.Repeat([]byte{0xff}, (bits+7)/8)
+	if r := bits % 8; r != 0 {
+		mask[0] = 1<<r - 1
+	}
+	return PUSHBytes(mask...)
+}
+
+// Mask returns a Bytecoder that PUSHes 2^bits-1, as with BitMask(), but with
+// the least amount of code instead of the least gas. Masks of more than 32 bits
+// are computed as SHR(256-bits, NOT(0)), which is always 5 bytes (costing 11
+// gas), instead of the 1+⌈bits/8⌉ bytes (and 3 gas) of a literal PUSH. A mask of
+// 256 bits is MaxUint256. Mask panics if bits is not in [1,256], in the same way
+// that PUSH() panics on invalid arguments.
+func Mask(bits int) types.Bytecoder {
+	switch {
+	case bits < 1 || bits > 256:
+		panic(fmt.Sprintf("Mask(%d) out of range [1,256]", bits))
+	case bits == 256:
+		return MaxUint256
+	case bits > maskLiteralMaxBits:
+		return Fn(SHR, PUSH(256-bits), MaxUint256)
+	default:
+		return BitMask(bits)
+	}
+}
+
+// maskLiteralMaxBits is the greatest number of bits for which a literal PUSH of
+// a mask is no larger than SHR(256-bits, NOT(0)).
+const maskLiteralMaxBits = 32
+
+// PUSHFixed18 returns a Bytecoder that PUSHes f as an 18-decimal fixed-point
+// number, i.e. f*10^18, as used by WAD-based math libraries such as PRBMath.
+// The scaled value is rounded to the nearest integer, with halves rounded away
+// from zero. PUSHFixed18 panics if f is negative or infinite, or if the scaled
+// value overflows 256 bits, in the same way that PUSH() panics on invalid
+// arguments.
+//
+// A *big.Float can't exactly represent most decimal fractions; e.g.
+// big.NewFloat(0.1) carries the error of the float64 literal, which is
+// significant at 18 decimals. Construct f from a string, e.g. with
+// big.ParseFloat(s, 10, 256, big.ToNearestEven), for constants that are exact
+// in decimal.
+func PUSHFixed18(f *big.Float) types.Bytecoder {
+	if f.Sign() < 0 || f.IsInf() {
+		panic(fmt.Sprintf("PUSHFixed18(%v) negative or infinite", f))
+	}
+	// Multiplication by 10^18, which requires 60 bits, is exact at this
+	// precision, so the only rounding is to the nearest integer.
+	scaled := new(big.Float).SetPrec(max(f.Prec(), 256) + 64)
+	scaled.Mul(f, new(big.Float).SetInt(fixed18Scale))
+	scaled.Add(scaled, big.NewFloat(0.5))
+
+	i, _ := scaled.Int(nil) // truncates, which after adding 0.5 rounds half up
+	v, overflow := uint256.FromBig(i)
+	if overflow {
+		panic(fmt.Sprintf("PUSHFixed18(%v) overflows 256 bits when scaled by 10^18", f))
+	}
+	return PUSH(*v)
+}
+
+var fixed18Scale = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
@@ -0,0 +1,133 @@
+package specops
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/types"
+)
+
+// decimal parses s as an exact-as-possible *big.Float for PUSHFixed18().
+func decimal(s string) *big.Float {
+	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestConstants(t *testing.T) {
+	// mask returns 2^bits-1.
+	mask := func(bits uint) *uint256.Int {
+		one := uint256.NewInt(1)
+		return new(uint256.Int).Sub(new(uint256.Int).Lsh(one, bits), one)
+	}
+
+	tests := []struct {
+		name string
+		bc   types.Bytecoder
+		want *uint256.Int
+	}{
+		{"WordSize", WordSize, uint256.NewInt(32)},
+		{"MaxUint256", MaxUint256, new(uint256.Int).SetAllOne()},
+		{"AddressMask", AddressMask, mask(160)},
+		{"SelectorShift", SelectorShift, uint256.NewInt(224)},
+		{"BitMask(1)", BitMask(1), uint256.NewInt(1)},
+		{"BitMask(7)", BitMask(7), uint256.NewInt(0x7f)},
+		{"BitMask(8)", BitMask(8), uint256.NewInt(0xff)},
+		{"BitMask(9)", BitMask(9), uint256.NewInt(0x1ff)},
+		{"BitMask(64)", BitMask(64), mask(64)},
+		{"BitMask(128)", BitMask(128), mask(128)},
+		{"BitMask(255)", BitMask(255), mask(255)},
+		{"BitMask(256)", BitMask(256), new(uint256.Int).SetAllOne()},
+		{"Mask(1)", Mask(1), uint256.NewInt(1)},
+		{"Mask(32)", Mask(32), mask(32)},
+		{"Mask(33)", Mask(33), mask(33)},
+		{"Mask(128)", Mask(128), mask(128)},
+		{"Mask(255)", Mask(255), mask(255)},
+		{"Mask(256)", Mask(256), new(uint256.Int).SetAllOne()},
+		{"PUSHFixed18(0)", PUSHFixed18(new(big.Float)), uint256.NewInt(0)},
+		{"PUSHFixed18(1)", PUSHFixed18(big.NewFloat(1)), uint256.NewInt(1e18)},
+		{"PUSHFixed18(1.5)", PUSHFixed18(big.NewFloat(1.5)), uint256.NewInt(15e17)},
+		{"PUSHFixed18(0.1)", PUSHFixed18(decimal("0.1")), uint256.NewInt(1e17)},
+		{"PUSHFixed18(e)", PUSHFixed18(decimal("2.7182818284590452353602874")), uint256.NewInt(2_718281828459045235)},
+		{"PUSHFixed18(round up)", PUSHFixed18(decimal("0.0000000000000000016")), uint256.NewInt(2)},
+		{"PUSHFixed18(round down)", PUSHFixed18(decimal("0.0000000000000000014")), uint256.NewInt(1)},
+		{"PUSHFixed18(1e50)", PUSHFixed18(decimal("1e50")), new(uint256.Int).Exp(uint256.NewInt(10), uint256.NewInt(68))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := Code{
+				Fn(MSTORE, PUSH0, tt.bc),
+				Fn(RETURN, PUSH0, PUSH(32)),
+			}
+			res, err := code.Run(nil)
+			if err != nil {
+				t.Fatalf("%T.Run() error %v", code, err)
+			}
+			if got := new(uint256.Int).SetBytes(res.ReturnData); !got.Eq(tt.want) {
+				t.Errorf("got %#x; want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskSize(t *testing.T) {
+	for bits := 1; bits <= 256; bits++ {
+		got, err := Code{Mask(bits)}.Compile()
+		if err != nil {
+			t.Fatalf("Mask(%d) compilation error %v", bits, err)
+		}
+		literal, err := Code{BitMask(bits)}.Compile()
+		if err != nil {
+			t.Fatalf("BitMask(%d) compilation error %v", bits, err)
+		}
+		if len(got) > len(literal) {
+			t.Errorf("Mask(%d) compiles to %d bytes; BitMask() equivalent is %d", bits, len(got), len(literal))
+		}
+		if bits <= maskLiteralMaxBits && len(got) != len(literal) {
+			t.Errorf("Mask(%d) compiles to %d bytes; want literal PUSH of %d", bits, len(got), len(literal))
+		}
+	}
+}
+
+func TestBitMaskPanics(t *testing.T) {
+	for _, bits := range []int{-1, 0, 257} {
+		t.Run("", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("BitMask(%d) did not panic", bits)
+				}
+			}()
+			BitMask(bits)
+		})
+		t.Run("", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Mask(%d) did not panic", bits)
+				}
+			}()
+			Mask(bits)
+		})
+	}
+}
+
+func TestPUSHFixed18Panics(t *testing.T) {
+	for _, f := range []*big.Float{
+		big.NewFloat(-1),
+		new(big.Float).SetInf(false),
+		decimal("1e60"),
+	} {
+		t.Run(f.String(), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("PUSHFixed18(%v) did not panic", f)
+				}
+			}()
+			PUSHFixed18(f)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package specops
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// CREATEAddress returns the address at which a contract will be deployed by a
+// CREATE from the deployer, when the deployer's nonce is as specified. Note
+// that, since EIP-161, contract accounts are created with a nonce of 1.
+func CREATEAddress(deployer common.Address, nonce uint64) common.Address {
+	return crypto.CreateAddress(deployer, nonce)
+}
+
+// CREATE2Address returns the address at which a contract will be deployed by a
+// CREATE2 from the deployer, with the specified salt and init code.
+func CREATE2Address(deployer common.Address, salt [32]byte, initCode []byte) common.Address {
+	return crypto.CreateAddress2(deployer, salt, crypto.Keccak256(initCode))
+}
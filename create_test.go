@@ -0,0 +1,51 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/arr4n/specops/runopts"
+)
+
+func TestCREATEAddresses(t *testing.T) {
+	// Init code that deploys a single STOP.
+	initCode := Code{
+		Fn(RETURN, PUSH0, PUSH(1)),
+	}
+	init, err := initCode.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", initCode, err)
+	}
+	salt := common.Hash{31: 42}
+
+	code := Code{
+		Fn(MSTORE, PUSH0, PUSH(init)),
+		Fn(CREATE, PUSH0, PUSH(32-len(init)), PUSH(len(init))),
+		Fn(CREATE, PUSH0, PUSH(32-len(init)), PUSH(len(init))),
+		Fn(CREATE2, PUSH0, PUSH(32-len(init)), PUSH(len(init)), PUSH(salt)),
+		Fn(MSTORE, PUSH(64)), // CREATE2
+		Fn(MSTORE, PUSH(32)), // second CREATE
+		Fn(MSTORE, PUSH0),    // first CREATE
+		Fn(RETURN, PUSH0, PUSH(96)),
+	}
+
+	deployer := runopts.DefaultContractAddress()
+	got, err := code.Run(nil)
+	if err != nil {
+		t.Fatalf("%T.Run() error %v", code, err)
+	}
+
+	// The default chain config predates EIP-161 so the contract's nonce starts
+	// at zero.
+	want := []common.Address{
+		CREATEAddress(deployer, 0),
+		CREATEAddress(deployer, 1),
+		CREATE2Address(deployer, salt, init),
+	}
+	for i, w := range want {
+		if g := common.BytesToAddress(got.ReturnData[i*32 : (i+1)*32]); g != w {
+			t.Errorf("Deployed address [%d] = %v; want %v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// A deadRun is a run, [start,end), of flattened Code that follows a
+// terminating Bytecoder without an intervening JUMPDEST or Label, and which
+// emits code. Raw bytes are assumed to be data, not code.
+type deadRun struct {
+	start, end int
+	after      types.Bytecoder // the terminating Bytecoder
+	// atLabel is true i.f.f. the run ends at a Label, as against a JUMPDEST or
+	// the end of the Code.
+	atLabel bool
+}
+
+// deadRuns returns every deadRun in the flattened Code.
+func deadRuns(flat Code) []deadRun {
+	var (
+		runs  []deadRun
+		curr  *deadRun
+		after types.Bytecoder // non-nil while unreachable
+	)
+	end := func(i int, atLabel bool) {
+		if curr != nil {
+			curr.end = i
+			curr.atLabel = atLabel
+			runs = append(runs, *curr)
+		}
+		curr, after = nil, nil
+	}
+
+	for i, bc := range flat {
+		switch bc.(type) {
+		case JUMPDEST:
+			end(i, false)
+			continue
+		case Label:
+			end(i, true)
+			continue
+		}
+
+		if after != nil && curr == nil && emitsCode(bc) {
+			curr = &deadRun{start: i, after: after}
+		}
+		if after == nil && terminates(bc) {
+			after = bc
+		}
+	}
+	end(len(flat), false)
+	return runs
+}
+
+// emitsCode returns whether bc is known to emit executable code, as against
+// data or compiler hints.
+func emitsCode(bc types.Bytecoder) bool {
+	switch bc.(type) {
+	case types.OpCode, types.StackPusher,
+		pushTag, pushTags, pushWide, pushSize, pushLinked, linkedPush,
+		stack.Ref, Inverted, stack.FromTop, stack.FromBottom,
+		rjump, rjumpi, rjumpv, callF, retF:
+		return true
+	default:
+		return false
+	}
+}
+
+// terminates returns whether bc halts execution or unconditionally jumps.
+func terminates(bc types.Bytecoder) bool {
+	switch bc := bc.(type) {
+	case types.OpCode:
+		return isTerminal(vm.OpCode(bc))
+	case rjump, retF:
+		return true
+	default:
+		return false
+	}
+}
+
+// withoutDeadCode returns the flattened Code with every deadRun that ends at a
+// JUMPDEST, or at the end of the Code, removed, along with any hints in the run
+// that check the stack, which would otherwise fail as the compiler's stack
+// depth is changed. A deadRun that ends at a Label is retained because the
+// stack depth at the Label depends on it.
+func withoutDeadCode(flat Code) Code {
+	drop := make(map[int]bool)
+	for _, r := range deadRuns(flat) {
+		if r.atLabel {
+			continue
+		}
+		for i := r.start; i < r.end; i++ {
+			switch bc := flat[i]; bc.(type) {
+			case stack.ExpectDepth, stack.ExpectDepthRuntime, stack.Model, stack.Names:
+				drop[i] = true
+			default:
+				if emitsCode(bc) {
+					drop[i] = true
+				}
+			}
+		}
+	}
+
+	out := make(Code, 0, len(flat)-len(drop))
+	for i, bc := range flat {
+		if !drop[i] {
+			out = append(out, bc)
+		}
+	}
+	return out
+}
+
+// CheckDeadCode is an opt-in check, separate from Analyze(), that returns a
+// Diagnostic, with Rule "dead-code", for every run of code that follows a
+// halting opcode or unconditional JUMP without an intervening JUMPDEST or
+// Label, and which can therefore never be executed; e.g. code left behind
+// after refactoring. Raw is assumed to be data, so isn't reported. Diagnostics
+// are ordered by PC, which is that at which the run begins. See
+// Code.Optimized() for removal of dead code. CheckDeadCode only returns an
+// error if compilation fails.
+func (c Code) CheckDeadCode() ([]Diagnostic, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	var diags []Diagnostic
+	for _, r := range deadRuns(c.flatten()) {
+		diags = append(diags, Diagnostic{
+			PC:      comp.pcOf(r.start),
+			Rule:    "dead-code",
+			Message: fmt.Sprintf("unreachable code after %s without an intervening JUMPDEST or Label", bytecoderString(r.after)),
+		})
+	}
+	return diags, nil
+}
@@ -0,0 +1,123 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestCheckDeadCode(t *testing.T) {
+	const rule = "dead-code"
+
+	tests := []struct {
+		name string
+		code Code
+		want []Diagnostic
+	}{
+		{
+			name: "none",
+			code: Code{
+				Fn(JUMPI, PUSH("end"), CALLVALUE), // 0, 1, 3
+				CALLER,                            // 4
+				JUMPDEST("end"), stack.SetDepth(0),
+				STOP,
+			},
+		},
+		{
+			name: "after STOP and JUMP",
+			code: Code{
+				STOP,                   // 0
+				stack.SetDepth(0),      // hint; not code
+				CALLER, CALLVALUE, ADD, // 1, 2, 3
+				JUMPDEST("a"), stack.SetDepth(0), // 4
+				Fn(JUMP, PUSH("end")),              // 5, 7
+				CALLER,                             // 8
+				JUMPDEST("end"), stack.SetDepth(0), // 9
+				Fn(RETURN, PUSH0, PUSH0), // 10, 11, 12
+				INVALID,                  // 13
+			},
+			want: []Diagnostic{
+				{PC: 1, Rule: rule, Message: "unreachable code after STOP without an intervening JUMPDEST or Label"},
+				{PC: 8, Rule: rule, Message: "unreachable code after JUMP without an intervening JUMPDEST or Label"},
+				{PC: 13, Rule: rule, Message: "unreachable code after RETURN without an intervening JUMPDEST or Label"},
+			},
+		},
+		{
+			name: "data and Label",
+			code: Code{
+				STOP,
+				Raw{1, 2, 3},
+				Label("x"),
+				Raw{4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.CheckDeadCode()
+			if err != nil {
+				t.Fatalf("%T.CheckDeadCode() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.CheckDeadCode() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
+
+func TestOptimizedRemovesDeadCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want Code
+	}{
+		{
+			name: "up to JUMPDEST",
+			code: Code{
+				Fn(REVERT, PUSH0, PUSH0),
+				stack.SetDepth(0),
+				PUSH(1), stack.Name("x"), stack.ExpectDepth(1), Raw{0xaa},
+				JUMPDEST("j"), stack.SetDepth(0),
+				STOP,
+				CALLER,
+			},
+			want: Code{
+				PUSH0, PUSH0, REVERT,
+				stack.SetDepth(0),
+				Raw{0xaa},
+				JUMPDEST("j"), stack.SetDepth(0),
+				STOP,
+			},
+		},
+		{
+			name: "retained before Label",
+			code: Code{
+				STOP,
+				PUSH(1), stack.ExpectDepth(1),
+				Label("l"),
+				stack.ExpectDepth(1),
+			},
+			want: Code{
+				STOP,
+				PUSH(1), stack.ExpectDepth(1),
+				Label("l"),
+				stack.ExpectDepth(1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.code.Optimized()
+			if diff := cmp.Diff(tt.want.String(), got.String()); diff != "" {
+				t.Errorf("%T.Optimized() diff (-want +got):\n%s", tt.code, diff)
+			}
+			if _, err := got.Compile(); err != nil {
+				t.Errorf("%T.Optimized().Compile() error %v", tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package specops
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// Decompile returns Go source approximating the Code from which the bytecode
+// could have been compiled, as a `Code{…}` composite literal intended for
+// dot-importing specops. The source may also refer to the stack, common, and
+// hexutil packages.
+//
+// Decompilation is best-effort:
+//   - Every JUMPDEST is named by its offset in the bytecode, and is followed by
+//     a stack.SetDepth() estimated from the preceding code (if it falls
+//     through), earlier jumps to it, and the code that follows;
+//   - A PUSH immediately followed by JUMP or JUMPI, of the offset of a
+//     JUMPDEST, is converted into a PUSH of the respective label;
+//   - All other PUSHes are of the same value but may be of a smaller width,
+//     which is noted in a comment. Hand-computed offsets (e.g. for CODECOPY)
+//     may therefore be incorrect if the layout of the code changes; and
+//   - Bytes that can't be reached without a jump, and which aren't a JUMPDEST,
+//     are treated as data and emitted as Raw, as are invalid opcodes; and
+//   - Code that begins by consuming items that it didn't push, as is the case
+//     for snippets extracted from a larger contract, is preceded by a
+//     stack.SetDepth() of the number of items required.
+//
+// All bytecode can be decompiled, if only as Raw data.
+func Decompile(bytecode []byte) string {
+	var s strings.Builder
+	s.WriteString("Code{\n")
+	for _, el := range decompile(bytecode) {
+		fmt.Fprintf(&s, "\t%s,\n", el.src)
+	}
+	s.WriteString("}")
+	return s.String()
+}
+
+// DecompileCode is equivalent to Decompile() except that it returns the Code
+// itself, instead of Go source, e.g. for editing and recompiling deployed
+// contracts. It returns an error i.f.f. the Code fails to compile, which can
+// only occur if a stack.SetDepth() was underestimated.
+func DecompileCode(bytecode []byte) (Code, error) {
+	var code Code
+	for _, el := range decompile(bytecode) {
+		code = append(code, el.code...)
+	}
+	if _, err := code.Compile(); err != nil {
+		return nil, fmt.Errorf("decompiled %T doesn't compile: %v", code, err)
+	}
+	return code, nil
+}
+
+// A decompiled element is a line of Go source returned by Decompile(), along
+// with the equivalent Bytecoders.
+type decompiled struct {
+	src  string
+	code Code
+}
+
+// decompile implements Decompile() and DecompileCode().
+func decompile(bytecode []byte) []decompiled {
+	instrs := disassemble(bytecode)
+
+	jumpdests := make(map[int]bool)
+	for _, in := range instrs {
+		if in.op == vm.JUMPDEST {
+			jumpdests[in.pc] = true
+		}
+	}
+	label := func(pc int) string {
+		return fmt.Sprintf("jumpdest_%d", pc)
+	}
+
+	var (
+		out       []decompiled
+		data      []byte // unreachable bytes not yet emitted
+		reachable = true
+		depth     int
+		// jumpedFrom tracks the greatest stack depth at any labelled JUMP or
+		// JUMPI, keyed by destination.
+		jumpedFrom = make(map[int]int)
+		jumpTo     = -1 // destination pushed by the last instruction, if any
+	)
+	if d := entryDepth(instrs); d > 0 {
+		out = append(out, decompiled{fmt.Sprintf("stack.SetDepth(%d)", d), Code{stack.SetDepth(d)}})
+		depth = d
+	}
+	flush := func() {
+		if len(data) > 0 {
+			out = append(out, decompiled{fmt.Sprintf("Raw(hexutil.MustDecode(%q))", hexutil.Encode(data)), Code{Raw(data)}})
+			data = nil
+		}
+	}
+
+	for i, in := range instrs {
+		raw := bytecode[in.pc : in.pc+1+len(in.data)]
+
+		if in.op == vm.JUMPDEST {
+			flush()
+			if !reachable {
+				depth = 0
+			}
+			depth = max(depth, jumpedFrom[in.pc], entryDepth(instrs[i+1:]))
+			out = append(out, decompiled{
+				fmt.Sprintf("JUMPDEST(%q), stack.SetDepth(%d)", label(in.pc), depth),
+				Code{JUMPDEST(label(in.pc)), stack.SetDepth(depth)},
+			})
+			reachable = true
+			continue
+		}
+
+		d, ok := stackDeltas[in.op]
+		if !reachable || !ok || in.truncated {
+			// Invalid opcodes and truncated PUSHes both halt execution.
+			reachable = false
+			data = append(data, raw...)
+			continue
+		}
+
+		switch {
+		case in.op == vm.PUSH0:
+			out = append(out, decompiled{"PUSH0", Code{PUSH0}})
+
+		case in.op.IsPush():
+			next := vm.STOP
+			if i+1 < len(instrs) {
+				next = instrs[i+1].op
+			}
+			dest := new(uint256.Int).SetBytes(in.data)
+			if (next == vm.JUMP || next == vm.JUMPI) && dest.IsUint64() && jumpdests[int(dest.Uint64())] {
+				jumpTo = int(dest.Uint64())
+				out = append(out, decompiled{fmt.Sprintf("PUSH(%q)", label(jumpTo)), Code{PUSH(label(jumpTo))}})
+				depth++
+				continue // jumpTo is used by the next instruction
+			}
+			out = append(out, decompiled{decompilePush(in), Code{PUSHBytes(in.data...)}})
+
+		default:
+			out = append(out, decompiled{in.op.String(), Code{types.OpCode(in.op)}})
+		}
+
+		depth = max(0, depth+int(d.push)-int(d.pop))
+		if jumpTo >= 0 {
+			jumpedFrom[jumpTo] = max(jumpedFrom[jumpTo], depth)
+			jumpTo = -1
+		}
+		if isTerminal(in.op) {
+			reachable = false
+		}
+	}
+	flush()
+	return out
+}
+
+// entryDepth returns the minimum stack depth required to execute instrs without
+// underflow, up to the next JUMPDEST or terminating opcode.
+func entryDepth(instrs []instruction) int {
+	var curr, lowest int
+	for _, in := range instrs {
+		d, ok := stackDeltas[in.op]
+		if in.op == vm.JUMPDEST || !ok {
+			break
+		}
+		curr -= int(d.pop)
+		if curr < lowest {
+			lowest = curr
+		}
+		curr += int(d.push)
+		if isTerminal(in.op) {
+			break
+		}
+	}
+	return -lowest
+}
+
+// decompilePush returns Go source for a PUSH of the same value as in, which
+// MUST be a PUSH1 to PUSH32.
+func decompilePush(in instruction) string {
+	trimmed := in.data
+	for len(trimmed) > 0 && trimmed[0] == 0 {
+		trimmed = trimmed[1:]
+	}
+
+	var src string
+	switch n := len(trimmed); {
+	case n == 0:
+		src = "PUSH0"
+	case n < 8:
+		src = fmt.Sprintf("PUSH(%#x)", trimmed)
+	case len(in.data) == common.AddressLength:
+		src = fmt.Sprintf("PUSH(common.HexToAddress(%q))", common.BytesToAddress(in.data).Hex())
+	case len(in.data) == common.HashLength:
+		src = fmt.Sprintf("PUSH(common.HexToHash(%q))", common.BytesToHash(in.data).Hex())
+	default:
+		src = fmt.Sprintf("PUSH(hexutil.MustDecode(%q))", hexutil.Encode(trimmed))
+	}
+
+	if len(trimmed) != len(in.data) {
+		// The compiler will use the smallest possible PUSH<N>.
+		src += fmt.Sprintf(" /* %v %#x */", in.op, in.data)
+	}
+	return src
+}
@@ -0,0 +1,307 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+func TestDecompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     Code
+		bytecode []byte // if non-nil, used instead of code
+		want     string
+	}{
+		{
+			name: "straight-line code",
+			code: Code{Fn(MSTORE, PUSH0, CALLVALUE), Fn(RETURN, PUSH0, PUSH(32))},
+			want: `Code{
+	CALLVALUE,
+	PUSH0,
+	MSTORE,
+	PUSH(0x20),
+	PUSH0,
+	RETURN,
+}`,
+		},
+		{
+			name: "labels reconstructed",
+			code: Code{
+				PUSH(1),
+				Fn(JUMPI, PUSH("end"), CALLVALUE),
+				Fn(JUMP, PUSH("loop")),
+				JUMPDEST("loop"), stack.SetDepth(1),
+				Fn(JUMP, PUSH("loop")),
+				JUMPDEST("end"), stack.SetDepth(1),
+				STOP,
+			},
+			want: `Code{
+	PUSH(0x01),
+	CALLVALUE,
+	PUSH("jumpdest_13"),
+	JUMPI,
+	PUSH("jumpdest_9"),
+	JUMP,
+	JUMPDEST("jumpdest_9"), stack.SetDepth(1),
+	PUSH("jumpdest_9"),
+	JUMP,
+	JUMPDEST("jumpdest_13"), stack.SetDepth(1),
+	STOP,
+}`,
+		},
+		{
+			name: "entry depth from following code",
+			code: Code{
+				STOP,
+				JUMPDEST("f"), stack.SetDepth(2),
+				ADD, POP,
+			},
+			want: `Code{
+	STOP,
+	JUMPDEST("jumpdest_1"), stack.SetDepth(2),
+	ADD,
+	POP,
+}`,
+		},
+		{
+			name: "non-empty starting stack",
+			code: Code{
+				stack.SetDepth(3),
+				ADD, ADD, POP,
+				STOP,
+			},
+			want: `Code{
+	stack.SetDepth(3),
+	ADD,
+	ADD,
+	POP,
+	STOP,
+}`,
+		},
+		{
+			name: "unreachable data",
+			code: Code{
+				Fn(CODECOPY, PUSH0, PUSH("data"), PUSHSize("data", "end")),
+				STOP,
+				Label("data"), Raw{0xfe, 0x5b, 0x60},
+				Label("end"),
+			},
+			want: `Code{
+	PUSH(0x03),
+	PUSH(0x07),
+	PUSH0,
+	CODECOPY,
+	STOP,
+	Raw(hexutil.MustDecode("0xfe")),
+	JUMPDEST("jumpdest_8"), stack.SetDepth(0),
+	Raw(hexutil.MustDecode("0x60")),
+}`,
+		},
+		{
+			name:     "non-minimal PUSHes",
+			bytecode: []byte{byte(vm.PUSH1), 0, byte(vm.PUSH3), 0, 0, 0x42, byte(STOP)},
+			want: `Code{
+	PUSH0 /* PUSH1 0x00 */,
+	PUSH(0x42) /* PUSH3 0x000042 */,
+	STOP,
+}`,
+		},
+		{
+			name: "wide PUSHes",
+			code: Code{
+				PUSHBytes(0xaa, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19),
+				PUSHBytes(0xbb, 1, 2, 3, 4, 5, 6, 7, 8, 9),
+			},
+			want: `Code{
+	PUSH(common.HexToAddress("0xaA0102030405060708090A0b0c0d0e0f10111213")),
+	PUSH(hexutil.MustDecode("0xbb010203040506070809")),
+}`,
+		},
+		{
+			name:     "invalid opcode and truncated PUSH",
+			bytecode: []byte{byte(CALLER), 0x0c, byte(vm.PUSH2), 1},
+			want: `Code{
+	CALLER,
+	Raw(hexutil.MustDecode("0x0c6101")),
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bytecode := tt.bytecode
+			if bytecode == nil {
+				var err error
+				bytecode, err = tt.code.Compile()
+				if err != nil {
+					t.Fatalf("%T.Compile() error %v", tt.code, err)
+				}
+			}
+
+			got := Decompile(bytecode)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Decompile(%#x) diff (-want +got):\n%s", bytecode, diff)
+			}
+		})
+	}
+}
+
+func TestDecompileRoundTrip(t *testing.T) {
+	// Outputs of the respective examples in examples_test.go.
+	tests := []struct {
+		name     string
+		bytecode string
+	}{
+		{"helloWorld", "0x6a48656c6c6f20776f726c645f52600b6015f3"},
+		{"eip1167", "0x363d3d373d3d3d363d73bebebebebebebebebebebebebebebebebebebebe5af43d82803e903d91602b57fd5bf3"},
+		{"eip1167Modern", "0x365f5f375f5f365f73bebebebebebebebebebebebebebebebebebebebe5af43d5f5f3e5f3d91602a57fd5bf3"},
+		{"0ageMetamorphic", "0x5860208158601c335a63aaf10f428752fa158151803b80938091923cf3"},
+		{"autoMetamorphic", "0x5860208158601c335a63aaf10f428752fa158151803b928084923cf3"},
+		{"jumpTable", "0x6004356001603a8210695d58524c453e37302820601660068504011a573d3dfd5b64045461b590025b64020ea2db80025b63e11fed20025b6353971500025b63197b6830025b6305c6b740025b62cbf340025b620a26c0025b6102d0025b658886807a746e601a60068406011a565b60048203025b60038203025b60028203025b60018203025b025b3d52593df3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := hexutil.MustDecode(tt.bytecode)
+			src := Decompile(want)
+
+			code, err := parseDecompiled(src)
+			if err != nil {
+				t.Fatalf("parsing Decompile(%#x) output: %v\n%s", want, err, src)
+			}
+			got, err := code.Compile()
+			if err != nil {
+				t.Fatalf("Decompile(%#x) output; %T.Compile() error %v\n%s", want, code, err, src)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Decompile(%#x) output compiles to %#x; want original\n%s", want, got, src)
+			}
+
+			lifted, err := DecompileCode(want)
+			if err != nil {
+				t.Fatalf("DecompileCode(%#x) error %v", want, err)
+			}
+			if got, want := lifted.String(), code.String(); got != want {
+				t.Errorf("DecompileCode(%s) = %s; want equivalent to Decompile() = %s", tt.bytecode, got, want)
+			}
+			got, err = lifted.Compile()
+			if err != nil {
+				t.Fatalf("DecompileCode(%#x); %T.Compile() error %v", want, lifted, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("DecompileCode(%#x) compiles to %#x; want original", want, got)
+			}
+		})
+	}
+}
+
+// parseDecompiled evaluates Go source returned by Decompile(), supporting only
+// the constructs that it emits.
+func parseDecompiled(src string) (Code, error) {
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, fmt.Errorf("got %T; want %T", expr, lit)
+	}
+
+	var code Code
+	for _, el := range lit.Elts {
+		bc, err := evalDecompiled(el)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", src[el.Pos()-1:el.End()-1], err)
+		}
+		code = append(code, bc)
+	}
+	return code, nil
+}
+
+func evalDecompiled(el ast.Expr) (types.Bytecoder, error) {
+	switch el := el.(type) {
+	case *ast.Ident:
+		op := vm.StringToOp(el.Name)
+		if op == vm.STOP && el.Name != "STOP" {
+			return nil, fmt.Errorf("unknown opcode %q", el.Name)
+		}
+		return types.OpCode(op), nil
+
+	case *ast.CallExpr:
+		if len(el.Args) != 1 {
+			return nil, fmt.Errorf("%d arguments", len(el.Args))
+		}
+		arg, err := evalDecompiledArg(el.Args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		switch fn := gotypes.ExprString(el.Fun); fn {
+		case "JUMPDEST":
+			return JUMPDEST(arg.(string)), nil
+		case "stack.SetDepth":
+			return stack.SetDepth(arg.(uint64)), nil
+		case "Raw":
+			return Raw(arg.([]byte)), nil
+		case "PUSH":
+			switch arg := arg.(type) {
+			case string:
+				return PUSH(arg), nil
+			case uint64:
+				return PUSH(arg), nil
+			case []byte:
+				return PUSH(arg), nil
+			case common.Address:
+				return PUSH(arg), nil
+			case common.Hash:
+				return PUSH(arg), nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported call %s(%T)", gotypes.ExprString(el.Fun), arg)
+	}
+	return nil, fmt.Errorf("unsupported %T", el)
+}
+
+func evalDecompiledArg(arg ast.Expr) (any, error) {
+	switch arg := arg.(type) {
+	case *ast.BasicLit:
+		switch arg.Kind {
+		case token.INT:
+			return strconv.ParseUint(arg.Value, 0, 64)
+		case token.STRING:
+			return strconv.Unquote(arg.Value)
+		}
+
+	case *ast.CallExpr:
+		if len(arg.Args) != 1 {
+			break
+		}
+		s, err := evalDecompiledArg(arg.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch fn := gotypes.ExprString(arg.Fun); fn {
+		case "hexutil.MustDecode":
+			return hexutil.Decode(s.(string))
+		case "common.HexToAddress":
+			return common.HexToAddress(s.(string)), nil
+		case "common.HexToHash":
+			return common.HexToHash(s.(string)), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported argument %s", gotypes.ExprString(arg))
+}
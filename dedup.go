@@ -0,0 +1,162 @@
+package specops
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// DeduplicateData returns an equivalent of the Code in which byte-identical
+// data sections are only included once, with all references to the removed
+// copies repointed to the retained one. The returned Code is flattened (see
+// Code.Bytecoders()) and can be compiled as usual.
+//
+// A data section is a Label, followed by a non-empty Raw, optionally followed
+// by another Label marking its end (e.g. for use with PUSHSize()). Sections
+// are only merged if:
+//   - They are unreachable by fall-through, i.e. they are preceded by a
+//     terminating opcode (STOP, RETURN, REVERT, INVALID, SELFDESTRUCT, or
+//     JUMP) or by another such data section;
+//   - Both or neither have an end Label; and
+//   - The section being removed is only referenced by PUSHSize() to measure
+//     itself (i.e. from its own start to its own end Label); any other size
+//     depends on the section's address, which would change.
+//
+// As JUMPDESTs never start a data section, code that is jumped into is never
+// merged. Jumps to hand-computed offsets within data, however, are not
+// detected.
+func (c Code) DeduplicateData() Code {
+	flat := c.flatten()
+
+	type section struct {
+		idx        int // of the starting Label in flat
+		start, end tag // end is empty if there is no end Label
+	}
+	var (
+		sections    []section
+		unreachable bool
+	)
+	for i := 0; i < len(flat); i++ {
+		l, ok := flat[i].(Label)
+		raw, isRaw := at[Raw](flat, i+1)
+		if !ok || !isRaw || len(raw) == 0 || !unreachable {
+			switch bc := flat[i].(type) {
+			case stack.SetDepth, stack.ExpectDepth, stack.Model, stack.Names, tableWidth, retainDepth:
+				// No bytecode so reachability is unchanged.
+			case types.OpCode:
+				unreachable = isTerminal(vm.OpCode(bc))
+			default:
+				unreachable = false
+			}
+			continue
+		}
+
+		s := section{idx: i, start: tag(l)}
+		n := 2
+		if end, ok := at[Label](flat, i+2); ok {
+			s.end = tag(end)
+			n++
+		}
+		sections = append(sections, s)
+		i += n - 1 // the loop's i++ moves past the section
+	}
+
+	// A PUSHSize() between anything other than the start and end of a single
+	// section depends on its address so the section can't be removed.
+	ends := make(map[tag]tag)
+	for _, s := range sections {
+		if s.end != "" {
+			ends[s.start] = s.end
+		}
+	}
+	pinned := make(map[tag]bool)
+	for _, bc := range flat {
+		p, ok := bc.(pushSize)
+		if !ok {
+			continue
+		}
+		if e, ok := ends[p[0]]; ok && e == p[1] {
+			continue
+		}
+		if e, ok := ends[p[1]]; ok && e == p[0] {
+			continue
+		}
+		pinned[p[0]] = true
+		pinned[p[1]] = true
+	}
+
+	var (
+		retained = make(map[string][]section) // keyed by data
+		renamed  = make(map[tag]tag)
+		drop     = make(map[int]bool) // indices in flat
+	)
+SectionLoop:
+	for _, s := range sections {
+		data := string(flat[s.idx+1].(Raw))
+		for _, g := range retained[data] {
+			if (g.end == "") != (s.end == "") || pinned[s.start] || pinned[s.end] {
+				continue
+			}
+			renamed[s.start] = g.start
+			drop[s.idx] = true
+			drop[s.idx+1] = true
+			if s.end != "" {
+				renamed[s.end] = g.end
+				drop[s.idx+2] = true
+			}
+			continue SectionLoop
+		}
+		retained[data] = append(retained[data], s)
+	}
+
+	rename := func(t tag) tag {
+		if r, ok := renamed[t]; ok {
+			return r
+		}
+		return t
+	}
+	out := make(Code, 0, len(flat))
+	for i, bc := range flat {
+		if drop[i] {
+			continue
+		}
+		switch bc := bc.(type) {
+		case pushTag:
+			out = append(out, pushTag(rename(tag(bc))))
+		case pushWide:
+			out = append(out, pushWide(rename(tag(bc))))
+		case pushTags:
+			ts := make(pushTags, len(bc))
+			for j, t := range bc {
+				ts[j] = rename(t)
+			}
+			out = append(out, ts)
+		case pushSize:
+			out = append(out, pushSize{rename(bc[0]), rename(bc[1])})
+		case rjump:
+			out = append(out, rjump(rename(tag(bc))))
+		case rjumpi:
+			out = append(out, rjumpi(rename(tag(bc))))
+		case rjumpv:
+			ts := make(rjumpv, len(bc))
+			for j, t := range bc {
+				ts[j] = rename(t)
+			}
+			out = append(out, ts)
+		default:
+			out = append(out, bc)
+		}
+	}
+	return out
+}
+
+// at returns c[i] as a T, and whether i is in range and of the correct type.
+func at[T types.Bytecoder](c Code, i int) (T, bool) {
+	var zero T
+	if i < 0 || i >= len(c) {
+		return zero, false
+	}
+	t, ok := c[i].(T)
+	return t, ok
+}
@@ -0,0 +1,102 @@
+package specops
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+func TestDeduplicateData(t *testing.T) {
+	msg := Raw("hello world")
+	returnData := func(start, end string) Code {
+		return Code{
+			Fn(CODECOPY, PUSH0, PUSH(start), PUSHSize(start, end)),
+			Fn(RETURN, PUSH0, PUSHSize(start, end)),
+		}
+	}
+
+	// code returns data from the "a" section, or via branch b if there is call
+	// data. The "a" section is followed by the remaining data.
+	code := func(b Code, data ...types.Bytecoder) Code {
+		return Code{
+			Fn(JUMPI, PUSH("b"), CALLDATASIZE),
+			returnData("a", "a_end"),
+			JUMPDEST("b"), stack.SetDepth(0),
+			b,
+			// Data MUST NOT precede code as it may be interpreted as PUSH<N>.
+			Label("a"), msg, Label("a_end"),
+			Code(data),
+		}
+	}
+	returnB := returnData("b_msg", "b_end")
+
+	tests := []struct {
+		name      string
+		code      Code
+		wantSaved int
+	}{
+		{
+			name:      "identical sections",
+			code:      code(returnB, Label("b_msg"), msg, Label("b_end")),
+			wantSaved: len(msg),
+		},
+		{
+			name: "different data",
+			code: code(returnB, Label("b_msg"), Raw("goodbye"), Label("b_end")),
+		},
+		{
+			name: "reachable by fall-through",
+			code: code(
+				returnB,
+				PC, // not a terminating opcode
+				Label("b_msg"), msg, Label("b_end"),
+			),
+		},
+		{
+			name: "sized together",
+			code: code(
+				returnB,
+				Label("b_msg"), msg, Label("b_end"),
+				PUSHSize("a", "b_msg"),
+			),
+		},
+		{
+			name: "sized from outside section",
+			code: code(
+				Code{
+					Fn(MSTORE, PUSH0, PUSHSize("b_msg", "tail")),
+					Fn(RETURN, PUSH0, PUSH(32)),
+				},
+				Label("b_msg"), msg, Label("b_end"),
+				Label("tail"),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dedup := tt.code.DeduplicateData()
+
+			orig, err := tt.code.Compile()
+			if err != nil {
+				t.Fatalf("%T.Compile() error %v", tt.code, err)
+			}
+			got, err := dedup.Compile()
+			if err != nil {
+				t.Fatalf("%T.DeduplicateData().Compile() error %v", tt.code, err)
+			}
+			if saved := len(orig) - len(got); saved != tt.wantSaved {
+				t.Errorf("%T.DeduplicateData() saved %d bytes; want %d", tt.code, saved, tt.wantSaved)
+			}
+
+			for _, callData := range [][]byte{nil, {1}} {
+				want := mustRunByteCode(orig, callData)
+				if got := mustRunByteCode(got, callData); !bytes.Equal(got, want) {
+					t.Errorf("%T.DeduplicateData() changed behaviour with call data %#x; got %q; want %q", tt.code, callData, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package specops
+
+import (
+	"fmt"
+)
+
+// deployerRuntimeLabel is the Label, in Code returned by Deployer(), at which
+// the runtime bytecode begins.
+const deployerRuntimeLabel = "specops.Deployer.runtime"
+
+// Deployer returns initcode (i.e. contract-creation code) that executes ctor
+// before returning the compiled runtime Code, which is appended as data and
+// copied to memory with CODECOPY. The ctor MAY be nil and MUST NOT halt. Any
+// values that ctor leaves on the stack are ignored. An error is only returned
+// if runtime fails to compile.
+//
+// The returned Code uses a Label named "specops.Deployer.runtime", which
+// therefore MUST NOT be used by ctor.
+func Deployer(runtime, ctor Code) (Code, error) {
+	code, err := runtime.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("compiling runtime: %v", err)
+	}
+	size := PUSH(len(code))
+	return Code{
+		ctor,
+		Fn(CODECOPY, PUSH0, PUSH(deployerRuntimeLabel), size),
+		Fn(RETURN, PUSH0, size),
+		Label(deployerRuntimeLabel),
+		Raw(code),
+	}, nil
+}
+
+// CompileDeployer compiles the Code as runtime bytecode, and returns initcode
+// that deploys it. It is equivalent to compiling the Code returned by
+// Deployer(c, nil).
+func (c Code) CompileDeployer() ([]byte, error) {
+	d, err := Deployer(c, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.Compile()
+}
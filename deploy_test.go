@@ -0,0 +1,73 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/runopts"
+)
+
+func TestDeployer(t *testing.T) {
+	runtime := Code{
+		Fn(MSTORE, PUSH0, CALLER),
+		Fn(RETURN, PUSH(12), PUSH(20)),
+	}
+	want, err := runtime.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", runtime, err)
+	}
+
+	t.Run("CompileDeployer", func(t *testing.T) {
+		initcode, err := runtime.CompileDeployer()
+		if err != nil {
+			t.Fatalf("%T.CompileDeployer() error %v", runtime, err)
+		}
+		// PUSH1 size, PUSH1 offset, PUSH0, CODECOPY, PUSH1 size, PUSH0, RETURN
+		const offset = 10
+		if diff := cmp.Diff(want, initcode[offset:]); diff != "" {
+			t.Errorf("%T.CompileDeployer()[%d:] diff (-runtime +got):\n%s", runtime, offset, diff)
+		}
+
+		res, err := Code{Raw(initcode)}.Run(nil)
+		if err != nil {
+			t.Fatalf("Run(%T.CompileDeployer()) error %v", runtime, err)
+		}
+		if diff := cmp.Diff(want, res.ReturnData); diff != "" {
+			t.Errorf("Run(%T.CompileDeployer()) returned diff (-runtime +got):\n%s", runtime, diff)
+		}
+	})
+
+	t.Run("with constructor", func(t *testing.T) {
+		ctor := Code{
+			Fn(SSTORE, PUSH0, PUSH(42)),
+			CALLVALUE, // left on the stack
+		}
+		deployer, err := Deployer(runtime, ctor)
+		if err != nil {
+			t.Fatalf("Deployer() error %v", err)
+		}
+
+		storage := runopts.CaptureStorageDiff(runopts.DefaultContractAddress())
+		res, err := deployer.Run(nil, storage)
+		if err != nil {
+			t.Fatalf("%T.Run([Deployer()]) error %v", deployer, err)
+		}
+		if diff := cmp.Diff(want, res.ReturnData); diff != "" {
+			t.Errorf("%T.Run([Deployer()]) returned diff (-runtime +got):\n%s", deployer, diff)
+		}
+		wantStorage := map[common.Hash]common.Hash{{}: {31: 42}}
+		if diff := cmp.Diff(wantStorage, storage.Val); diff != "" {
+			t.Errorf("%T.Run([Deployer()]) storage diff (-want +got):\n%s", deployer, diff)
+		}
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		_, err := Deployer(Code{ADD}, nil)
+		if err == nil || !strings.Contains(err.Error(), "compiling runtime") {
+			t.Errorf("Deployer(%T{ADD}, nil) got err %v; want compilation error", runtime, err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+const (
+	// maxStackDepth is the EVM's limit on the number of stack items.
+	maxStackDepth = 1024
+	// depthGuardPeak is the number of items, in excess of those filled in by
+	// a runtime depth guard, that are transiently on the stack during its
+	// filling loop.
+	depthGuardPeak = 3
+	// maxGuardedDepth is the greatest depth that runtimeDepthGuard() can
+	// check, as the filling loop must run at least once.
+	maxGuardedDepth = maxStackDepth - depthGuardPeak - 2
+	// depthGuardLoopBack is the distance from the JUMPDEST at the start of
+	// each guard loop to the PC opcode in decrementAndLoop.
+	depthGuardLoopBack = 10
+)
+
+// runtimeDepthGuard returns the bytecode emitted for a stack.ExpectDepthRuntime
+// of the specified depth. It has no net effect on the stack, and halts
+// exceptionally if the concrete depth is greater than `depth` or (checking at
+// most 16 items) less than it.
+//
+// The greater-than check pushes items until the stack would overflow if it
+// already had more than `depth` items, before popping them all. Both of its
+// loops jump relative to the PC so the bytecode is independent of its
+// location, and each loop body is 3 bytes before decrementAndLoop, which
+// depthGuardLoopBack depends on.
+func runtimeDepthGuard(depth uint) (Raw, error) {
+	if depth > maxGuardedDepth {
+		return nil, fmt.Errorf("%T(%d) exceeds maximum of %d", stack.ExpectDepthRuntime(0), depth, maxGuardedDepth)
+	}
+
+	var tooShallow Code
+	if depth > 0 {
+		tooShallow = Code{
+			types.OpCode(vm.DUP1 + vm.OpCode(min(depth, 16)-1)), // underflows
+			POP,
+		}
+	}
+
+	decrementAndLoop := Code{
+		PUSH(1), SWAP1, SUB,
+		Fn(JUMPI, Fn(SUB, PC, PUSH(depthGuardLoopBack)), DUP1),
+	}
+	fill := uint64(maxStackDepth - depthGuardPeak - 1 - depth) // the counter is the last item
+
+	code := Code{
+		stack.SetDepth(depth),
+		tooShallow,
+		PUSH(fill),
+		// Add an item below the counter.
+		Raw{byte(vm.JUMPDEST)}, PUSH0, SWAP1,
+		decrementAndLoop,
+		POP,
+		PUSH(fill),
+		// Remove the item below the counter.
+		Raw{byte(vm.JUMPDEST)}, SWAP1, POP,
+		decrementAndLoop,
+		POP,
+	}
+	b, err := code.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return Raw(b), nil
+}
@@ -0,0 +1,100 @@
+package specops
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestExpectDepthRuntime(t *testing.T) {
+	tests := []struct {
+		depth uint
+		// Raw opcodes aren't tracked by the compiler, so can be used to make
+		// the concrete depth differ from the compile-time one.
+		offBy    int
+		wantHalt bool
+	}{
+		{depth: 0, offBy: 0},
+		{depth: 0, offBy: 1, wantHalt: true},
+		{depth: 1, offBy: 0},
+		{depth: 1, offBy: -1, wantHalt: true},
+		{depth: 1, offBy: 1, wantHalt: true},
+		{depth: 16, offBy: 0},
+		{depth: 16, offBy: -1, wantHalt: true},
+		{depth: 16, offBy: 1, wantHalt: true},
+		{depth: 17, offBy: 0},
+		{depth: 17, offBy: -1, wantHalt: false}, // documented limitation
+		{depth: 17, offBy: 1, wantHalt: true},
+		{depth: maxGuardedDepth, offBy: 0},
+		{depth: maxGuardedDepth, offBy: -1, wantHalt: false},
+		{depth: maxGuardedDepth, offBy: 1, wantHalt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("depth %d off by %d", tt.depth, tt.offBy), func(t *testing.T) {
+			var code Code
+			for i := uint(0); i < tt.depth; i++ {
+				code = append(code, PUSH(uint64(i+1)))
+			}
+			switch {
+			case tt.offBy > 0:
+				code = append(code, Raw{byte(vm.PUSH0)})
+			case tt.offBy < 0:
+				code = append(code, Raw{byte(vm.POP)})
+			}
+			code = append(code, stack.ExpectDepthRuntime(tt.depth))
+
+			var want []byte
+			if tt.depth > 0 && tt.offBy == 0 {
+				// The guard must leave the stack intact.
+				code = append(code,
+					Fn(MSTORE, PUSH0),
+					Fn(RETURN, PUSH0, PUSH(32)),
+				)
+				w := uint256.NewInt(uint64(tt.depth)).Bytes32()
+				want = w[:]
+			}
+
+			got, err := code.Run(nil)
+			if gotHalt := err != nil; gotHalt != tt.wantHalt {
+				t.Fatalf("%T.Run() got error %v; want halt = %t", code, err, tt.wantHalt)
+			}
+			if !tt.wantHalt && string(got.ReturnData) != string(want) {
+				t.Errorf("%T.Run() got return data %#x; want %#x", code, got.ReturnData, want)
+			}
+		})
+	}
+}
+
+func TestExpectDepthRuntimeCompileErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        Code
+		errContains string
+	}{
+		{
+			name:        "compile-time mismatch",
+			code:        Code{PUSH0, stack.ExpectDepthRuntime(2)},
+			errContains: "stack depth 1 when expecting 2",
+		},
+		{
+			name:        "too deep",
+			code:        Code{stack.SetDepth(maxGuardedDepth + 1), stack.ExpectDepthRuntime(maxGuardedDepth + 1)},
+			errContains: "exceeds maximum",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.code.Compile()
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("%T.Compile() got error %v; want containing %q", tt.code, err, tt.errContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package specops
+
+import (
+	"fmt"
+	"strings"
+)
+
+// depthTraceLen is the number of recent stack-depth changes retained by a
+// depthTrace.
+const depthTraceLen = 8
+
+// A depthTrace is a ring buffer of the most recent changes to the compiler's
+// belief about the stack depth, used to explain stack.ExpectDepth failures.
+type depthTrace struct {
+	steps [depthTraceLen]depthStep
+	n     int // total number of steps ever recorded
+}
+
+// A depthStep describes a single change in stack depth. If set is true then
+// the depth was overridden (e.g. by stack.SetDepth) instead of being the result
+// of popping and pushing.
+type depthStep struct {
+	desc      string
+	pop, push uint
+	set       bool
+	depth     uint // after the step
+}
+
+// record appends the step to the trace, evicting the oldest if full.
+func (t *depthTrace) record(s depthStep) {
+	t.steps[t.n%depthTraceLen] = s
+	t.n++
+}
+
+// String returns the retained steps, oldest first.
+func (t *depthTrace) String() string {
+	start := max(t.n-depthTraceLen, 0)
+	parts := make([]string, 0, t.n-start)
+	for i := start; i < t.n; i++ {
+		parts = append(parts, t.steps[i%depthTraceLen].String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s depthStep) String() string {
+	if s.set {
+		return fmt.Sprintf("%s [=%d]", s.desc, s.depth)
+	}
+	return fmt.Sprintf("%s [-%d +%d =%d]", s.desc, s.pop, s.push, s.depth)
+}
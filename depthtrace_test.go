@@ -0,0 +1,52 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestExpectDepthTrace(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want string
+	}{
+		{
+			name: "opcodes",
+			code: Code{
+				PUSH0, PUSH(1), DUP2, ADD,
+				stack.ExpectDepth(1),
+			},
+			want: "stack depth 2 when expecting 1; last changes: PUSH0 [-0 +1 =1], PUSH1 [-0 +1 =2], DUP2 [-1 +2 =3], ADD [-2 +1 =2]",
+		},
+		{
+			name: "SetDepth and label",
+			code: Code{
+				JUMPDEST("x"), stack.SetDepth(3),
+				PUSH("x"), POP, POP,
+				stack.ExpectDepth(3),
+			},
+			want: `stack depth 2 when expecting 3; last changes: stack.SetDepth(3) [=3], PUSH("x") [-0 +1 =4], POP [-1 +0 =3], POP [-1 +0 =2]`,
+		},
+		{
+			name: "only most recent retained",
+			code: Code{
+				PUSH0, PUSH0, PUSH0, PUSH0, PUSH0,
+				POP, POP, POP, POP, POP,
+				stack.ExpectDepth(1),
+			},
+			want: "last changes: PUSH0 [-0 +1 =3], PUSH0 [-0 +1 =4], PUSH0 [-0 +1 =5], POP [-1 +0 =4], POP [-1 +0 =3], POP [-1 +0 =2], POP [-1 +0 =1], POP [-1 +0 =0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.code.Compile()
+			if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+				t.Errorf("%T.Compile() got error %v; want ending in %q", tt.code, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package specops
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A DiffChange describes how a line of a CodeDiff differs between the two
+// Codes.
+type DiffChange int
+
+// Kinds of DiffChange.
+const (
+	DiffUnchanged DiffChange = iota
+	DiffRemoved              // only present in the first Code
+	DiffAdded                // only present in the second Code
+)
+
+// A DiffLine is a single instruction of a CodeDiff.
+type DiffLine struct {
+	Change DiffChange
+	// PCA and PCB are the offsets of the instruction in the first and second
+	// compiled Codes respectively, or -1 if the instruction is absent.
+	PCA, PCB int
+	// Instruction is the opcode's mnemonic, followed by any immediate data.
+	Instruction string
+}
+
+// A CodeDiff is the opcode-level difference between two compiled Codes, as
+// returned by Diff(). A changed instruction is represented as a DiffRemoved
+// line followed by a DiffAdded one.
+type CodeDiff []DiffLine
+
+// Diff compiles both Codes and returns the difference between their
+// disassembled instructions, based on their longest common subsequence. An
+// instruction is only considered unchanged if both its opcode and immediate
+// data are equal; its PC may differ.
+func Diff(a, b Code) (CodeDiff, error) {
+	instrs := make([][]string, 2)
+	pcs := make([][]int, 2)
+	for i, c := range []Code{a, b} {
+		compiled, err := c.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("Code[%d]: %v", i, err)
+		}
+		for _, in := range disassemble(compiled) {
+			instrs[i] = append(instrs[i], in.String())
+			pcs[i] = append(pcs[i], in.pc)
+		}
+	}
+
+	var diff CodeDiff
+	for _, e := range lcsEdits(instrs[0], instrs[1]) {
+		l := DiffLine{
+			Change: e.change,
+			PCA:    -1,
+			PCB:    -1,
+		}
+		if e.i != -1 {
+			l.PCA = pcs[0][e.i]
+			l.Instruction = instrs[0][e.i]
+		}
+		if e.j != -1 {
+			l.PCB = pcs[1][e.j]
+			l.Instruction = instrs[1][e.j]
+		}
+		diff = append(diff, l)
+	}
+	return diff, nil
+}
+
+// Equal returns whether the CodeDiff has only DiffUnchanged lines.
+func (d CodeDiff) Equal() bool {
+	for _, l := range d {
+		if l.Change != DiffUnchanged {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a human-readable diff, in the style of cmp.Diff() with -a
+// +b, with the PCs of each instruction in a and b respectively. Every line
+// has the format `%c %5s %5s %s`; i.e. the change marker, both PCs in decimal
+// (blank if absent), and the instruction. It is therefore also suitable for
+// parsing.
+func (d CodeDiff) String() string {
+	var b strings.Builder
+	pc := func(pc int) string {
+		if pc == -1 {
+			return ""
+		}
+		return strconv.Itoa(pc)
+	}
+	for _, l := range d {
+		fmt.Fprintf(&b, "%c %5s %5s %s\n", l.Change.marker(), pc(l.PCA), pc(l.PCB), l.Instruction)
+	}
+	return b.String()
+}
+
+func (c DiffChange) marker() byte {
+	switch c {
+	case DiffRemoved:
+		return '-'
+	case DiffAdded:
+		return '+'
+	default:
+		return ' '
+	}
+}
+
+// An lcsEdit is a single step in transforming one slice into another. The
+// index i (j) is into the first (second) slice, or -1 if the element is absent
+// from it.
+type lcsEdit struct {
+	change DiffChange
+	i, j   int
+}
+
+// lcsEdits returns the edits that transform a into b, based on their longest
+// common subsequence. Removals are ordered before additions when both are
+// possible.
+func lcsEdits[T comparable](a, b []T) []lcsEdit {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []lcsEdit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, lcsEdit{DiffUnchanged, i, j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, lcsEdit{DiffRemoved, i, -1})
+			i++
+		default:
+			edits = append(edits, lcsEdit{DiffAdded, -1, j})
+			j++
+		}
+	}
+	return edits
+}
@@ -0,0 +1,78 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestDiff(t *testing.T) {
+	a := Code{
+		Fn(MSTORE, PUSH0, PUSH(42)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+	b := Code{
+		Fn(JUMPI, PUSH("ret"), CALLVALUE),
+		JUMPDEST("ret"), stack.SetDepth(0),
+		Fn(MSTORE, PUSH0, PUSH(43)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+
+	got, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("Diff() error %v", err)
+	}
+	want := CodeDiff{
+		{DiffRemoved, 0, -1, "PUSH1 0x2a"},
+		{DiffAdded, -1, 0, "CALLVALUE"},
+		{DiffAdded, -1, 1, "PUSH1 0x04"},
+		{DiffAdded, -1, 3, "JUMPI"},
+		{DiffAdded, -1, 4, "JUMPDEST"},
+		{DiffAdded, -1, 5, "PUSH1 0x2b"},
+		{DiffUnchanged, 2, 7, "PUSH0"},
+		{DiffUnchanged, 3, 8, "MSTORE"},
+		{DiffUnchanged, 4, 9, "PUSH1 0x20"},
+		{DiffUnchanged, 6, 11, "PUSH0"},
+		{DiffUnchanged, 7, 12, "RETURN"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Diff() diff (-want +got):\n%s", diff)
+	}
+	if got.Equal() {
+		t.Errorf("%T.Equal() = true; want false", got)
+	}
+
+	wantStr := `-     0       PUSH1 0x2a
++           0 CALLVALUE
++           1 PUSH1 0x04
++           3 JUMPI
++           4 JUMPDEST
++           5 PUSH1 0x2b
+      2     7 PUSH0
+      3     8 MSTORE
+      4     9 PUSH1 0x20
+      6    11 PUSH0
+      7    12 RETURN
+`
+	if diff := cmp.Diff(wantStr, got.String()); diff != "" {
+		t.Errorf("%T.String() diff (-want +got):\n%s", got, diff)
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		d, err := Diff(a, a)
+		if err != nil {
+			t.Fatalf("Diff() error %v", err)
+		}
+		if !d.Equal() {
+			t.Errorf("Diff(a, a).Equal() = false; diff:\n%v", d)
+		}
+	})
+
+	t.Run("compilation error", func(t *testing.T) {
+		if _, err := Diff(a, Code{Fn(JUMP, PUSH("missing"))}); err == nil {
+			t.Error("Diff(<valid>, <invalid>) got nil error")
+		}
+	})
+}
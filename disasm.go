@@ -0,0 +1,51 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// An instruction is a single opcode in compiled bytecode, along with any
+// immediate data that it carries (i.e. the value pushed by a PUSH<N>).
+type instruction struct {
+	pc   int
+	op   vm.OpCode
+	data []byte // nil if op isn't PUSH1 to PUSH32
+	// truncated is true i.f.f. the bytecode ended before all of the PUSH<N>
+	// bytes were available, in which case data is shorter than N.
+	truncated bool
+}
+
+// disassemble walks the bytecode, splitting it into instructions. It is the
+// inverse of compilation, but without recovery of special opcodes.
+func disassemble(code []byte) []instruction {
+	var instrs []instruction
+	for pc := 0; pc < len(code); pc++ {
+		in := instruction{
+			pc: pc,
+			op: vm.OpCode(code[pc]),
+		}
+
+		if in.op.IsPush() && in.op != vm.PUSH0 {
+			n := int(in.op - vm.PUSH0)
+			end := pc + 1 + n
+			if end > len(code) {
+				end = len(code)
+				in.truncated = true
+			}
+			in.data = code[pc+1 : end]
+			pc = end - 1
+		}
+		instrs = append(instrs, in)
+	}
+	return instrs
+}
+
+// String returns the opcode's mnemonic, followed by any immediate data.
+func (in instruction) String() string {
+	if in.data == nil {
+		return in.op.String()
+	}
+	return fmt.Sprintf("%v %#x", in.op, in.data)
+}
@@ -0,0 +1,456 @@
+package specops
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// EOF (EIP-3540) container constants, as per the EOFv1 specification that
+// includes the type section of EIP-4750.
+const (
+	eofMagic     = 0xef00
+	eofVersion   = 1
+	eofKindType  = 0x01
+	eofKindCode  = 0x02
+	eofKindData  = 0xff
+	eofTerm      = 0x00
+	eofTypeSize  = 4    // inputs, outputs, and 2-byte max stack height
+	eofNonReturn = 0x80 // outputs of a section that never returns
+
+	eofMaxStackHeight  = 1023
+	eofMaxCodeSections = 1024
+	eofMaxOutputs      = 0x7f
+)
+
+// eofBannedOps are legacy opcodes that are invalid in EOF code sections.
+var eofBannedOps = map[vm.OpCode]bool{
+	vm.CALLCODE:     true,
+	vm.SELFDESTRUCT: true,
+	vm.JUMP:         true,
+	vm.JUMPI:        true,
+	vm.PC:           true,
+	vm.CREATE:       true,
+	vm.CREATE2:      true,
+	vm.CALL:         true,
+	vm.DELEGATECALL: true,
+	vm.STATICCALL:   true,
+	vm.CODESIZE:     true,
+	vm.CODECOPY:     true,
+	vm.EXTCODESIZE:  true,
+	vm.EXTCODECOPY:  true,
+	vm.EXTCODEHASH:  true,
+	vm.GAS:          true,
+}
+
+// CompileEOF compiles the Code as the single code section of an EOF (EIP-3540)
+// container, with the data as its data section, which MAY be empty. The
+// container is laid out as follows, with all sizes as big-endian uint16s:
+//
+//	0xef00 0x01                  magic and version
+//	0x01 0x0004                  type section of one entry
+//	0x02 0x0001 <code size>      one code section
+//	0xff <data size>             data section
+//	0x00                         header terminator
+//	0x00 0x80 <max stack height> type entry: no inputs, non-returning
+//	<code>
+//	<data>
+//
+// The type section is that introduced by EIP-4750 and included in all
+// implementations of EOFv1. The maximum stack height is computed over every
+// path through the code's relative jumps (see RJUMP()), not only the linear
+// stack depth tracked by the compiler.
+//
+// CompileEOF is equivalent to CompileEOFFuncs() with a single, non-returning
+// EOFFunc, the errors of which it shares.
+func (c Code) CompileEOF(data []byte) ([]byte, error) {
+	return CompileEOFFuncs(data, EOFFunc{
+		Outputs: EOFNonReturning,
+		Code:    c,
+	})
+}
+
+// EOFNonReturning is the EOFFunc.Outputs value of a function that never
+// returns to its caller; i.e. it doesn't include RETF.
+const EOFNonReturning = eofNonReturn
+
+// An EOFFunc is a function, compiled as an EOF (EIP-4750) code section, that
+// can be called by name with CALLF() and that returns with RETF. Its Code
+// starts with a stack depth equal to Inputs and MUST have a depth equal to
+// Outputs at every RETF.
+type EOFFunc struct {
+	// Name, if non-empty, allows the function to be called with CALLF(Name).
+	Name            string
+	Inputs, Outputs uint8
+	// MaxStackHeight is computed by the compiler if zero, otherwise it MUST be
+	// equal to the computed value.
+	MaxStackHeight uint16
+	Code           Code
+}
+
+// CALLF returns a Bytecoder that calls the EOFFunc with the name, which is
+// resolved to the index of its code section by CompileEOFFuncs(). The called
+// function's Inputs are popped from the stack and its Outputs are pushed.
+// Compilation fails if CALLF is used outside of CompileEOFFuncs() or if the
+// called function is non-returning.
+func CALLF[T ~string](name T) types.Bytecoder {
+	return callF(name)
+}
+
+// RETF returns from an EOFFunc to its caller, and is only valid with
+// CompileEOFFuncs().
+const RETF = retF(opRETF)
+
+// EOF (EIP-4750) function opcodes, which aren't defined by geth.
+const (
+	opCALLF = vm.OpCode(0xe3)
+	opRETF  = vm.OpCode(0xe4)
+)
+
+type (
+	callF tag
+	retF  vm.OpCode
+)
+
+func (c callF) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("%s outside of CompileEOFFuncs()", bytecoderString(c))
+}
+
+func (retF) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("RETF outside of CompileEOFFuncs()")
+}
+
+// An eofCall is a CALLF resolved by CompileEOFFuncs().
+type eofCall struct {
+	name    tag
+	index   uint16
+	inputs  uint8
+	outputs uint8
+}
+
+func (c eofCall) Bytecode() ([]byte, error) {
+	return []byte{byte(opCALLF), byte(c.index >> 8), byte(c.index)}, nil
+}
+
+// CompileEOFFuncs compiles the functions, in order, as the code sections of an
+// EOF container, with the data as its data section; see Code.CompileEOF() for
+// the layout. The first function is the container's entry point so MUST have
+// zero Inputs and be EOFNonReturning.
+//
+// Stack effects are validated as per EIP-5450, following every path through
+// relative jumps: each instruction MUST be reached with the same stack height
+// along all paths, the stack MUST hold the Inputs of every function called
+// with CALLF(), MUST hold exactly a function's Outputs at every RETF, and its
+// maximum height MUST NOT exceed the EOF limit of 1023. Only the callee's own
+// frame is considered, so overflow of the entire stack across calls isn't
+// detected.
+//
+// An error is also returned if any function's Code is empty, if it doesn't end
+// with a terminating opcode (STOP, RETURN, REVERT, INVALID, RJUMP, or RETF),
+// if it contains any legacy opcode that EOF bans (e.g. JUMP, PC, GAS,
+// CODECOPY, or CALL), or if any size exceeds its limit. Validation is
+// otherwise minimal; e.g. unreachable code isn't rejected.
+func CompileEOFFuncs(data []byte, funcs ...EOFFunc) ([]byte, error) {
+	switch n := len(funcs); {
+	case n == 0:
+		return nil, fmt.Errorf("no EOF code sections")
+	case n > eofMaxCodeSections:
+		return nil, fmt.Errorf("%d EOF code sections exceeds limit of %d", n, eofMaxCodeSections)
+	}
+	if f := funcs[0]; f.Inputs != 0 || f.Outputs != EOFNonReturning {
+		return nil, fmt.Errorf("first EOF code section MUST have 0 inputs and be non-returning; got %d inputs and %#x outputs", f.Inputs, f.Outputs)
+	}
+
+	byName := make(map[tag]int)
+	for i, f := range funcs {
+		if f.Name == "" {
+			continue
+		}
+		if _, ok := byName[tag(f.Name)]; ok {
+			return nil, fmt.Errorf("duplicate %T name %q", f, f.Name)
+		}
+		byName[tag(f.Name)] = i
+	}
+
+	sigs := make([]eofType, len(funcs))
+	code := make([][]byte, len(funcs))
+	for i, f := range funcs {
+		t, c, err := f.compile(funcs, byName)
+		if err != nil {
+			return nil, fmt.Errorf("EOF code section [%d] %q: %w", i, f.Name, err)
+		}
+		sigs[i] = t
+		code[i] = c
+	}
+	return eofContainer(sigs, code, data)
+}
+
+// compile compiles the EOFFunc as a code section, resolving CALLF()s against
+// all of the funcs, with indices keyed by name.
+func (f EOFFunc) compile(funcs []EOFFunc, byName map[tag]int) (eofType, []byte, error) {
+	returns := f.Outputs != EOFNonReturning
+	if returns && f.Outputs > eofMaxOutputs {
+		return eofType{}, nil, fmt.Errorf("%d outputs exceeds EOF limit of %d", f.Outputs, eofMaxOutputs)
+	}
+
+	body := Code{stack.SetDepth(f.Inputs)}
+	for _, bc := range f.Code.flatten() {
+		switch bc := bc.(type) {
+		case callF:
+			i, ok := byName[tag(bc)]
+			if !ok {
+				return eofType{}, nil, fmt.Errorf("%s without corresponding %T", bytecoderString(bc), f)
+			}
+			callee := funcs[i]
+			if callee.Outputs == EOFNonReturning {
+				return eofType{}, nil, fmt.Errorf("%s of non-returning function", bytecoderString(bc))
+			}
+			body = append(body, eofCall{
+				name:    tag(bc),
+				index:   uint16(i),
+				inputs:  callee.Inputs,
+				outputs: callee.Outputs,
+			})
+
+		case retF:
+			if !returns {
+				return eofType{}, nil, fmt.Errorf("RETF in non-returning function")
+			}
+			body = append(body, stack.ExpectDepth(f.Outputs), Raw{byte(opRETF)})
+
+		default:
+			body = append(body, bc)
+		}
+	}
+
+	comp, err := body.compile()
+	if err != nil {
+		return eofType{}, nil, err
+	}
+	code := comp.bytecode
+
+	if len(code) == 0 {
+		return eofType{}, nil, fmt.Errorf("empty EOF code section")
+	}
+	var last vm.OpCode
+	for _, in := range disassembleEOF(code) {
+		if eofBannedOps[in.op] {
+			return eofType{}, nil, fmt.Errorf("%v at PC %d is invalid in EOF code", in.op, in.pc)
+		}
+		if in.truncated {
+			return eofType{}, nil, fmt.Errorf("truncated %v at PC %d", in.op, in.pc)
+		}
+		last = in.op
+	}
+	// JUMP and SELFDESTRUCT are considered terminal but are already banned.
+	if !isTerminal(last) && last != opRJUMP && last != opRETF {
+		return eofType{}, nil, fmt.Errorf("EOF code section ends with %v; must end with a terminating opcode", last)
+	}
+
+	height, err := eofStackHeight(code, f, funcs)
+	if err != nil {
+		return eofType{}, nil, err
+	}
+	if height > eofMaxStackHeight {
+		return eofType{}, nil, fmt.Errorf("max stack height %d exceeds EOF limit of %d", height, eofMaxStackHeight)
+	}
+	if m := f.MaxStackHeight; m != 0 && uint(m) != height {
+		return eofType{}, nil, fmt.Errorf("declared max stack height %d; computed %d", m, height)
+	}
+
+	return eofType{
+		inputs:         f.Inputs,
+		outputs:        f.Outputs,
+		maxStackHeight: uint16(height),
+	}, code, nil
+}
+
+// eofStackHeight returns the maximum stack height of the EOFFunc's compiled
+// code section over all paths through its relative jumps (EIP-5450), with
+// CALLF()s resolved against the funcs. An error is returned if any instruction
+// is reached with different stack heights, if the stack would underflow, if
+// any RETF doesn't have exactly the function's Outputs on the stack, or if a
+// relative jump doesn't land on an instruction boundary.
+func eofStackHeight(code []byte, f EOFFunc, funcs []EOFFunc) (uint, error) {
+	instrs := disassembleEOF(code)
+	at := make(map[int]int, len(instrs)) // PC -> index in instrs
+	for i, in := range instrs {
+		at[in.pc] = i
+	}
+
+	heights := make(map[int]uint, len(instrs)) // keyed by index in instrs
+	highest := uint(f.Inputs)
+	var visit func(i int, h uint) error
+	visit = func(i int, h uint) error {
+		for ; i < len(instrs); i++ {
+			in := instrs[i]
+			if prev, ok := heights[i]; ok {
+				if prev != h {
+					return fmt.Errorf("stack height %d at PC %d when previously reached with %d", h, in.pc, prev)
+				}
+				return nil
+			}
+			heights[i] = h
+
+			var d stackDelta
+			switch in.op {
+			case opRJUMP:
+			case opRJUMPI, opRJUMPV:
+				d.pop = 1
+			case opRETF:
+				if h != uint(f.Outputs) {
+					return fmt.Errorf("RETF at PC %d with stack height %d; want %d outputs", in.pc, h, f.Outputs)
+				}
+			case opCALLF:
+				idx := int(binary.BigEndian.Uint16(in.data))
+				if idx >= len(funcs) {
+					return fmt.Errorf("CALLF at PC %d of code section %d; only %d exist", in.pc, idx, len(funcs))
+				}
+				callee := funcs[idx]
+				d = stackDelta{pop: uint(callee.Inputs), push: uint(callee.Outputs)}
+			default:
+				var ok bool
+				if d, ok = stackDeltas[in.op]; !ok {
+					return fmt.Errorf("undefined opcode %#x at PC %d", byte(in.op), in.pc)
+				}
+			}
+			if h < d.pop {
+				return fmt.Errorf("%v at PC %d popping %d values with stack height %d", in.op, in.pc, d.pop, h)
+			}
+			h = h - d.pop + d.push
+			if h > highest {
+				highest = h
+			}
+
+			var rel []byte // big-endian int16 offsets of relative jumps
+			switch in.op {
+			case opRJUMP, opRJUMPI:
+				rel = in.data
+			case opRJUMPV:
+				rel = in.data[1:]
+			}
+			end := in.pc + 1 + len(in.data)
+			for j := 0; j+1 < len(rel); j += 2 {
+				dest := end + int(int16(binary.BigEndian.Uint16(rel[j:])))
+				k, ok := at[dest]
+				if !ok {
+					return fmt.Errorf("%v at PC %d to %d, which isn't an instruction", in.op, in.pc, dest)
+				}
+				if err := visit(k, h); err != nil {
+					return err
+				}
+			}
+
+			if isTerminal(in.op) || in.op == opRJUMP || in.op == opRETF {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	if err := visit(0, uint(f.Inputs)); err != nil {
+		return 0, err
+	}
+	return highest, nil
+}
+
+// An eofType is an entry in an EOF container's type section, describing the
+// code section at the same index.
+type eofType struct {
+	inputs, outputs uint8
+	maxStackHeight  uint16
+}
+
+// eofContainer returns the EOF container with the code sections, described by
+// the respective types, and the data.
+func eofContainer(types []eofType, code [][]byte, data []byte) ([]byte, error) {
+	if len(types) != len(code) {
+		return nil, fmt.Errorf("%d EOF types for %d code sections", len(types), len(code))
+	}
+	u16 := func(n int, what string) (uint16, error) {
+		if n > 0xffff {
+			return 0, fmt.Errorf("%s %d exceeds EOF limit of %d", what, n, 0xffff)
+		}
+		return uint16(n), nil
+	}
+
+	be := binary.BigEndian
+	out := be.AppendUint16(nil, eofMagic)
+	out = append(out, eofVersion, eofKindType)
+
+	typeSize, err := u16(eofTypeSize*len(types), "type-section size")
+	if err != nil {
+		return nil, err
+	}
+	out = be.AppendUint16(out, typeSize)
+
+	numCode, err := u16(len(code), "number of code sections")
+	if err != nil {
+		return nil, err
+	}
+	out = be.AppendUint16(append(out, eofKindCode), numCode)
+	for i, c := range code {
+		n, err := u16(len(c), fmt.Sprintf("code section [%d] size", i))
+		if err != nil {
+			return nil, err
+		}
+		out = be.AppendUint16(out, n)
+	}
+
+	dataSize, err := u16(len(data), "data-section size")
+	if err != nil {
+		return nil, err
+	}
+	out = be.AppendUint16(append(out, eofKindData), dataSize)
+	out = append(out, eofTerm)
+
+	for _, t := range types {
+		out = append(out, t.inputs, t.outputs)
+		out = be.AppendUint16(out, t.maxStackHeight)
+	}
+	for _, c := range code {
+		out = append(out, c...)
+	}
+	return append(out, data...), nil
+}
+
+// disassembleEOF is equivalent to disassemble() except that it also skips the
+// immediates of EOF opcodes, which are recorded as instruction data.
+func disassembleEOF(code []byte) []instruction {
+	var instrs []instruction
+	for pc := 0; pc < len(code); pc++ {
+		in := instruction{
+			pc: pc,
+			op: vm.OpCode(code[pc]),
+		}
+
+		var n int
+		switch op := in.op; {
+		case op.IsPush():
+			n = int(op - vm.PUSH0)
+		case op == opRJUMP, op == opRJUMPI, op == opCALLF:
+			n = 2
+		case op == opRJUMPV:
+			if pc+1 < len(code) {
+				n = relJumpSize(op, int(code[pc+1])+1) - 1
+			} else {
+				n = 1
+			}
+		}
+		if n > 0 {
+			end := pc + 1 + n
+			if end > len(code) {
+				end = len(code)
+				in.truncated = true
+			}
+			in.data = code[pc+1 : end]
+			pc = end - 1
+		}
+		instrs = append(instrs, in)
+	}
+	return instrs
+}
@@ -0,0 +1,287 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/types"
+)
+
+func TestCompileEOF(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, CALLVALUE),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+	data := []byte{0xaa, 0xbb, 0xcc}
+
+	got, err := code.CompileEOF(data)
+	if err != nil {
+		t.Fatalf("%T.CompileEOF() error %v", code, err)
+	}
+
+	body, err := code.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", code, err)
+	}
+
+	want := []byte{
+		0xef, 0x00, 0x01, // magic + version
+		0x01, 0x00, 0x04, // type section
+		0x02, 0x00, 0x01, 0x00, byte(len(body)), // one code section
+		0xff, 0x00, 0x03, // data section
+		0x00,                   // terminator
+		0x00, 0x80, 0x00, 0x02, // type: 0 inputs, non-returning, max height 2
+	}
+	want = append(want, body...)
+	want = append(want, data...)
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%T.CompileEOF() diff (-want +got):\n%s", code, diff)
+	}
+}
+
+func TestCompileEOFErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        Code
+		data        []byte
+		errContains string
+	}{
+		{
+			name:        "empty",
+			code:        Code{},
+			errContains: "empty",
+		},
+		{
+			name:        "unterminated",
+			code:        Code{PUSH0, PUSH0, ADD},
+			errContains: "must end with a terminating opcode",
+		},
+		{
+			name:        "JUMP",
+			code:        Code{Fn(JUMP, PUSH0)},
+			errContains: "JUMP at PC 1",
+		},
+		{
+			name:        "GAS",
+			code:        Code{GAS, STOP},
+			errContains: "GAS at PC 0",
+		},
+		{
+			name:        "CODECOPY",
+			code:        Code{Fn(CODECOPY, PUSH0, PUSH0, PUSH0), STOP},
+			errContains: "CODECOPY at PC 3",
+		},
+		{
+			name:        "data too large",
+			code:        Code{STOP},
+			data:        make([]byte, 0x10000),
+			errContains: "data-section size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.code.CompileEOF(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("%T.CompileEOF() got err %v; want containing %q", tt.code, err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestCompileEOFMaxStackHeight(t *testing.T) {
+	code := Code{
+		PUSH0, PUSH0, PUSH0, PUSH0, PUSH0,
+		POP, POP, POP, POP, POP,
+		STOP,
+	}
+	got, err := code.CompileEOF(nil)
+	if err != nil {
+		t.Fatalf("%T.CompileEOF() error %v", code, err)
+	}
+	const heightOffset = 17
+	if h := int(got[heightOffset])<<8 | int(got[heightOffset+1]); h != 5 {
+		t.Errorf("%T.CompileEOF() max stack height = %d; want 5", code, h)
+	}
+
+	t.Run("label reached by relative jump", func(t *testing.T) {
+		// The compiler's linear depth tracking continues from the STOP, with
+		// depth 0, into the Label, but the RJUMPI arrives with depth 3.
+		code := Code{
+			PUSH0, PUSH0, PUSH0, PUSH(1),
+			RJUMPI("deep"),
+			POP, POP, POP,
+			STOP,
+			Label("deep"),
+			PUSH0, PUSH0,
+			STOP,
+		}
+		got, err := code.CompileEOF(nil)
+		if err != nil {
+			t.Fatalf("%T.CompileEOF() error %v", code, err)
+		}
+		if h := int(got[heightOffset])<<8 | int(got[heightOffset+1]); h != 5 {
+			t.Errorf("%T.CompileEOF() max stack height = %d; want 5", code, h)
+		}
+	})
+}
+
+func TestCompileEOFRelativeJumps(t *testing.T) {
+	code := Code{
+		PUSH0,
+		RJUMPI("end"),
+		Label("loop"),
+		RJUMP("loop"), // 0xe0fffd; the immediate's 0xff MUST NOT be treated as SELFDESTRUCT
+		Label("end"),
+		STOP,
+	}
+	if _, err := code.CompileEOF(nil); err != nil {
+		t.Errorf("%T.CompileEOF() error %v", code, err)
+	}
+
+	unterminated := Code{Label("loop"), PUSH0, RJUMPI("loop")}
+	if _, err := unterminated.CompileEOF(nil); err == nil {
+		t.Errorf("%T.CompileEOF() with trailing RJUMPI; got nil error", unterminated)
+	}
+	terminated := Code{Label("loop"), RJUMP("loop")}
+	if _, err := terminated.CompileEOF(nil); err != nil {
+		t.Errorf("%T.CompileEOF() with trailing RJUMP; error %v", terminated, err)
+	}
+}
+
+func TestCompileEOFFuncs(t *testing.T) {
+	main := EOFFunc{
+		Outputs: EOFNonReturning,
+		Code: Code{
+			Fn(CALLF("add"), PUSH(1), PUSH(2)),
+			Fn(MSTORE, PUSH0),
+			Fn(RETURN, PUSH0, PUSH(32)),
+		},
+	}
+	add := EOFFunc{
+		Name:    "add",
+		Inputs:  2,
+		Outputs: 1,
+		Code:    Code{ADD, RETF},
+	}
+
+	got, err := CompileEOFFuncs(nil, main, add)
+	if err != nil {
+		t.Fatalf("CompileEOFFuncs() error %v", err)
+	}
+
+	want := []byte{
+		0xef, 0x00, 0x01, // magic + version
+		0x01, 0x00, 0x08, // type section
+		0x02, 0x00, 0x02, 0x00, 0x0d, 0x00, 0x02, // two code sections
+		0xff, 0x00, 0x00, // data section
+		0x00,                   // terminator
+		0x00, 0x80, 0x00, 0x02, // main: 0 inputs, non-returning, max height 2
+		0x02, 0x01, 0x00, 0x02, // add: 2 inputs, 1 output, max height 2
+		// main
+		0x60, 2, 0x60, 1, byte(opCALLF), 0x00, 0x01,
+		byte(PUSH0), byte(MSTORE),
+		0x60, 32, byte(PUSH0), byte(RETURN),
+		// add
+		byte(ADD), byte(opRETF),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CompileEOFFuncs() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompileEOFFuncsErrors(t *testing.T) {
+	main := func(c ...types.Bytecoder) EOFFunc {
+		return EOFFunc{Outputs: EOFNonReturning, Code: c}
+	}
+	add := EOFFunc{Name: "add", Inputs: 2, Outputs: 1, Code: Code{ADD, RETF}}
+
+	tests := []struct {
+		name        string
+		funcs       []EOFFunc
+		errContains string
+	}{
+		{
+			name:        "first section with inputs",
+			funcs:       []EOFFunc{{Inputs: 1, Outputs: EOFNonReturning, Code: Code{STOP}}},
+			errContains: "first EOF code section",
+		},
+		{
+			name:        "first section returning",
+			funcs:       []EOFFunc{{Code: Code{RETF}}},
+			errContains: "first EOF code section",
+		},
+		{
+			name:        "duplicate name",
+			funcs:       []EOFFunc{main(STOP), add, add},
+			errContains: `duplicate specops.EOFFunc name "add"`,
+		},
+		{
+			name:        "CALLF unknown",
+			funcs:       []EOFFunc{main(CALLF("nope"), STOP)},
+			errContains: `CALLF("nope") without corresponding`,
+		},
+		{
+			name: "CALLF non-returning",
+			funcs: []EOFFunc{
+				main(CALLF("loop"), STOP),
+				{Name: "loop", Outputs: EOFNonReturning, Code: Code{STOP}},
+			},
+			errContains: "non-returning function",
+		},
+		{
+			name:        "CALLF with too few inputs",
+			funcs:       []EOFFunc{main(Fn(CALLF("add"), PUSH(1)), STOP), add},
+			errContains: `CALLF("add") popping 2 values with stack depth 1`,
+		},
+		{
+			name:        "RETF in non-returning",
+			funcs:       []EOFFunc{main(RETF)},
+			errContains: "RETF in non-returning function",
+		},
+		{
+			name: "RETF with wrong outputs",
+			funcs: []EOFFunc{
+				main(STOP),
+				{Name: "bad", Inputs: 2, Outputs: 1, Code: Code{RETF}},
+			},
+			errContains: "stack depth 2 when expecting 1",
+		},
+		{
+			name:        "inconsistent stack height at relative-jump destination",
+			funcs:       []EOFFunc{main(PUSH0, PUSH(1), RJUMPI("end"), PUSH0, Label("end"), STOP)},
+			errContains: "stack height 2 at PC 7 when previously reached with 1",
+		},
+		{
+			name: "declared max stack height",
+			funcs: []EOFFunc{
+				main(STOP),
+				{Name: "f", Inputs: 2, Outputs: 1, MaxStackHeight: 3, Code: Code{ADD, RETF}},
+			},
+			errContains: "declared max stack height 3; computed 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileEOFFuncs(nil, tt.funcs...)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("CompileEOFFuncs() got err %v; want containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestEOFFuncOpsOutsideContainer(t *testing.T) {
+	for _, c := range []Code{
+		{RETF},
+		{CALLF("f")},
+	} {
+		if _, err := c.Compile(); err == nil || !strings.Contains(err.Error(), "outside of CompileEOFFuncs()") {
+			t.Errorf("%v.Compile() got err %v; want outside of CompileEOFFuncs()", c, err)
+		}
+	}
+}
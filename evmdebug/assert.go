@@ -0,0 +1,70 @@
+package evmdebug
+
+import (
+	"bytes"
+	"strings"
+)
+
+// TestingT is the subset of testing.TB required by the assertion helpers,
+// allowing them to be used without importing the testing package in non-test
+// code. It is narrower than specops.TB, which also satisfies it.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertStack reports an error to tb if the stack captured by the Debugger's
+// last step differs from want, ordered from the top of the stack, returning
+// whether the two are equal. Stack items that can't be represented as uint64
+// are reported as differing.
+func AssertStack(tb TestingT, dbg *Debugger, want []uint64) bool {
+	tb.Helper()
+
+	st := dbg.State()
+	if st.Context == nil {
+		tb.Errorf("%T.State() has no captured stack", dbg)
+		return false
+	}
+
+	n := len(st.Context.StackData())
+	equal := n == len(want)
+	got := make([]string, n)
+	for i := range got {
+		item := st.StackBack(i)
+		got[i] = item.Hex()
+		if equal && (!item.IsUint64() || item.Uint64() != want[i]) {
+			equal = false
+		}
+	}
+	if !equal {
+		tb.Errorf("Stack [top to bottom] = [%s]; want %#x", strings.Join(got, " "), want)
+	}
+	return equal
+}
+
+// AssertMemory reports an error to tb if the memory captured by the Debugger's
+// last step, starting at the offset, doesn't begin with want, returning whether
+// the bytes are equal.
+func AssertMemory(tb TestingT, dbg *Debugger, offset uint64, want []byte) bool {
+	tb.Helper()
+
+	st := dbg.State()
+	if st.Context == nil {
+		tb.Errorf("%T.State() has no captured memory", dbg)
+		return false
+	}
+
+	mem := st.Context.MemoryData()
+	// Compare against the remaining length instead of computing the end of the
+	// range, which may overflow.
+	if n := uint64(len(mem)); offset > n || uint64(len(want)) > n-offset {
+		tb.Errorf("Memory length %d; want at least %d bytes from offset %d", n, len(want), offset)
+		return false
+	}
+	end := offset + uint64(len(want))
+	if got := mem[offset:end]; !bytes.Equal(got, want) {
+		tb.Errorf("Memory [%d,%d) = %#x; want %#x", offset, end, got, want)
+		return false
+	}
+	return true
+}
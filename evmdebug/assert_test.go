@@ -0,0 +1,94 @@
+package evmdebug_test
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/evmdebug"
+
+	. "github.com/arr4n/specops"
+)
+
+// recordingTB records failures instead of reporting them.
+type recordingTB struct {
+	errs []string
+}
+
+func (*recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestAssertStackAndMemory(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, PUSH(0xcafe)),
+		PUSH(1), PUSH(2),
+		Fn(MSTORE8, PUSH(32), PUSH(0xff)),
+		PUSH(new(big.Int).Lsh(big.NewInt(1), 64).Bytes()),
+		STOP,
+	}
+
+	dbg, _, err := code.StartDebugging(nil)
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+
+	if !dbg.RunUntil(func(s *evmdebug.CapturedState) bool { return s.Op == vm.MSTORE8 }) {
+		t.Fatalf("%T.RunUntil(MSTORE8) never reached", dbg)
+	}
+
+	word := make([]byte, 32)
+	word[30], word[31] = 0xca, 0xfe
+
+	stackTests := []struct {
+		want     []uint64
+		wantPass bool
+	}{
+		{[]uint64{2, 1}, true},
+		{[]uint64{1, 2}, false},
+		{[]uint64{2}, false},
+		{[]uint64{2, 1, 0}, false},
+		{nil, false},
+	}
+	for _, tt := range stackTests {
+		tb := new(recordingTB)
+		if got := evmdebug.AssertStack(tb, dbg, tt.want); got != tt.wantPass || (len(tb.errs) == 0) != tt.wantPass {
+			t.Errorf("AssertStack(%d) got %t with errors %q; want %t", tt.want, got, tb.errs, tt.wantPass)
+		}
+	}
+
+	memTests := []struct {
+		offset   uint64
+		want     []byte
+		wantPass bool
+	}{
+		{0, word, true},
+		{30, []byte{0xca, 0xfe}, true},
+		{31, []byte{0xfe, 0xff}, true},
+		{31, []byte{0xfe, 0}, false},
+		{32, make([]byte, 32), false},      // includes the byte from MSTORE8
+		{33, make([]byte, 32), false},      // beyond memory
+		{math.MaxUint64, []byte{0}, false}, // end overflows
+		{math.MaxUint64 - 1, make([]byte, 2), false},
+	}
+	for _, tt := range memTests {
+		tb := new(recordingTB)
+		if got := evmdebug.AssertMemory(tb, dbg, tt.offset, tt.want); got != tt.wantPass || (len(tb.errs) == 0) != tt.wantPass {
+			t.Errorf("AssertMemory(%d, %#x) got %t with errors %q; want %t", tt.offset, tt.want, got, tb.errs, tt.wantPass)
+		}
+	}
+
+	t.Run("non-uint64", func(t *testing.T) {
+		dbg.RunUntil(func(s *evmdebug.CapturedState) bool { return s.Op == vm.PUSH9 })
+		tb := new(recordingTB)
+		if evmdebug.AssertStack(tb, dbg, []uint64{0, 2, 1}) {
+			t.Errorf("AssertStack() with 2^64 on stack got true; want false")
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package evmdebug
+
+// SetConditionalBreakpoint registers a breakpoint at the program counter, at
+// which Continue() pauses only if cond returns true for the State(). A nil cond
+// is always met. As with RunUntil(), the breakpoint is hit once the opcode at
+// pc has been executed, so the captured stack and memory reflect its
+// execution.
+//
+// Breakpoints apply to frames at all depths; cond can inspect
+// CapturedState.Depth to limit them. Setting a breakpoint at a PC that already
+// has one replaces it.
+func (d *Debugger) SetConditionalBreakpoint(pc uint64, cond func(*CapturedState) bool) {
+	if d.breakpoints == nil {
+		d.breakpoints = make(map[uint64]func(*CapturedState) bool)
+	}
+	if cond == nil {
+		cond = func(*CapturedState) bool { return true }
+	}
+	d.breakpoints[pc] = cond
+}
+
+// SetBreakpoint is equivalent to SetConditionalBreakpoint(pc, nil).
+func (d *Debugger) SetBreakpoint(pc uint64) {
+	d.SetConditionalBreakpoint(pc, nil)
+}
+
+// ClearBreakpoint removes any breakpoint at the program counter.
+func (d *Debugger) ClearBreakpoint(pc uint64) {
+	delete(d.breakpoints, pc)
+}
+
+// Continue calls Step() until a breakpoint is hit, or until execution ends,
+// returning whether a breakpoint was hit. Like RunUntil(), calling Continue()
+// when Done() returns true is acceptable.
+func (d *Debugger) Continue() bool {
+	return d.RunUntil(d.atBreakpoint)
+}
+
+func (d *Debugger) atBreakpoint(s *CapturedState) bool {
+	cond, ok := d.breakpoints[s.PC]
+	return ok && cond(s)
+}
@@ -0,0 +1,70 @@
+package evmdebug_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/evmdebug"
+	"github.com/arr4n/specops/stack"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestConditionalBreakpoint(t *testing.T) {
+	code := Code{
+		PUSH0,                               // 0
+		JUMPDEST("loop"), stack.SetDepth(1), // 1
+		PUSH(1), ADD, // 2, 4
+		Fn(JUMPI, PUSH("loop"), Fn(GT, PUSH(5), DUP1)),
+		STOP,
+	}
+	const (
+		jumpdestPC = 1
+		addPC      = 4
+	)
+
+	dbg, _, err := code.StartDebugging(nil)
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+	state := dbg.State()
+
+	counterIs := func(want uint64) func(*evmdebug.CapturedState) bool {
+		return func(s *evmdebug.CapturedState) bool {
+			got := s.StackBack(0)
+			return got.IsUint64() && got.Uint64() == want
+		}
+	}
+	dbg.SetConditionalBreakpoint(addPC, counterIs(3))
+	dbg.SetBreakpoint(jumpdestPC)
+
+	// The unconditional breakpoint is hit on every iteration, until cleared.
+	for i := 0; i < 2; i++ {
+		if !dbg.Continue() {
+			t.Fatalf("%T.Continue() returned false before JUMPDEST", dbg)
+		}
+		if got, want := state.PC, uint64(jumpdestPC); got != want {
+			t.Fatalf("%T.Continue() paused at PC %d; want %d", dbg, got, want)
+		}
+	}
+	dbg.ClearBreakpoint(jumpdestPC)
+
+	if !dbg.Continue() {
+		t.Fatalf("%T.Continue() returned false before conditional breakpoint", dbg)
+	}
+	if got, want := state.PC, uint64(addPC); got != want || state.Op != vm.ADD {
+		t.Errorf("%T.Continue() paused at {PC: %d, Op: %v}; want {PC: %d, Op: %v}", dbg, got, state.Op, want, vm.ADD)
+	}
+	if got := state.StackBack(0); !got.IsUint64() || got.Uint64() != 3 {
+		t.Errorf("At conditional breakpoint; top of stack = %v; want 3", &got)
+	}
+
+	if dbg.Continue() {
+		t.Errorf("%T.Continue() returned true after last breakpoint; stopped at PC %d", dbg, state.PC)
+	}
+	if !dbg.Done() {
+		t.Errorf("%T.Done() = false after Continue() with no more breakpoints", dbg)
+	}
+}
@@ -0,0 +1,37 @@
+package evmdebug
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// SetCallData replaces the call data of the frame in which the last-captured
+// opcode was executed (i.e. that of State()), such that subsequent CALLDATALOAD,
+// CALLDATASIZE, and CALLDATACOPY opcodes in the frame read the new data. It
+// MUST only be called between steps, while the EVM is blocked, and returns an
+// error before the first Step(), after Done() returns true, or if the EVM
+// isn't a vm.EVMInterpreter.
+//
+// Modifying call data mid-execution diverges from faithful execution and is
+// intended only for exploratory debugging and fault injection. Values already
+// read from the original data (e.g. on the stack or in memory) are unchanged,
+// as are gas costs already charged. If the last-captured opcode was a call or
+// the end of a nested frame then the next opcode will be executed in a
+// different frame, which won't observe the change until execution returns to
+// the frame of State().
+func (d *Debugger) SetCallData(data []byte) error {
+	if d.Done() {
+		return errors.New("execution ended")
+	}
+	switch ctx := d.State().Context.(type) {
+	case nil:
+		return errors.New("no captured state; call Step() first")
+	case *vm.ScopeContext:
+		ctx.Contract.Input = data
+		return nil
+	default:
+		return fmt.Errorf("unsupported %T", ctx)
+	}
+}
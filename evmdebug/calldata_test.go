@@ -0,0 +1,50 @@
+package evmdebug_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestSetCallData(t *testing.T) {
+	code := Code{
+		CALLDATASIZE,
+		Fn(MSTORE, PUSH0, Fn(CALLDATALOAD, PUSH0)),
+		Fn(MSTORE, PUSH(32)),
+		Fn(RETURN, PUSH0, PUSH(64)),
+	}
+	orig := []byte{1}
+	want := bytes.Repeat([]byte{0xff}, 32)
+
+	dbg, results, err := code.StartDebugging(orig)
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+
+	if err := dbg.SetCallData(want); err == nil {
+		t.Errorf("%T.SetCallData() before first Step(); got nil error", dbg)
+	}
+
+	dbg.Step() // CALLDATASIZE
+	if err := dbg.SetCallData(want); err != nil {
+		t.Fatalf("%T.SetCallData() error %v", dbg, err)
+	}
+	dbg.FastForward()
+
+	res, err := results()
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() results error %v", code, err)
+	}
+	// The CALLDATASIZE of the original data was already on the stack.
+	wantRet := append(want, make([]byte, 31)...)
+	wantRet = append(wantRet, byte(len(orig)))
+	if !bytes.Equal(res.ReturnData, wantRet) {
+		t.Errorf("After %T.SetCallData(); returned %#x; want %#x", dbg, res.ReturnData, wantRet)
+	}
+
+	if err := dbg.SetCallData(want); err == nil {
+		t.Errorf("%T.SetCallData() after Done(); got nil error", dbg)
+	}
+}
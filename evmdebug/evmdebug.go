@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/holiman/uint256"
+	"github.com/arr4n/specops/internal/labels"
 	"github.com/arr4n/specops/internal/sync"
 )
 
@@ -40,6 +41,7 @@ func NewDebugger() *Debugger {
 			fastForward: fastForward,
 			stepped:     stepped, // sent on to signal end of single step
 			done:        done,    // closed to signal end of running
+			histogram:   make(map[vm.OpCode]int),
 		},
 	}
 }
@@ -57,8 +59,10 @@ type (
 // memory, etc. The value returned by its Tracer() method should be placed
 // inside a vm.Config before execution commences.
 //
-// Currently only a single frame is supported (i.e. no *CALL methods). This
-// requires execution with a vm.EVMInterpreter.
+// Opcodes of nested frames (i.e. those entered via the *CALL and CREATE*
+// methods) are stepped through as with those of the outermost frame, but
+// execution is only considered done when the latter ends. This requires
+// execution with a vm.EVMInterpreter.
 type Debugger struct {
 	d *debugger
 
@@ -68,6 +72,9 @@ type Debugger struct {
 	// Receive internal state changes
 	stepped <-chan stepped
 	done    <-chan done
+
+	breakpoints map[uint64]func(*CapturedState) bool
+	labels      *labels.Index
 }
 
 // Tracer returns an EVMLogger that enables debugging, compatible with geth.
@@ -163,6 +170,29 @@ func (d *Debugger) FastForward() {
 	}
 }
 
+// RunUntil calls Step() until cond returns true for the State(), or until
+// execution ends, returning whether cond was met. Unlike Step(), calling
+// RunUntil() when Done() returns true is acceptable, but cond will never be
+// met.
+func (d *Debugger) RunUntil(cond func(*CapturedState) bool) bool {
+	for !d.Done() {
+		d.Step()
+		if cond(d.State()) {
+			return true
+		}
+	}
+	return false
+}
+
+// runUntilFault calls RunUntil() with a condition that is met by the first
+// captured error, e.g. REVERT, INVALID, or an out-of-gas error, returning
+// whether such a fault occurred.
+func (d *Debugger) runUntilFault() bool {
+	return d.RunUntil(func(s *CapturedState) bool {
+		return s.Err != nil
+	})
+}
+
 // Done returns whether exeuction has ended.
 func (d *Debugger) Done() bool {
 	select {
@@ -185,6 +215,19 @@ func (d *Debugger) State() *CapturedState {
 	return &d.d.last
 }
 
+// OpcodeHistogram returns the number of times that each opcode has been
+// executed so far, including in nested frames. Opcodes that failed before
+// execution (e.g. due to insufficient gas) aren't counted. As with State(), it
+// SHOULD only be called while the EVM is blocked between steps, or after
+// Done() returns true.
+func (d *Debugger) OpcodeHistogram() map[vm.OpCode]int {
+	h := make(map[vm.OpCode]int, len(d.d.histogram))
+	for op, n := range d.d.histogram {
+		h[op] = n
+	}
+	return h
+}
+
 // CapturedState carries all values passed to the debugger.
 //
 // N.B. See ownership note in Debugger.State() documentation.
@@ -192,8 +235,16 @@ type CapturedState struct {
 	PC, GasLeft, GasCost uint64
 	Op                   vm.OpCode
 	Context              tracing.OpContext // contains memory and stack ;)
-	ReturnData           []byte
-	Err                  error
+	// ReturnData reflects the execution of Op, like the stack and memory. It
+	// is the data returned by the last call (or precompile) made by the frame
+	// in which execution continues, as available to its next RETURNDATASIZE or
+	// RETURNDATACOPY. For example, after Step()ping over a call to a
+	// precompile, or over the RETURN of a nested frame, it is the data
+	// returned to the caller. It is retained upon a fault that ends execution.
+	ReturnData []byte
+	// Depth is that of the frame executing Op, starting from 1.
+	Depth int
+	Err   error
 }
 
 // StackBack returns the n'th item in the captured stack; equivalent to
@@ -216,16 +267,22 @@ type debugger struct {
 	// for synchronisation).
 	blockingEVM sync.Toggle
 	// Closed after execution of one of {STOP,RETURN,REVERT}, or upon a fault,
-	// externally signalling completion of the execution.
+	// in the outermost frame, externally signalling completion of the
+	// execution.
 	done chan<- done
 
-	last CapturedState
+	last      CapturedState
+	histogram map[vm.OpCode]int
 }
 
 // NOTE: when directly calling EVMInterpreter.Run(), only on{OpCode,Fault}
 // will ever be invoked.
 
 func (d *debugger) onOpCode(pc uint64, op byte, gasLeft, gasCost uint64, scope tracing.OpContext, retData []byte, depth int, err error) {
+	// The return data are the result of the *previous* opcode, so MUST be
+	// captured before unblocking Debugger.Wait(), which Step() uses to signal
+	// that said opcode has completed.
+	d.last.ReturnData = retData
 	d.blockingEVM.Set(true) // unblocks Debugger.Wait()
 
 	// TODO: with the <-d.step at the beginning we can inspect initial state,
@@ -240,25 +297,43 @@ func (d *debugger) onOpCode(pc uint64, op byte, gasLeft, gasCost uint64, scope t
 	d.last.GasLeft = gasLeft
 	d.last.GasCost = gasCost
 	d.last.Context = scope
-	d.last.ReturnData = retData
+	d.last.Depth = depth
 	d.last.Err = err
+	if err == nil {
+		d.histogram[vm.OpCode(op)]++
+	}
 
 	// In all cases below, closing / sending on d.stepped MUST be the last
 	// action. Debugger.Step() relies on this to perform checks once its receive
 	// on d.stepped is unblocked.
-	switch vm.OpCode(op) {
-	case vm.STOP, vm.RETURN: // REVERT will end up in onFault().
-		close(d.done)
-		close(d.stepped)
+	switch op := vm.OpCode(op); {
+	case depth > 1:
+		d.unblockStep()
+	case op == vm.STOP, op == vm.RETURN: // REVERT will end up in onFault().
+		d.closeDone()
+	case err != nil:
+		// Errors raised before execution of the opcode (e.g. out of gas) are
+		// reported here instead of to onFault().
+		d.closeDone()
 	default:
-		d.blockingEVM.Set(false) // blocks Debugger.Wait()
-		d.stepped <- stepped{}
+		d.unblockStep()
 	}
 }
 
+// unblockStep signals the end of a Step() that didn't end execution.
+func (d *debugger) unblockStep() {
+	d.blockingEVM.Set(false) // blocks Debugger.Wait()
+	d.stepped <- stepped{}
+}
+
+// closeDone signals the end of a Step() that ended execution.
+func (d *debugger) closeDone() {
+	close(d.done)
+	close(d.stepped)
+}
+
 func (d *debugger) onFault(pc uint64, op byte, gasLeft, gasCost uint64, scope tracing.OpContext, depth int, err error) {
 	d.blockingEVM.Set(true)
-	defer func() { d.blockingEVM.Set(false) }()
 
 	select {
 	case <-d.step:
@@ -270,10 +345,18 @@ func (d *debugger) onFault(pc uint64, op byte, gasLeft, gasCost uint64, scope tr
 	d.last.GasLeft = gasLeft
 	d.last.GasCost = gasCost
 	d.last.Context = scope
-	d.last.ReturnData = nil
+	// ReturnData are unchanged as they are only modified by calls, the
+	// completion of which is captured by onOpCode().
+	d.last.Depth = depth
 	d.last.Err = err
 
-	// See CaptureState for why closing d.stepped MUST be performed last.
-	close(d.done)
-	close(d.stepped)
+	// See onOpCode() for why closing / sending on d.stepped MUST be performed
+	// last.
+	if depth > 1 {
+		// Execution continues in the calling frame.
+		d.unblockStep()
+		return
+	}
+	defer d.blockingEVM.Set(false)
+	d.closeDone()
 }
@@ -0,0 +1,23 @@
+package evmdebug
+
+// RunUntilFault exposes runUntilFault(), as used by the terminal UI, to
+// external tests.
+func (d *Debugger) RunUntilFault() bool {
+	return d.runUntilFault()
+}
+
+// CodeHighlighter exposes the terminal UI's highlighting of the code to
+// external tests. Each call to the returned function highlights the code as
+// after a Step(), returning the index of the highlighted item and the title.
+func (d *Debugger) CodeHighlighter(bytecode []byte) func() (int, string) {
+	t := &termDBG{
+		Debugger: d,
+		dbgCtx:   &Context{Bytecode: bytecode},
+	}
+	t.initComponents()
+	t.populateCode()
+	return func() (int, string) {
+		t.highlightPC()
+		return t.code.GetCurrentItem(), t.code.GetTitle()
+	}
+}
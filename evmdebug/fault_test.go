@@ -0,0 +1,72 @@
+package evmdebug_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestRunUntilFault(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      Code
+		wantFault bool
+		wantOp    vm.OpCode // only checked if wantFault
+	}{
+		{
+			name:      "INVALID",
+			code:      Code{PUSH0, INVALID, STOP},
+			wantFault: true,
+			wantOp:    vm.INVALID,
+		},
+		{
+			name:      "REVERT",
+			code:      Code{Fn(REVERT, PUSH0, PUSH0), STOP},
+			wantFault: true,
+			wantOp:    vm.REVERT,
+		},
+		{
+			name:      "stack underflow",
+			code:      Code{PUSH0, Raw{byte(vm.ADD)}, STOP},
+			wantFault: true,
+			wantOp:    vm.ADD,
+		},
+		{
+			name: "no fault",
+			code: Code{Fn(MSTORE, PUSH0, PUSH(1)), Fn(RETURN, PUSH0, PUSH(32))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbg, results, err := tt.code.StartDebugging(nil)
+			if err != nil {
+				t.Fatalf("%T.StartDebugging() error %v", tt.code, err)
+			}
+			defer dbg.FastForward()
+
+			if got := dbg.RunUntilFault(); got != tt.wantFault {
+				t.Fatalf("%T.RunUntilFault() got %t; want %t", dbg, got, tt.wantFault)
+			}
+			state := dbg.State()
+			if !tt.wantFault {
+				if !dbg.Done() {
+					t.Errorf("%T.Done() = false after %T.RunUntilFault() without fault", dbg, dbg)
+				}
+				if state.Err != nil {
+					t.Errorf("%T.Err = %v; want nil", state, state.Err)
+				}
+				if _, err := results(); err != nil {
+					t.Errorf("Results error = %v", err)
+				}
+				return
+			}
+
+			if state.Op != tt.wantOp || state.Err == nil {
+				t.Errorf("After %T.RunUntilFault(); got {Op: %v, Err: %v}; want {Op: %v, Err: non-nil}", dbg, state.Op, state.Err, tt.wantOp)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package evmdebug_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/evmdebug"
+	"github.com/arr4n/specops/stack"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestOpcodeHistogram(t *testing.T) {
+	code := Code{
+		PUSH(3),
+		JUMPDEST("loop"), stack.SetDepth(1),
+		PUSH(1), SWAP1, SUB,
+		Fn(JUMPI, PUSH("loop"), DUP1),
+		STOP,
+	}
+
+	dbg, _, err := code.StartDebugging(nil)
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+
+	dbg.RunUntil(func(s *evmdebug.CapturedState) bool { return s.Op == vm.JUMPI })
+	if diff := cmp.Diff(map[vm.OpCode]int{
+		vm.PUSH1:    3,
+		vm.JUMPDEST: 1,
+		vm.SWAP1:    1,
+		vm.SUB:      1,
+		vm.DUP1:     1,
+		vm.JUMPI:    1,
+	}, dbg.OpcodeHistogram()); diff != "" {
+		t.Errorf("%T.OpcodeHistogram() after first JUMPI; diff (-want +got):\n%s", dbg, diff)
+	}
+
+	dbg.FastForward()
+	want := map[vm.OpCode]int{
+		vm.PUSH1:    7,
+		vm.JUMPDEST: 3,
+		vm.SWAP1:    3,
+		vm.SUB:      3,
+		vm.DUP1:     3,
+		vm.JUMPI:    3,
+		vm.STOP:     1,
+	}
+	if diff := cmp.Diff(want, dbg.OpcodeHistogram()); diff != "" {
+		t.Errorf("%T.OpcodeHistogram() after FastForward(); diff (-want +got):\n%s", dbg, diff)
+	}
+}
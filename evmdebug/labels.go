@@ -0,0 +1,26 @@
+package evmdebug
+
+import (
+	"github.com/arr4n/specops/internal/labels"
+)
+
+// SetLabels registers the offsets of the contract's JUMPDESTs and Labels,
+// keyed by name, for use by CurrentLabel(). It is called automatically by
+// runopts.WithDebugger() with the labels of the compiled Code, and MUST NOT be
+// called concurrently with CurrentLabel().
+func (d *Debugger) SetLabels(ls map[string]int) {
+	d.labels = labels.New(ls)
+}
+
+// CurrentLabel returns the name of the JUMPDEST or Label at or most closely
+// preceding the PC of the last executed opcode, i.e. the basic block or section
+// currently being executed, and true. It returns false if no labels have been
+// set, if no opcode has yet been executed, if the PC precedes all labels, or
+// if execution is in a nested frame, to which the labels don't apply.
+func (d *Debugger) CurrentLabel() (string, bool) {
+	s := d.State()
+	if d.labels == nil || s.Context == nil || s.Depth != 1 {
+		return "", false
+	}
+	return d.labels.Nearest(s.PC)
+}
@@ -0,0 +1,115 @@
+package evmdebug_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/runopts"
+	"github.com/arr4n/specops/stack"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestCurrentLabel(t *testing.T) {
+	calleeAddr := common.HexToAddress("0xca11ee")
+
+	code := Code{
+		PUSH0, POP,
+		JUMPDEST("first"), stack.SetDepth(0),
+		Fn(STATICCALL, PUSH(0xffff), PUSH(calleeAddr), PUSH0, PUSH0, PUSH0, PUSH0),
+		Label("second"),
+		POP,
+	}
+	callee := []byte{byte(PUSH0), byte(STOP)}
+
+	dbg, _, err := code.StartDebugging(nil, runopts.GenesisAlloc(types.GenesisAlloc{
+		calleeAddr: {Code: callee},
+	}))
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+
+	type label struct {
+		name string
+		ok   bool
+	}
+	current := func() label {
+		n, ok := dbg.CurrentLabel()
+		return label{n, ok}
+	}
+
+	if got := current(); got.ok {
+		t.Errorf("%T.CurrentLabel() before first step = %q; want false", dbg, got.name)
+	}
+
+	first := label{"first", true}
+	want := []label{
+		{},                                       // PUSH0
+		{},                                       // POP
+		first,                                    // JUMPDEST
+		first, first, first, first, first, first, // STATICCALL arguments
+		first,            // STATICCALL
+		{},               // PUSH0 in the callee
+		{},               // STOP in the callee
+		{"second", true}, // POP
+	}
+	for i, w := range want {
+		dbg.Step()
+		if got := current(); got != w {
+			t.Errorf("%T.CurrentLabel() after step %d (%v at PC %d, depth %d) got (%q, %t); want (%q, %t)", dbg, i, dbg.State().Op, dbg.State().PC, dbg.State().Depth, got.name, got.ok, w.name, w.ok)
+		}
+	}
+}
+
+func TestHighlightNestedFrame(t *testing.T) {
+	calleeAddr := common.HexToAddress("0xca11ee")
+
+	code := Code{
+		Label("caller"),
+		Fn(STATICCALL, PUSH(0xffff), PUSH(calleeAddr), PUSH0, PUSH0, PUSH0, PUSH0),
+		POP,
+		STOP,
+	}
+	compiled, err := code.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", code, err)
+	}
+	callee := []byte{byte(PUSH0), byte(STOP)}
+
+	dbg, _, err := code.StartDebugging(nil, runopts.GenesisAlloc(types.GenesisAlloc{
+		calleeAddr: {Code: callee},
+	}))
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+	highlight := dbg.CodeHighlighter(compiled)
+
+	for dbg.State().Op != vm.STATICCALL {
+		dbg.Step()
+	}
+	callItem, title := highlight()
+	if want := "Code @ caller"; title != want {
+		t.Errorf("Code title after STATICCALL = %q; want %q", title, want)
+	}
+
+	const want = "Code (nested call @ depth 2)"
+	for _, op := range []vm.OpCode{vm.PUSH0, vm.STOP} {
+		dbg.Step()
+		if got := dbg.State().Op; got != op {
+			t.Fatalf("%T.State().Op = %v; want %v in callee", dbg, got, op)
+		}
+		if item, title := highlight(); item != callItem || title != want {
+			t.Errorf("After %v in callee; highlighted (%d, %q); want (%d, %q)", op, item, title, callItem, want)
+		}
+	}
+
+	dbg.Step() // POP
+	if item, title := highlight(); item != callItem+1 || title != "Code @ caller" {
+		t.Errorf("After return to caller; highlighted (%d, %q); want (%d, %q)", item, title, callItem+1, "Code @ caller")
+	}
+}
@@ -0,0 +1,102 @@
+package evmdebug_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/evmdebug"
+	"github.com/arr4n/specops/runopts"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestReturnData(t *testing.T) {
+	callee := Code{
+		Fn(MSTORE, PUSH0, PUSH(0xdecafbad)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+	calleeCode, err := callee.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", callee, err)
+	}
+	calleeAddr := common.HexToAddress("0xca11ee")
+
+	code := Code{
+		Fn(DELEGATECALL, PUSH(0xffff), PUSH(calleeAddr), PUSH0, PUSH0, PUSH0, PUSH0),
+		POP,
+		Fn(RETURNDATACOPY, PUSH0, PUSH0, RETURNDATASIZE),
+		INVALID,
+	}
+	want := common.LeftPadBytes([]byte{0xde, 0xca, 0xfb, 0xad}, 32)
+
+	dbg, results, err := code.StartDebugging(nil, runopts.GenesisAlloc(types.GenesisAlloc{
+		calleeAddr: {Code: calleeCode},
+	}))
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+
+	state := dbg.State()
+	tests := []struct {
+		op             vm.OpCode
+		wantDepth      int
+		wantReturnData []byte
+	}{
+		// Execution continues in the callee, which is yet to make a call.
+		{vm.DELEGATECALL, 1, nil},
+		{vm.MSTORE, 2, nil},
+		// Execution continues in the caller, to which data were returned.
+		{vm.RETURN, 2, want},
+		{vm.POP, 1, want},
+		{vm.RETURNDATACOPY, 1, want},
+	}
+
+	for _, tt := range tests {
+		if !dbg.RunUntil(func(s *evmdebug.CapturedState) bool { return s.Op == tt.op }) {
+			t.Fatalf("%T.RunUntil(%v) never reached", dbg, tt.op)
+		}
+		if got, want := state.Depth, tt.wantDepth; got != want {
+			t.Errorf("After %v; %T.Depth = %d; want %d", tt.op, state, got, want)
+		}
+		if diff := cmp.Diff(tt.wantReturnData, state.ReturnData); diff != "" {
+			t.Errorf("After %v; %T.ReturnData diff (-want +got):\n%s", tt.op, state, diff)
+		}
+	}
+
+	// The fault is captured as a separate step after that of the INVALID
+	// opcode itself.
+	dbg.FastForward()
+	if state.Op != vm.INVALID || state.Err == nil {
+		t.Errorf("After %T.FastForward(); got {Op: %v, Err: %v}; want {Op: %v, Err: non-nil}", dbg, state.Op, state.Err, vm.INVALID)
+	}
+	if diff := cmp.Diff(want, state.ReturnData); diff != "" {
+		t.Errorf("After fault; %T.ReturnData diff (-want +got):\n%s", state, diff)
+	}
+	if _, err := results(); err == nil {
+		t.Error("Results error = nil after INVALID")
+	}
+}
+
+func TestDoneOnPreExecutionError(t *testing.T) {
+	// Errors raised before an opcode is executed (e.g. out of gas) are only
+	// reported to the tracer's OnOpcode hook, without a subsequent OnFault.
+	code := Code{
+		Fn(STATICCALL, GAS, PUSH(4), PUSH0, PUSH0, PUSH0, PUSH0),
+	}
+
+	dbg, _, err := code.StartDebugging(nil)
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	defer dbg.FastForward()
+
+	dbg.RunUntil(func(*evmdebug.CapturedState) bool { return false })
+	if got := dbg.State(); got.Op != vm.STATICCALL || got.Err == nil {
+		t.Errorf("%T.State() after running to completion got {Op: %v, Err: %v}; want {Op: %v, Err: non-nil}", dbg, got.Op, got.Err, vm.STATICCALL)
+	}
+}
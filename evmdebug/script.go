@@ -0,0 +1,73 @@
+package evmdebug
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A DebugCommand is a single instruction in a script passed to
+// Debugger.Execute(), allowing debugging scenarios to be reproduced without the
+// terminal UI; e.g. in tests that pin specific mid-execution states.
+type DebugCommand interface {
+	execute(*Debugger) error
+}
+
+type commandFunc func(*Debugger) error
+
+func (f commandFunc) execute(d *Debugger) error { return f(d) }
+
+// Execute runs each of the commands in order, stopping at and returning the
+// first error.
+func (d *Debugger) Execute(script []DebugCommand) error {
+	for i, c := range script {
+		if err := c.execute(d); err != nil {
+			return fmt.Errorf("%T[%d]: %w", script, i, err)
+		}
+	}
+	return nil
+}
+
+// ErrExecutionEnded is returned by Debugger.Execute() if a DebugCommand
+// requires further execution after Debugger.Done() returns true.
+var ErrExecutionEnded = errors.New("execution ended")
+
+// Step returns a DebugCommand that calls Debugger.Step() n times. It results
+// in ErrExecutionEnded if execution ends before all n steps are performed.
+func Step(n int) DebugCommand {
+	return commandFunc(func(d *Debugger) error {
+		for i := 0; i < n; i++ {
+			if d.Done() {
+				return fmt.Errorf("%w after %d of %d steps", ErrExecutionEnded, i, n)
+			}
+			d.Step()
+		}
+		return nil
+	})
+}
+
+// FastForward returns a DebugCommand that calls Debugger.FastForward().
+func FastForward() DebugCommand {
+	return commandFunc(func(d *Debugger) error {
+		d.FastForward()
+		return nil
+	})
+}
+
+// RunUntil returns a DebugCommand that calls Debugger.RunUntil(cond). It
+// results in ErrExecutionEnded if cond is never met.
+func RunUntil(cond func(*CapturedState) bool) DebugCommand {
+	return commandFunc(func(d *Debugger) error {
+		if !d.RunUntil(cond) {
+			return fmt.Errorf("%w before condition met", ErrExecutionEnded)
+		}
+		return nil
+	})
+}
+
+// Assert returns a DebugCommand that propagates any error returned by
+// fn(Debugger.State()).
+func Assert(fn func(*CapturedState) error) DebugCommand {
+	return commandFunc(func(d *Debugger) error {
+		return fn(d.State())
+	})
+}
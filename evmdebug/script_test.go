@@ -0,0 +1,99 @@
+package evmdebug_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/evmdebug"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestExecute(t *testing.T) {
+	code := Code{
+		PUSH(1), PUSH(2), ADD,
+		Fn(MSTORE, PUSH0),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+
+	stackTop := func(want uint64) evmdebug.DebugCommand {
+		return evmdebug.Assert(func(s *evmdebug.CapturedState) error {
+			if got := s.StackBack(0); !got.IsUint64() || got.Uint64() != want {
+				return fmt.Errorf("top of stack = %v; want %d", &got, want)
+			}
+			return nil
+		})
+	}
+	isOp := func(op vm.OpCode) func(*evmdebug.CapturedState) bool {
+		return func(s *evmdebug.CapturedState) bool {
+			return s.Op == op
+		}
+	}
+
+	tests := []struct {
+		name    string
+		script  []evmdebug.DebugCommand
+		wantErr bool
+		errIs   error // only checked if non-nil
+	}{
+		{
+			name: "happy path",
+			script: []evmdebug.DebugCommand{
+				// Although the captured state carries the opcode, the stack
+				// reflects its execution.
+				evmdebug.Step(2), // PUSH1 PUSH1
+				stackTop(2),
+				evmdebug.Step(1), // ADD
+				stackTop(3),
+				evmdebug.RunUntil(isOp(vm.PUSH0)),
+				stackTop(0),
+				evmdebug.FastForward(),
+			},
+		},
+		{
+			name: "failed assertion",
+			script: []evmdebug.DebugCommand{
+				evmdebug.Step(3),
+				stackTop(42),
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many steps",
+			script: []evmdebug.DebugCommand{
+				evmdebug.Step(100),
+			},
+			wantErr: true,
+			errIs:   evmdebug.ErrExecutionEnded,
+		},
+		{
+			name: "condition never met",
+			script: []evmdebug.DebugCommand{
+				evmdebug.RunUntil(isOp(vm.SSTORE)),
+			},
+			wantErr: true,
+			errIs:   evmdebug.ErrExecutionEnded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbg, _, err := code.StartDebugging(nil)
+			if err != nil {
+				t.Fatalf("%T.StartDebugging(nil) error %v", code, err)
+			}
+			defer dbg.FastForward()
+
+			err = dbg.Execute(tt.script)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("%T.Execute() got err %v; want err %t", dbg, err, tt.wantErr)
+			}
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Errorf("%T.Execute() got err %v; want %v", dbg, err, tt.errIs)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package evmdebug
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -13,12 +14,21 @@ import (
 type Context struct {
 	Bytecode, CallData []byte
 	Results            func() (*core.ExecutionResult, error)
+	// StartAtFault, if true, fast-forwards execution to the first fault (e.g.
+	// REVERT or INVALID) before the UI is displayed. If there is no fault,
+	// execution is fast-forwarded to the end.
+	StartAtFault bool
 }
 
 // RunTerminalUI starts a UI that controls the Debugger and displays opcodes,
-// memory, stack etc. Because of the current Debugger limitation of a single
-// call frame, only that exact Contract can be displayed. The callData is
-// assumed to be the same as passed to the execution environment.
+// memory, stack etc. Only the code of the Contract itself is displayed; opcodes
+// of nested call frames are stepped through, with their stack and memory, but
+// the code remains highlighted at the calling opcode until the frame returns.
+// The callData is assumed to be the same as passed to the execution
+// environment. Pressing 'c'
+// toggles the call data between raw hex and 32-byte words following the
+// function selector, and pressing 'f' runs until the first fault; see also
+// Context.StartAtFault.
 //
 // As the Debugger only has access via a vm.EVMLogger, it can't retrieve the
 // final result. The `results` argument MUST return the returned buffer / error
@@ -32,6 +42,9 @@ func (d *Debugger) RunTerminalUI(dbgCtx *Context) error {
 	t.initApp()
 	t.populateCallData()
 	t.populateCode()
+	if dbgCtx.StartAtFault {
+		t.runUntilFault()
+	}
 	return t.app.Run()
 }
 
@@ -41,6 +54,7 @@ type termDBG struct {
 
 	stack, memory    *tview.List
 	callData, result *tview.TextView
+	callDataAsWords  bool // toggled by the 'c' key
 
 	code         *tview.List
 	pcToCodeItem map[uint64]int
@@ -110,7 +124,29 @@ func (t *termDBG) createLayout() tview.Primitive {
 }
 
 func (t *termDBG) populateCallData() {
-	t.callData.SetText(fmt.Sprintf("%x", t.dbgCtx.CallData))
+	if !t.callDataAsWords {
+		t.callData.SetTitle("calldata")
+		t.callData.SetText(fmt.Sprintf("%x", t.dbgCtx.CallData))
+		return
+	}
+	t.callData.SetTitle("calldata (words)")
+	t.callData.SetText(callDataWords(t.dbgCtx.CallData))
+}
+
+// callDataWords returns the call data formatted for ABI decoding: the 4-byte
+// selector on its own line, followed by one line per 32-byte word, each
+// labelled with its offset in the same manner as the memory panel.
+func callDataWords(cd []byte) string {
+	if len(cd) < 4 {
+		return fmt.Sprintf("%x", cd)
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "selector %x", cd[:4])
+	for i := 4; i < len(cd); i += 32 {
+		fmt.Fprintf(&s, "\n%02x %x", i, cd[i:min(i+32, len(cd))])
+	}
+	return s.String()
 }
 
 func (t *termDBG) populateCode() {
@@ -143,8 +179,22 @@ func (t *termDBG) populateCode() {
 	t.code.AddItem("--- END ---", "", 0, nil)
 }
 
+// highlightPC highlights the opcode following the last one executed, and
+// titles the code with the current label, if any. The displayed code is only
+// that of the Contract so, while executing in a nested call frame, the
+// highlighting remains at the calling opcode and the title shows the depth.
 func (t *termDBG) highlightPC() {
+	if d := t.State().Depth; d > 1 {
+		t.code.SetTitle(fmt.Sprintf("Code (nested call @ depth %d)", d))
+		return
+	}
 	t.code.SetCurrentItem(t.pcToCodeItem[t.State().PC] + 1)
+
+	title := "Code"
+	if l, ok := t.CurrentLabel(); ok {
+		title += " @ " + l
+	}
+	t.code.SetTitle(title)
 }
 
 // onStep is triggered by t.code's ChangedFunc.
@@ -192,12 +242,16 @@ func (t *termDBG) inputCapture(ev *tcell.EventKey) *tcell.EventKey {
 		if t.Done() {
 			t.app.Stop()
 		}
+
+	case 'c':
+		t.callDataAsWords = !t.callDataAsWords
+		t.populateCallData()
+
+	case 'f':
+		t.runUntilFault()
 	} // switch ev.Rune()
 
-	if t.State().Context != nil {
-		t.populateStack()
-		t.populateMemory()
-	}
+	t.populateState()
 
 	if propagate {
 		return ev
@@ -205,6 +259,21 @@ func (t *termDBG) inputCapture(ev *tcell.EventKey) *tcell.EventKey {
 	return nil
 }
 
+// runUntilFault steps until State().Err is non-nil, or until execution ends,
+// then updates the UI accordingly.
+func (t *termDBG) runUntilFault() {
+	t.Debugger.runUntilFault()
+	t.highlightPC()
+	t.populateState()
+}
+
+func (t *termDBG) populateState() {
+	if t.State().Context != nil {
+		t.populateStack()
+		t.populateMemory()
+	}
+}
+
 func (t *termDBG) populateStack() {
 	stack := t.State().Context.StackData()
 
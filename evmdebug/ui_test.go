@@ -0,0 +1,66 @@
+package evmdebug
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCallDataWords(t *testing.T) {
+	selector := []byte{0xde, 0xad, 0xbe, 0xef}
+	word := func(b byte) []byte {
+		return bytes.Repeat([]byte{b}, 32)
+	}
+	concat := func(bs ...[]byte) []byte {
+		return bytes.Join(bs, nil)
+	}
+
+	tests := []struct {
+		name     string
+		callData []byte
+		want     []string // lines
+	}{
+		{
+			name:     "empty",
+			callData: nil,
+			want:     []string{""},
+		},
+		{
+			name:     "shorter than selector",
+			callData: []byte{1, 2, 3},
+			want:     []string{"010203"},
+		},
+		{
+			name:     "selector only",
+			callData: selector,
+			want:     []string{"selector deadbeef"},
+		},
+		{
+			name:     "selector and words",
+			callData: concat(selector, word(1), word(2)),
+			want: []string{
+				"selector deadbeef",
+				"04 " + strings.Repeat("01", 32),
+				"24 " + strings.Repeat("02", 32),
+			},
+		},
+		{
+			name:     "not a multiple of 32 after selector",
+			callData: concat(selector, word(1), []byte{0xaa, 0xbb}),
+			want: []string{
+				"selector deadbeef",
+				"04 " + strings.Repeat("01", 32),
+				"24 aabb",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := strings.Join(tt.want, "\n")
+			if got := callDataWords(tt.callData); got != want {
+				t.Errorf("callDataWords(%#x) got:\n%s\nwant:\n%s", tt.callData, got, want)
+			}
+		})
+	}
+}
@@ -119,18 +119,19 @@ func ExampleCode_verbose0ageMetamorphic() {
 		// much easier to reason about. This is especially so when
 		// refactoring as the specific DUP<N> would otherwise have to
 		// change.
-		Fn(
-			// Although Fn() wasn't intended to be used without a
-			// function-like opcode at the beginning, it sheds light on
-			// what 0age was doing here: setting up all the arguments
-			// for a later STATICCALL. While nested Fn()s act like
-			// regular functions (see ISZERO later), sequential ones
-			// have the effect of "piping" arguments to the next, which
-			// may or may not use them. As the MSTORE Fn() has
-			// sufficient arguments, the ones set up here are left for
-			// the STATICCALL.
+		Pipe(
+			// Pipe() is identical to Fn() but without a function-like
+			// opcode at the beginning, which sheds light on what 0age
+			// was doing here: setting up all the arguments for a later
+			// STATICCALL. While nested Fn()s act like regular functions
+			// (see ISZERO later), sequential ones have the effect of
+			// "piping" arguments to the next, which may or may not use
+			// them. As the MSTORE Fn() has sufficient arguments, the
+			// ones set up here are left for the STATICCALL. Using
+			// Pipe() instead of Fn() signals to Code.Analyze() that this
+			// is deliberate.
 			//
-			// Note that everything in Fn() is reversed so PCs count
+			// Note that everything in Pipe() is reversed so PCs count
 			// from the right, but the rest is easier to read as it is
 			// Yul-like. I'm guessing that this argument setup without
 			// the call was a trick to cheaply get the PC=4 in the right
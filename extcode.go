@@ -0,0 +1,30 @@
+package specops
+
+import (
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/arr4n/specops/types"
+)
+
+// VerifyExtcodeMatches returns Code that REVERTs, with empty data, unless the
+// code deployed at addr is exactly equal to `code`. This is a building block
+// for on-chain verification of clones and metamorphic contracts.
+//
+// The deployed code is copied to memory with EXTCODECOPY, starting at offset
+// zero, and its KECCAK256 hash is compared against that of `code`; its length
+// is also checked with EXTCODESIZE. Any memory in [0, len(code)) is therefore
+// overwritten. The stack is unchanged by the returned Code.
+//
+// As with CALLChecked(), the addr Bytecoder is used more than once so MUST push
+// exactly one value without side effects.
+func VerifyExtcodeMatches(addr types.Bytecoder, code []byte) Code {
+	size := PUSH(len(code))
+	return Code{
+		Fn(EXTCODECOPY, addr, PUSH0, PUSH0, size),
+		Fn(AND,
+			Fn(EQ, size, Fn(EXTCODESIZE, addr)),
+			Fn(EQ, PUSH(crypto.Keccak256Hash(code)), Fn(KECCAK256, PUSH0, size)),
+		),
+		revertUnless(),
+	}
+}
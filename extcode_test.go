@@ -0,0 +1,81 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/arr4n/specops/revert"
+	"github.com/arr4n/specops/runopts"
+)
+
+func TestVerifyExtcodeMatches(t *testing.T) {
+	clone := common.Address{'c', 'l', 'o', 'n', 'e'}
+	deployed := []byte{1, 2, 3, 4, 5}
+
+	alloc := runopts.GenesisAlloc(types.GenesisAlloc{
+		clone: {Code: deployed},
+	})
+
+	tests := []struct {
+		name       string
+		addr       common.Address
+		code       []byte
+		wantRevert bool
+	}{
+		{
+			name: "match",
+			addr: clone,
+			code: deployed,
+		},
+		{
+			name:       "different code",
+			addr:       clone,
+			code:       []byte{1, 2, 3, 4, 6},
+			wantRevert: true,
+		},
+		{
+			name:       "prefix of deployed code",
+			addr:       clone,
+			code:       deployed[:4],
+			wantRevert: true,
+		},
+		{
+			name:       "deployed code is prefix",
+			addr:       clone,
+			code:       []byte{1, 2, 3, 4, 5, 0},
+			wantRevert: true,
+		},
+		{
+			name: "no code",
+			addr: common.Address{'n', 'o', 'n', 'e'},
+			code: []byte{},
+		},
+		{
+			name:       "no code but expected",
+			addr:       common.Address{'n', 'o', 'n', 'e'},
+			code:       deployed,
+			wantRevert: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := Code{
+				VerifyExtcodeMatches(PUSH(tt.addr), tt.code),
+				STOP,
+			}
+			_, err := code.Run(nil, alloc)
+			if tt.wantRevert {
+				if data, ok := revert.Data(err); !ok || len(data) != 0 {
+					t.Errorf("%T.Run() got err %v; want %T with empty data", code, err, &revert.Error{})
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("%T.Run() error %v", code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package specops
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/types"
+)
+
+// foldConstants returns the Code with every Fn() of a pure opcode, all of the
+// arguments of which are constants, replaced by a PUSH of the result. Nested
+// Fn()s are folded first so entire constant expressions are reduced to a
+// single PUSH. The receiver is not modified.
+func (c Code) foldConstants() Code {
+	out := make(Code, len(c))
+	for i, bc := range c {
+		out[i] = foldConstants(bc)
+	}
+	return out
+}
+
+// foldConstants is the single-Bytecoder equivalent of Code.foldConstants().
+func foldConstants(bc types.Bytecoder) types.Bytecoder {
+	switch bc := bc.(type) {
+	case Code:
+		return bc.foldConstants()
+
+	case pipeCall:
+		// The pipe itself is never folded as bc[0] doesn't consume the
+		// remaining arguments, but they may be folded themselves.
+		return pipeCall{fnCall(Code(bc.fnCall).foldConstants())}
+
+	case fnCall:
+		f := fnCall(Code(bc).foldConstants())
+		if v, ok := evalConstFn(f); ok {
+			if v.IsZero() {
+				return PUSH0
+			}
+			return PUSH(*v)
+		}
+		return f
+
+	default:
+		return bc
+	}
+}
+
+// evalConstFn returns the result of f, and true, i.f.f. f[0] is a pure opcode
+// and the remainder of f are exactly its constant arguments.
+func evalConstFn(f fnCall) (*uint256.Int, bool) {
+	if len(f) == 0 {
+		return nil, false
+	}
+	op, ok := f[0].(types.OpCode)
+	if !ok {
+		return nil, false
+	}
+	eval, ok := pureOps[vm.OpCode(op)]
+	if !ok || len(f)-1 != int(stackDeltas[vm.OpCode(op)].pop) {
+		return nil, false
+	}
+
+	args := make([]*uint256.Int, len(f)-1)
+	for i, bc := range f[1:] {
+		v, ok := constValue(bc)
+		if !ok {
+			return nil, false
+		}
+		args[i] = v
+	}
+	return eval(args), true
+}
+
+// constValue returns the value that bc pushes, and true, i.f.f. it is a PUSH of
+// a constant.
+func constValue(bc types.Bytecoder) (*uint256.Int, bool) {
+	switch bc := bc.(type) {
+	case types.OpCode:
+		if vm.OpCode(bc) == vm.PUSH0 {
+			return new(uint256.Int), true
+		}
+	case types.StackPusher:
+		if buf := bc.ToPush(); len(buf) > 0 && len(buf) <= 32 {
+			return new(uint256.Int).SetBytes(buf), true
+		}
+	}
+	return nil, false
+}
+
+// pureOps are the opcodes that can be evaluated at compile time, given
+// constant arguments. Arguments are in Fn() order; i.e. args[0] is the top of
+// the stack. Implementations mirror those of geth's core/vm so MAY modify their
+// arguments.
+var pureOps = map[vm.OpCode]func(args []*uint256.Int) *uint256.Int{
+	vm.ADD:    func(a []*uint256.Int) *uint256.Int { return a[0].Add(a[0], a[1]) },
+	vm.MUL:    func(a []*uint256.Int) *uint256.Int { return a[0].Mul(a[0], a[1]) },
+	vm.SUB:    func(a []*uint256.Int) *uint256.Int { return a[0].Sub(a[0], a[1]) },
+	vm.DIV:    func(a []*uint256.Int) *uint256.Int { return a[0].Div(a[0], a[1]) },
+	vm.SDIV:   func(a []*uint256.Int) *uint256.Int { return a[0].SDiv(a[0], a[1]) },
+	vm.MOD:    func(a []*uint256.Int) *uint256.Int { return a[0].Mod(a[0], a[1]) },
+	vm.SMOD:   func(a []*uint256.Int) *uint256.Int { return a[0].SMod(a[0], a[1]) },
+	vm.ADDMOD: func(a []*uint256.Int) *uint256.Int { return a[0].AddMod(a[0], a[1], a[2]) },
+	vm.MULMOD: func(a []*uint256.Int) *uint256.Int { return a[0].MulMod(a[0], a[1], a[2]) },
+	vm.EXP:    func(a []*uint256.Int) *uint256.Int { return a[0].Exp(a[0], a[1]) },
+	vm.SIGNEXTEND: func(a []*uint256.Int) *uint256.Int {
+		return a[1].ExtendSign(a[1], a[0])
+	},
+	vm.LT:     func(a []*uint256.Int) *uint256.Int { return boolWord(a[0].Lt(a[1])) },
+	vm.GT:     func(a []*uint256.Int) *uint256.Int { return boolWord(a[0].Gt(a[1])) },
+	vm.SLT:    func(a []*uint256.Int) *uint256.Int { return boolWord(a[0].Slt(a[1])) },
+	vm.SGT:    func(a []*uint256.Int) *uint256.Int { return boolWord(a[0].Sgt(a[1])) },
+	vm.EQ:     func(a []*uint256.Int) *uint256.Int { return boolWord(a[0].Eq(a[1])) },
+	vm.ISZERO: func(a []*uint256.Int) *uint256.Int { return boolWord(a[0].IsZero()) },
+	vm.AND:    func(a []*uint256.Int) *uint256.Int { return a[0].And(a[0], a[1]) },
+	vm.OR:     func(a []*uint256.Int) *uint256.Int { return a[0].Or(a[0], a[1]) },
+	vm.XOR:    func(a []*uint256.Int) *uint256.Int { return a[0].Xor(a[0], a[1]) },
+	vm.NOT:    func(a []*uint256.Int) *uint256.Int { return a[0].Not(a[0]) },
+	vm.BYTE:   func(a []*uint256.Int) *uint256.Int { return a[1].Byte(a[0]) },
+	vm.SHL: func(a []*uint256.Int) *uint256.Int {
+		if !a[0].LtUint64(256) {
+			return a[1].Clear()
+		}
+		return a[1].Lsh(a[1], uint(a[0].Uint64()))
+	},
+	vm.SHR: func(a []*uint256.Int) *uint256.Int {
+		if !a[0].LtUint64(256) {
+			return a[1].Clear()
+		}
+		return a[1].Rsh(a[1], uint(a[0].Uint64()))
+	},
+	vm.SAR: func(a []*uint256.Int) *uint256.Int {
+		if a[0].GtUint64(255) {
+			if a[1].Sign() >= 0 {
+				return a[1].Clear()
+			}
+			return a[1].SetAllOne()
+		}
+		return a[1].SRsh(a[1], uint(a[0].Uint64()))
+	},
+}
+
+func boolWord(b bool) *uint256.Int {
+	if b {
+		return uint256.NewInt(1)
+	}
+	return new(uint256.Int)
+}
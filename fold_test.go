@@ -0,0 +1,130 @@
+package specops
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+func TestConstantFolding(t *testing.T) {
+	minus := func(bc types.Bytecoder) types.Bytecoder {
+		return Fn(SUB, PUSH0, bc)
+	}
+
+	tests := []struct {
+		name string
+		bc   types.Bytecoder
+	}{
+		{"ADD", Fn(ADD, PUSH(1), PUSH(2))},
+		{"SUB", Fn(SUB, PUSH(1), PUSH(2))},
+		{"nested", Fn(MUL, Fn(ADD, PUSH(1), PUSH(2)), Fn(SUB, PUSH(10), PUSH(3)))},
+		{"DIV", Fn(DIV, PUSH(100), PUSH(7))},
+		{"DIV by zero", Fn(DIV, PUSH(100), PUSH0)},
+		{"SDIV", Fn(SDIV, minus(PUSH(100)), PUSH(7))},
+		{"MOD", Fn(MOD, PUSH(100), PUSH(7))},
+		{"SMOD", Fn(SMOD, minus(PUSH(100)), PUSH(7))},
+		{"ADDMOD", Fn(ADDMOD, MaxUint256, PUSH(2), PUSH(7))},
+		{"MULMOD", Fn(MULMOD, MaxUint256, PUSH(3), PUSH(7))},
+		{"EXP", Fn(EXP, PUSH(3), PUSH(50))},
+		{"SIGNEXTEND", Fn(SIGNEXTEND, PUSH0, PUSH(0xff))},
+		{"LT", Fn(LT, PUSH(1), PUSH(2))},
+		{"GT", Fn(GT, PUSH(1), PUSH(2))},
+		{"SLT", Fn(SLT, minus(PUSH(1)), PUSH(2))},
+		{"SGT", Fn(SGT, minus(PUSH(1)), PUSH(2))},
+		{"EQ", Fn(EQ, PUSH(2), PUSH(2))},
+		{"ISZERO", Fn(ISZERO, PUSH0)},
+		{"AND", Fn(AND, PUSH(0xf0f), PUSH(0xff))},
+		{"OR", Fn(OR, PUSH(0xf00), PUSH(0xff))},
+		{"XOR", Fn(XOR, PUSH(0xf0f), PUSH(0xff))},
+		{"NOT", Fn(NOT, PUSH(0xff))},
+		{"BYTE", Fn(BYTE, PUSH(30), PUSH(0xabcd))},
+		{"SHL", Fn(SHL, PUSH(8), PUSH(1))},
+		{"SHL overflow", Fn(SHL, PUSH(256), PUSH(1))},
+		{"SHR", Fn(SHR, PUSH(4), PUSH(0xff))},
+		{"SAR", Fn(SAR, PUSH(4), minus(PUSH(0xff)))},
+		{"SAR overflow negative", Fn(SAR, PUSH(300), minus(PUSH(1)))},
+		{"Mask", Mask(100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := Code{
+				Fn(MSTORE, PUSH0, tt.bc),
+				Fn(RETURN, PUSH0, PUSH(32)),
+			}
+			opt := code.Optimized()
+
+			if got := (Code{tt.bc}).Optimized(); len(got) != 1 {
+				t.Errorf("%T{%s}.Optimized() = %s; want single PUSH", code, bytecoderString(tt.bc), got)
+			} else if _, ok := constValue(got[0]); !ok {
+				t.Errorf("%T{%s}.Optimized() = %s; want constant PUSH", code, bytecoderString(tt.bc), got)
+			}
+
+			want, err := code.Run(nil)
+			if err != nil {
+				t.Fatalf("%T.Run() error %v", code, err)
+			}
+			got, err := opt.Run(nil)
+			if err != nil {
+				t.Fatalf("%T.Optimized().Run() error %v", code, err)
+			}
+			if !bytes.Equal(got.ReturnData, want.ReturnData) {
+				t.Errorf("%T.Optimized().Run() returned %#x; without optimisation %#x", code, got.ReturnData, want.ReturnData)
+			}
+		})
+	}
+}
+
+func TestConstantFoldingNotApplied(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+	}{
+		{
+			name: "non-constant argument",
+			code: Code{Fn(ADD, PUSH(1), CALLVALUE)},
+		},
+		{
+			name: "impure opcode",
+			code: Code{Fn(KECCAK256, PUSH0, PUSH0)},
+		},
+		{
+			name: "too few arguments",
+			code: Code{PUSH(1), Fn(ADD, PUSH(2))},
+		},
+		{
+			name: "stack transformation",
+			code: Code{Fn(SUB, stack.Permute(1, 0), PUSH(1), PUSH(2))},
+		},
+		{
+			name: "Pipe",
+			code: Code{Pipe(PUSH(1), PUSH(2)), ADD},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Optimized().Compile()
+			if err != nil {
+				t.Fatalf("%T.Optimized().Compile() error %v", tt.code, err)
+			}
+			want, err := tt.code.Compile()
+			if err != nil {
+				t.Fatalf("%T.Compile() error %v", tt.code, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%T.Optimized().Compile() = %#x; want unchanged %#x", tt.code, got, want)
+			}
+		})
+	}
+
+	// Arguments of unfoldable Fn()s are themselves folded.
+	code := Code{Fn(ADD, CALLVALUE, Fn(MUL, PUSH(6), PUSH(7)))}
+	got := code.Optimized()
+	want := Code{PUSH(42), CALLVALUE, ADD}
+	if got.String() != want.String() {
+		t.Errorf("%T.Optimized() = %s; want %s", code, got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package specops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/arr4n/specops/types"
+)
+
+// CompileFor is equivalent to Compile() except that it targets the fork with
+// the rules, e.g. that of an L2 that lags Mainnet. If PUSH0 isn't available
+// (i.e. before Shanghai) then the PUSH0 opcode, and every PUSH of a zero value,
+// including a JUMPDEST or Label location, is compiled as PUSH1 0x00 instead. A *ForbiddenOpsError is then wrapped and
+// returned if any reachable opcode isn't available under the rules; e.g.
+// TSTORE or MCOPY before Cancun. Opcodes are considered unreachable in the
+// same manner as by Validate(), so data following a halting opcode isn't
+// checked.
+//
+// Availability is determined by geth's instruction set for the rules, so forks
+// after Cancun are treated as Cancun. The stack effects of opcodes don't differ
+// between forks so are the same as for Compile().
+func (c Code) CompileFor(rules params.Rules) ([]byte, error) {
+	// An error is only returned for forks after Cancun, along with the Cancun
+	// instruction set.
+	jt, _ := vm.LookupInstructionSet(rules)
+
+	var opts compileOptions
+	if !opAvailable(jt, vm.PUSH0) {
+		c = c.withoutPUSH0()
+		opts.noPUSH0 = true
+	}
+	comp, err := c.compileContext(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	code := comp.bytecode
+
+	e := new(ForbiddenOpsError)
+	reachable := true
+	for _, in := range disassemble(code) {
+		if in.op == vm.JUMPDEST {
+			reachable = true
+		}
+		if !reachable {
+			continue
+		}
+		if _, ok := stackDeltas[in.op]; !ok {
+			// Only possible via Raw, which is treated as data.
+			reachable = false
+			continue
+		}
+		if !opAvailable(jt, in.op) {
+			e.Found = append(e.Found, ForbiddenOp{PC: in.pc, Op: in.op})
+		}
+		if isTerminal(in.op) {
+			reachable = false
+		}
+	}
+	if len(e.Found) > 0 {
+		return nil, fmt.Errorf("unavailable for target fork: %w", e)
+	}
+	return code, nil
+}
+
+// opAvailable returns whether op is defined in the instruction set.
+func opAvailable(jt vm.JumpTable, op vm.OpCode) bool {
+	switch op {
+	case vm.STOP, vm.INVALID:
+		// STOP has no cost, and INVALID is designated as such so is always
+		// "available".
+		return true
+	default:
+		return jt[op].HasCost()
+	}
+}
+
+// withoutPUSH0 returns a flattened equivalent of the Code with every PUSH0
+// opcode, and PUSH of a zero value, replaced by PUSH1 0x00. Values that are
+// only known after compilation (e.g. JUMPDEST locations) are instead handled
+// by compileOptions.noPUSH0.
+func (c Code) withoutPUSH0() Code {
+	flat := c.flatten()
+	out := make(Code, len(flat))
+	for i, bc := range flat {
+		switch b := bc.(type) {
+		case types.OpCode:
+			if vm.OpCode(b) == vm.PUSH0 {
+				bc = pushZeroLegacy{}
+			}
+		case types.StackPusher:
+			if buf := b.ToPush(); len(buf) > 0 && len(bytes.Trim(buf, "\x00")) == 0 {
+				bc = pushZeroLegacy{}
+			}
+		}
+		out[i] = bc
+	}
+	return out
+}
+
+// A pushZeroLegacy pushes zero without PUSH0.
+type pushZeroLegacy struct{}
+
+func (pushZeroLegacy) Bytecode() ([]byte, error) {
+	return []byte{byte(vm.PUSH1), 0}, nil
+}
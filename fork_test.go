@@ -0,0 +1,116 @@
+package specops
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestCompileFor(t *testing.T) {
+	london := params.Rules{IsLondon: true}
+	shanghai := params.Rules{IsShanghai: true}
+	cancun := params.Rules{IsCancun: true}
+
+	tests := []struct {
+		name  string
+		code  Code
+		rules params.Rules
+		want  []byte
+		// If non-nil, want is ignored.
+		wantForbidden []ForbiddenOp
+	}{
+		{
+			name:  "PUSH0 replaced before Shanghai",
+			code:  Code{Fn(MSTORE, PUSH0, PUSH(0)), Fn(RETURN, PUSHBytes(0, 0), PUSH(32))},
+			rules: london,
+			want: []byte{
+				byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(MSTORE),
+				byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(RETURN),
+			},
+		},
+		{
+			name: "loop starting at PC 0 before Shanghai",
+			code: Code{
+				Label("start"),
+				JUMPDEST("loop"), stack.SetDepth(0),
+				Fn(JUMP, PUSH("loop")),
+			},
+			rules: london,
+			want:  []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0, byte(JUMP)},
+		},
+		{
+			name:  "zero PUSHSize() before Shanghai",
+			code:  Code{Label("a"), Label("b"), PUSHSize("a", "b"), STOP},
+			rules: london,
+			want:  []byte{byte(vm.PUSH1), 0, byte(STOP)},
+		},
+		{
+			name: "loop starting at PC 0 from Shanghai",
+			code: Code{
+				JUMPDEST("loop"), stack.SetDepth(0),
+				Fn(JUMP, PUSH("loop")),
+			},
+			rules: shanghai,
+			want:  []byte{byte(vm.JUMPDEST), byte(PUSH0), byte(JUMP)},
+		},
+		{
+			name:  "PUSH0 retained from Shanghai",
+			code:  Code{Fn(MSTORE, PUSH0, PUSH(0))},
+			rules: shanghai,
+			want:  []byte{byte(PUSH0), byte(PUSH0), byte(MSTORE)},
+		},
+		{
+			name:          "Cancun opcodes before Cancun",
+			code:          Code{Fn(TSTORE, PUSH0, PUSH(1)), Fn(MCOPY, PUSH0, PUSH0, PUSH0), STOP},
+			rules:         shanghai,
+			wantForbidden: []ForbiddenOp{{PC: 3, Op: vm.TSTORE}, {PC: 7, Op: vm.MCOPY}},
+		},
+		{
+			name:  "Cancun opcodes from Cancun",
+			code:  Code{Fn(TSTORE, PUSH0, PUSH(1))},
+			rules: cancun,
+			want:  []byte{byte(vm.PUSH1), 1, byte(PUSH0), byte(TSTORE)},
+		},
+		{
+			name:          "Frontier",
+			code:          Code{CHAINID, SELFBALANCE, BASEFEE, STOP, Raw{byte(BASEFEE)}, INVALID},
+			rules:         params.Rules{},
+			wantForbidden: []ForbiddenOp{{PC: 0, Op: vm.CHAINID}, {PC: 1, Op: vm.SELFBALANCE}, {PC: 2, Op: vm.BASEFEE}},
+		},
+		{
+			name:  "unreachable data",
+			code:  Code{STOP, Raw{byte(TSTORE), byte(MCOPY)}},
+			rules: london,
+			want:  []byte{byte(STOP), byte(TSTORE), byte(MCOPY)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.CompileFor(tt.rules)
+
+			if tt.wantForbidden != nil {
+				var e *ForbiddenOpsError
+				if !errors.As(err, &e) {
+					t.Fatalf("%T.CompileFor() got err %v; want %T", tt.code, err, e)
+				}
+				if diff := cmp.Diff(tt.wantForbidden, e.Found); diff != "" {
+					t.Errorf("%T.CompileFor() forbidden opcodes diff (-want +got):\n%s", tt.code, diff)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%T.CompileFor() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.CompileFor() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package specops
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/revert"
+	"github.com/arr4n/specops/runopts"
+)
+
+// A Fuzzer runs a single compilation of Code against arbitrary call data,
+// returning an error on any unexpected revert. Its Run() method is a single
+// iteration, suitable for use with Go's native fuzzing:
+//
+//	fuzzer, err := code.Fuzzer()
+//	// ...
+//	f.Fuzz(func(t *testing.T, callData []byte) {
+//		if err := fuzzer.Run(callData); err != nil {
+//			t.Error(err)
+//		}
+//	})
+type Fuzzer struct {
+	built *Built
+
+	// ExpectRevert reports whether the revert is expected for the call data.
+	// If nil, no reverts are expected.
+	ExpectRevert func(callData []byte, err *revert.Error) bool
+}
+
+// Fuzzer compiles the Code and returns a Fuzzer that runs it with the options.
+// See Code.Run() for the treatment of options.
+func (c Code) Fuzzer(opts ...runopts.Option) (*Fuzzer, error) {
+	b, err := c.Build(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Fuzzer{built: b}, nil
+}
+
+// Run runs the compiled Code with the call data, returning an error if it
+// reverts and f.ExpectRevert doesn't return true, or if the EVM itself returns
+// an error. Reverts are detected even if runopts.NoErrorOnRevert() is used.
+func (f *Fuzzer) Run(callData []byte) error {
+	res, err := f.built.Run(callData)
+	if res == nil {
+		return err
+	}
+	rErr, ok := revert.ErrFrom(res).(*revert.Error)
+	if !ok {
+		return nil
+	}
+	if f.ExpectRevert != nil && f.ExpectRevert(callData, rErr) {
+		return nil
+	}
+	return fmt.Errorf("unexpected revert with call data %#x: %w", callData, rErr)
+}
+
+// FuzzRun runs the Code the specified number of times, each with call data
+// returned by gen, and returns an error on the first unexpected revert. The
+// Code is only compiled once. See Fuzzer.Run() for details of errors and the
+// treatment of expectRevert, which MAY be nil.
+func (c Code) FuzzRun(gen func() []byte, iterations int, expectRevert func(callData []byte, err *revert.Error) bool, opts ...runopts.Option) error {
+	f, err := c.Fuzzer(opts...)
+	if err != nil {
+		return err
+	}
+	f.ExpectRevert = expectRevert
+
+	for i := 0; i < iterations; i++ {
+		if err := f.Run(gen()); err != nil {
+			return fmt.Errorf("iteration %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// FuzzRun compiles the Code and uses it as the target of Go's native fuzzing,
+// reporting an error for any call data that results in a fault; i.e. an
+// exceptional halt such as INVALID, stack underflow, or running out of gas.
+// Reverts are not considered faults. A fault is allowed if allowFault, which
+// MAY be nil, returns true. The options are applied as with Code.Run().
+//
+//	func FuzzContract(f *testing.F) {
+//		f.Add([]byte{0})
+//		specops.FuzzRun(f, code, nil)
+//	}
+//
+// Unlike Code.FuzzRun(), which checks for unexpected reverts over call data
+// from a generator, FuzzRun() is driven by the Go fuzzing engine.
+func FuzzRun(f *testing.F, code Code, allowFault func(callData []byte, err error) bool, opts ...runopts.Option) {
+	f.Helper()
+	b, err := code.Build(opts...)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, callData []byte) {
+		res, err := b.Run(callData)
+		if res == nil {
+			t.Fatalf("Run(%#x) error %v", callData, err)
+		}
+		fault := res.Err
+		if fault == nil || errors.Is(fault, vm.ErrExecutionReverted) {
+			return
+		}
+		if allowFault != nil && allowFault(callData, fault) {
+			return
+		}
+		t.Errorf("Run(%#x) faulted: %v", callData, fault)
+	})
+}
@@ -0,0 +1,112 @@
+package specops
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/revert"
+	"github.com/arr4n/specops/runopts"
+	"github.com/arr4n/specops/stack"
+)
+
+// revertOnFF reverts i.f.f. the first byte of call data is 0xff.
+var revertOnFF = Code{
+	Fn(JUMPI, PUSH("ok"), Fn(LT, Fn(BYTE, PUSH0, Fn(CALLDATALOAD, PUSH0)), PUSH(0xff))),
+	Fn(REVERT, PUSH0, PUSH0),
+	JUMPDEST("ok"), stack.SetDepth(0),
+	STOP,
+}
+
+func TestFuzzRun(t *testing.T) {
+	// counter returns a generator of single-byte call data, incrementing from
+	// zero.
+	counter := func() func() []byte {
+		var b byte
+		return func() []byte {
+			defer func() { b++ }()
+			return []byte{b}
+		}
+	}
+	expectFF := func(callData []byte, _ *revert.Error) bool {
+		return callData[0] == 0xff
+	}
+
+	tests := []struct {
+		name         string
+		iterations   int
+		expectRevert func([]byte, *revert.Error) bool
+		opts         []runopts.Option
+		wantErr      bool
+	}{
+		{
+			name:       "no revert",
+			iterations: 255,
+		},
+		{
+			name:       "unexpected revert",
+			iterations: 256,
+			wantErr:    true,
+		},
+		{
+			name:       "unexpected revert without error on revert",
+			iterations: 256,
+			opts:       []runopts.Option{runopts.NoErrorOnRevert()},
+			wantErr:    true,
+		},
+		{
+			name:         "expected revert",
+			iterations:   256,
+			expectRevert: expectFF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := revertOnFF.FuzzRun(counter(), tt.iterations, tt.expectRevert, tt.opts...)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("%T.FuzzRun(…) got error %v; want error %t", revertOnFF, err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			if _, ok := revert.Data(err); !ok {
+				t.Errorf("%T.FuzzRun(…) got error %v; want wrapping %T", revertOnFF, err, &revert.Error{})
+			}
+		})
+	}
+}
+
+func FuzzFuzzerRun(f *testing.F) {
+	fuzzer, err := revertOnFF.Fuzzer()
+	if err != nil {
+		f.Fatalf("%T.Fuzzer() error %v", revertOnFF, err)
+	}
+	fuzzer.ExpectRevert = func(callData []byte, _ *revert.Error) bool {
+		return len(callData) > 0 && callData[0] == 0xff
+	}
+
+	f.Add([]byte{})
+	f.Add([]byte{0})
+	f.Add([]byte{0xfe, 0xff})
+	f.Add([]byte{0xff})
+
+	f.Fuzz(func(t *testing.T, callData []byte) {
+		if err := fuzzer.Run(callData); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func FuzzHashOrEcho(f *testing.F) {
+	f.Add([]byte{0, 42})
+	f.Add([]byte{1, 42})
+
+	// Without any call data, the length of the remainder underflows, so the
+	// CALLDATACOPY's gas calculation overflows.
+	allowFault := func(callData []byte, err error) bool {
+		return len(callData) == 0 && errors.Is(err, vm.ErrGasUintOverflow)
+	}
+	FuzzRun(f, hashOrEcho, allowFault)
+}
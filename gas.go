@@ -0,0 +1,103 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// StaticGas compiles the Code and returns the sum of the constant gas costs of
+// its opcodes, ignoring all dynamic costs such as memory expansion, cold
+// account and storage access, and the size-dependent costs of copying,
+// hashing, and logging. The result is therefore a lower bound on the gas
+// consumed by executing the fragment from start to end, useful for quickly
+// comparing alternative implementations.
+//
+// The Code MUST be straight-line; i.e. it MUST NOT contain JUMP nor JUMPI,
+// although it MAY contain JUMPDESTs, which are costed as if fallen through. An
+// error is also returned for invalid opcodes and truncated PUSHes. Costs are
+// those of the Cancun instruction set, which is used by Code.Run().
+func (c Code) StaticGas() (uint64, error) {
+	code, err := c.Compile()
+	if err != nil {
+		return 0, err
+	}
+
+	var total uint64
+	for _, in := range disassemble(code) {
+		switch {
+		case in.op == vm.JUMP || in.op == vm.JUMPI:
+			return 0, fmt.Errorf("%v at PC %d in non-straight-line code", in.op, in.pc)
+		case in.truncated:
+			return 0, fmt.Errorf("%v at PC %d truncated", in.op, in.pc)
+		}
+		gas, ok := constantGas(in.op)
+		if !ok {
+			return 0, fmt.Errorf("invalid opcode %v at PC %d", in.op, in.pc)
+		}
+		total += gas
+	}
+	return total, nil
+}
+
+// constantGas returns the constant gas cost of the opcode in the Cancun
+// instruction set, mirroring geth's (unexported) jump table, and whether the
+// opcode is valid.
+func constantGas(op vm.OpCode) (uint64, bool) {
+	switch {
+	case op.IsPush(), op >= vm.DUP1 && op <= vm.DUP16, op >= vm.SWAP1 && op <= vm.SWAP16:
+		if op == vm.PUSH0 {
+			return vm.GasQuickStep, true
+		}
+		return vm.GasFastestStep, true
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0, true // all dynamic
+	}
+
+	switch op {
+	case vm.STOP, vm.SLOAD, vm.SSTORE, vm.RETURN, vm.REVERT, vm.INVALID:
+		return 0, true
+
+	case vm.JUMPDEST:
+		return params.JumpdestGas, true
+
+	case vm.ADDRESS, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATASIZE,
+		vm.CODESIZE, vm.GASPRICE, vm.RETURNDATASIZE, vm.COINBASE, vm.TIMESTAMP,
+		vm.NUMBER, vm.DIFFICULTY, vm.GASLIMIT, vm.CHAINID, vm.BASEFEE,
+		vm.BLOBBASEFEE, vm.POP, vm.PC, vm.MSIZE, vm.GAS:
+		return vm.GasQuickStep, true
+
+	case vm.ADD, vm.SUB, vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.ISZERO, vm.AND,
+		vm.OR, vm.XOR, vm.NOT, vm.BYTE, vm.SHL, vm.SHR, vm.SAR, vm.CALLDATALOAD,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.BLOBHASH, vm.MLOAD,
+		vm.MSTORE, vm.MSTORE8, vm.MCOPY:
+		return vm.GasFastestStep, true
+
+	case vm.MUL, vm.DIV, vm.SDIV, vm.MOD, vm.SMOD, vm.SIGNEXTEND, vm.SELFBALANCE:
+		return vm.GasFastStep, true
+
+	case vm.ADDMOD, vm.MULMOD:
+		return vm.GasMidStep, true
+
+	case vm.EXP:
+		return vm.GasSlowStep, true
+
+	case vm.BLOCKHASH:
+		return vm.GasExtStep, true
+
+	case vm.KECCAK256:
+		return params.Keccak256Gas, true
+
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODECOPY, vm.EXTCODEHASH, vm.TLOAD,
+		vm.TSTORE, vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		return params.WarmStorageReadCostEIP2929, true
+
+	case vm.CREATE, vm.CREATE2:
+		return params.CreateGas, true
+
+	case vm.SELFDESTRUCT:
+		return params.SelfdestructGasEIP150, true
+	}
+	return 0, false
+}
@@ -0,0 +1,105 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestStaticGas(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want uint64
+	}{
+		{
+			name: "empty",
+			code: Code{},
+			want: 0,
+		},
+		{
+			name: "arithmetic",
+			code: Code{
+				Fn(ADD, PUSH(1), PUSH(2)),   // 3 + 3 + 3
+				Fn(MUL, PUSH0),              // 2 + 5
+				Fn(ADDMOD, CALLER, PUSH(7)), // 2 + 3 + 8
+				Fn(EXP, PUSH(2)),            // 3 + 10
+				POP,                         // 2
+			},
+			want: 44,
+		},
+		{
+			name: "JUMPDEST and terminal",
+			code: Code{
+				JUMPDEST("x"), stack.SetDepth(0), // 1
+				Fn(SELFBALANCE), POP, // 5 + 2
+				STOP, // 0
+			},
+			want: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.StaticGas()
+			if err != nil {
+				t.Fatalf("%T.StaticGas() error %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("%T.StaticGas() got %d; want %d", tt.code, got, tt.want)
+			}
+
+			// Without any dynamic costs, the static gas is exactly that used
+			// by execution, after the intrinsic cost of the transaction.
+			res, err := tt.code.Run(nil)
+			if err != nil {
+				t.Fatalf("%T.Run() error %v", tt.code, err)
+			}
+			if got, want := res.UsedGas-params.TxGas, tt.want; got != want {
+				t.Errorf("%T.Run() used %d gas in excess of intrinsic; want %d", tt.code, got, want)
+			}
+		})
+	}
+}
+
+func TestStaticGasErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        Code
+		errContains string
+	}{
+		{
+			name:        "JUMP",
+			code:        Code{Fn(JUMP, PUSH("x")), JUMPDEST("x"), stack.SetDepth(0)},
+			errContains: "non-straight-line",
+		},
+		{
+			name:        "JUMPI",
+			code:        Code{Fn(JUMPI, PUSH("x"), PUSH0), JUMPDEST("x"), stack.SetDepth(0)},
+			errContains: "non-straight-line",
+		},
+		{
+			name:        "invalid opcode",
+			code:        Code{Raw{0x0c}},
+			errContains: "invalid opcode",
+		},
+		{
+			name:        "truncated PUSH",
+			code:        Code{Raw{byte(vm.PUSH2), 1}},
+			errContains: "truncated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.code.StaticGas()
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("%T.StaticGas() got error %v; want containing %q", tt.code, err, tt.errContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+)
+
+// Generate compiles the Code and writes a Go source file, in package pkg, that
+// declares a variable named varName holding the bytecode as a []byte. It is
+// intended to be called by a program invoked with `//go:generate`, allowing
+// contracts to be compiled into binaries at build time without depending on
+// specops at run time. The file carries the standard header that marks it as
+// generated code.
+func Generate(w io.Writer, pkg, varName string, code Code) error {
+	for _, id := range []string{pkg, varName} {
+		if !token.IsIdentifier(id) {
+			return fmt.Errorf("invalid Go identifier %q", id)
+		}
+	}
+
+	compiled, err := code.Compile()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by specops.Generate(); DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&buf, "// %s is the compiled bytecode (%d bytes).\n", varName, len(compiled))
+	fmt.Fprintf(&buf, "var %s = []byte{", varName)
+	for i, b := range compiled {
+		if i%16 == 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "0x%02x, ", b)
+	}
+	buf.WriteString("\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("BUG: formatting generated source: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
@@ -0,0 +1,69 @@
+package specops
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, PUSHBytes(bytes.Repeat([]byte{0xfe}, 32)...)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+	want, err := code.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", code, err)
+	}
+
+	var buf bytes.Buffer
+	if err := Generate(&buf, "contracts", "Bytecode", code); err != nil {
+		t.Fatalf("Generate() error %v", err)
+	}
+	src := buf.String()
+
+	// https://pkg.go.dev/cmd/go#hdr-Generate_Go_files_by_processing_source
+	if !regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`).MatchString(src) {
+		t.Error("Generate() output missing generated-code header")
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, 0)
+	if err != nil {
+		t.Fatalf("parser.ParseFile(Generate() output) error %v", err)
+	}
+	if got, want := file.Name.Name, "contracts"; got != want {
+		t.Errorf("Generate() package name = %q; want %q", got, want)
+	}
+
+	spec := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+	if got, want := spec.Names[0].Name, "Bytecode"; got != want {
+		t.Errorf("Generate() variable name = %q; want %q", got, want)
+	}
+	var got []byte
+	for _, el := range spec.Values[0].(*ast.CompositeLit).Elts {
+		b, err := strconv.ParseUint(el.(*ast.BasicLit).Value, 0, 8)
+		if err != nil {
+			t.Fatalf("Parsing byte in Generate() output: %v", err)
+		}
+		got = append(got, byte(b))
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Generate() variable = %#x; want %#x", got, want)
+	}
+
+	for _, bad := range []struct{ pkg, varName string }{
+		{"", "x"},
+		{"x", ""},
+		{"my-pkg", "x"},
+		{"x", "1st"},
+	} {
+		if err := Generate(new(strings.Builder), bad.pkg, bad.varName, code); err == nil {
+			t.Errorf("Generate(%q, %q) got nil error", bad.pkg, bad.varName)
+		}
+	}
+}
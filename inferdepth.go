@@ -0,0 +1,187 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// WithInferredDepths returns a flattened equivalent of the Code in which every
+// JUMPDEST without an explicit stack.SetDepth() is followed by one, the depth
+// of which is inferred from the ways in which the JUMPDEST is reached:
+//
+//   - by falling through from the preceding code, if it doesn't end with JUMP
+//     or a halting opcode; and
+//   - by a JUMP or JUMPI that immediately follows PUSH(<the JUMPDEST>), with
+//     the depth being that after the jump opcode pops its arguments.
+//
+// An error is returned if the inferred depths of the incoming paths conflict,
+// or if a JUMPDEST is reached by neither means, e.g. if it is only the target
+// of a jump table, in which case a stack.SetDepth() is still required. An
+// explicit stack.SetDepth() is always used as is, and inference of all later
+// depths starts from its value.
+//
+// Inference doesn't validate the Code beyond the above conditions, so
+// compilation may still fail; e.g. due to stack underflow.
+func (c Code) WithInferredDepths() (Code, error) {
+	flat := c.flatten()
+
+	explicit := make(map[tag]bool)
+	for i, bc := range flat {
+		if j, ok := bc.(JUMPDEST); ok && i+1 < len(flat) && setsDepth(flat[i+1]) {
+			explicit[tag(j)] = true
+		}
+	}
+
+	inferred := make(map[tag]int)
+	// Every pass over the Code can only add to the inferred depths, and a
+	// JUMPDEST that is still unknown after a pass without additions will
+	// never be known.
+	for changed := true; changed; {
+		var err error
+		changed, err = inferDepthsPass(flat, explicit, inferred)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(Code, 0, len(flat)+len(inferred))
+	for _, bc := range flat {
+		out = append(out, bc)
+
+		j, ok := bc.(JUMPDEST)
+		if !ok || explicit[tag(j)] {
+			continue
+		}
+		d, ok := inferred[tag(j)]
+		if !ok {
+			return nil, fmt.Errorf("%s: can't infer stack depth as it is neither reached by falling through nor the target of a PUSH + JUMP/JUMPI; add a %T", bytecoderString(j), stack.SetDepth(0))
+		}
+		out = append(out, stack.SetDepth(d))
+	}
+	return out, nil
+}
+
+// setsDepth returns whether bc is a compiler hint that satisfies the need for
+// a JUMPDEST to be followed by a depth setting.
+func setsDepth(bc types.Bytecoder) bool {
+	switch bc.(type) {
+	case stack.SetDepth, retainDepth:
+		return true
+	default:
+		return false
+	}
+}
+
+// inferDepthsPass performs a single pass over the flattened Code, adding
+// depths of non-explicit JUMPDESTs to `inferred`, and returning whether any
+// were added.
+func inferDepthsPass(flat Code, explicit map[tag]bool, inferred map[tag]int) (bool, error) {
+	var (
+		changed bool
+		depth   int
+		known   = true // whether depth is meaningful, i.e. the code is reachable with a known depth
+		pushed  tag    // the last pushTag or pushWide, if immediately preceding
+	)
+
+	infer := func(t tag, d int, via string) error {
+		switch got, ok := inferred[t]; {
+		case !ok:
+			inferred[t] = d
+			changed = true
+		case got != d:
+			return fmt.Errorf("%s reached with conflicting stack depths %d and %d (the latter %s)", bytecoderString(JUMPDEST(t)), got, d, via)
+		}
+		return nil
+	}
+
+	for i, bc := range flat {
+		lastPushed := pushed
+		pushed = ""
+
+		switch bc := bc.(type) {
+		case JUMPDEST:
+			t := tag(bc)
+			switch {
+			case explicit[t]:
+				// The SetDepth() that follows will be handled in the next
+				// iteration.
+			case known:
+				if err := infer(t, depth, "by falling through"); err != nil {
+					return false, err
+				}
+			default:
+				depth, known = inferred[t]
+			}
+			continue
+
+		case stack.SetDepth:
+			depth, known = int(bc), true
+			continue
+
+		case pushTag:
+			pushed = tag(bc)
+			depth++
+			continue
+
+		case pushWide:
+			pushed = tag(bc)
+			depth++
+			continue
+
+		case pushTags, pushSize, pushLinked:
+			depth++
+			continue
+
+		case lazyLocator, Raw:
+			continue
+
+		case Inverted:
+			depth += netDepthChange(vm.OpCode(bc))
+			continue
+
+		case stack.FromBottom:
+			depth += netDepthChange(vm.OpCode(bc))
+			continue
+
+		case stack.FromTop:
+			depth += netDepthChange(vm.OpCode(bc))
+			continue
+
+		case stack.Ref:
+			depth++ // a DUP
+			continue
+		}
+
+		code, err := bc.Bytecode()
+		if err != nil {
+			// Compiler hints, which don't modify the stack, return errors,
+			// as do invalid Bytecoders, which compilation will report.
+			continue
+		}
+		for _, in := range disassemble(code) {
+			depth += netDepthChange(in.op)
+
+			isJump := in.op == vm.JUMP || in.op == vm.JUMPI
+			if isJump && len(code) == 1 && lastPushed != "" && known && !explicit[lastPushed] {
+				if err := infer(lastPushed, depth, fmt.Sprintf("by %v at index %d", in.op, i)); err != nil {
+					return false, err
+				}
+			}
+			if isTerminal(in.op) {
+				known = false
+			}
+		}
+	}
+	return changed, nil
+}
+
+// netDepthChange returns the net change in stack depth caused by the opcode, or 0
+// if it is invalid.
+func netDepthChange(op vm.OpCode) int {
+	d := stackDeltas[op]
+	return int(d.push) - int(d.pop)
+}
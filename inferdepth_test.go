@@ -0,0 +1,129 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestWithInferredDepths(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		// want is the explicit equivalent, which is compared after
+		// compilation; empty if an error is expected.
+		want        Code
+		errContains string
+	}{
+		{
+			name: "backward loop",
+			code: Code{
+				PUSH(3),
+				JUMPDEST("loop"),
+				PUSH(1), SWAP1, SUB,
+				Fn(JUMPI, PUSH("loop"), DUP1),
+				STOP,
+			},
+			want: Code{
+				PUSH(3),
+				JUMPDEST("loop"), stack.SetDepth(1),
+				PUSH(1), SWAP1, SUB,
+				Fn(JUMPI, PUSH("loop"), DUP1),
+				STOP,
+			},
+		},
+		{
+			name: "forward jumps",
+			code: Code{
+				PUSH0, PUSH0,
+				Fn(JUMPI, PUSH("b"), CALLVALUE), // depth 2
+				POP,
+				Fn(JUMP, PUSH("a")), // depth 1
+				JUMPDEST("b"),
+				POP,
+				JUMPDEST("a"), // also reached by falling through from "b"
+				POP,
+				Fn(JUMP, PUSHWide("c")),
+				JUMPDEST("unused"), stack.SetDepth(42), STOP,
+				JUMPDEST("c"),
+				STOP,
+			},
+			want: Code{
+				PUSH0, PUSH0,
+				Fn(JUMPI, PUSH("b"), CALLVALUE),
+				POP,
+				Fn(JUMP, PUSH("a")),
+				JUMPDEST("b"), stack.SetDepth(2),
+				POP,
+				JUMPDEST("a"), stack.SetDepth(1),
+				POP,
+				Fn(JUMP, PUSHWide("c")),
+				JUMPDEST("unused"), stack.SetDepth(42), STOP,
+				JUMPDEST("c"), stack.SetDepth(0),
+				STOP,
+			},
+		},
+		{
+			name: "conflict via jumps",
+			code: Code{
+				Fn(JUMPI, PUSH("x"), CALLVALUE),
+				PUSH0,
+				Fn(JUMP, PUSH("x")),
+				JUMPDEST("x"),
+			},
+			errContains: `JUMPDEST("x") reached with conflicting stack depths 0 and 1 (the latter by JUMP`,
+		},
+		{
+			name: "conflict via fall through",
+			code: Code{
+				Fn(JUMPI, PUSH("x"), CALLVALUE),
+				PUSH0,
+				JUMPDEST("x"),
+			},
+			errContains: "conflicting stack depths 0 and 1 (the latter by falling through)",
+		},
+		{
+			name: "jump table only",
+			code: Code{
+				Fn(JUMP, Fn(BYTE, PUSH0, PUSH([]string{"x"}))),
+				JUMPDEST("x"),
+			},
+			errContains: `JUMPDEST("x"): can't infer stack depth`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.WithInferredDepths()
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("%T.WithInferredDepths() got err %v; want containing %q", tt.code, err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%T.WithInferredDepths() error %v", tt.code, err)
+			}
+
+			gotBuf, err := got.Compile()
+			if err != nil {
+				t.Fatalf("%T.WithInferredDepths().Compile() error %v", tt.code, err)
+			}
+			wantBuf, err := tt.want.Compile()
+			if err != nil {
+				t.Fatalf("Compile() of explicit equivalent error %v", err)
+			}
+			if diff := cmp.Diff(wantBuf, gotBuf); diff != "" {
+				t.Errorf("%T.WithInferredDepths().Compile() diff (-want +got):\n%s", tt.code, diff)
+			}
+			if diff := cmp.Diff(tt.want.flatten(), got, cmp.Comparer(func(a, b Code) bool {
+				return bytecoderString(a) == bytecoderString(b)
+			})); diff != "" {
+				t.Errorf("%T.WithInferredDepths() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
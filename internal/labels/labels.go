@@ -0,0 +1,51 @@
+// Package labels maps program counters to the JUMPDEST or Label that most
+// closely precedes them, allowing compiled bytecode to be described in terms of
+// the sections of the Code from which it was compiled.
+package labels
+
+import "sort"
+
+// An Index supports lookup of the label at or before a program counter.
+type Index struct {
+	offsets []int
+	names   []string
+}
+
+// New returns an Index of the labels, which map names to byte offsets.
+func New(labels map[string]int) *Index {
+	names := make([]string, 0, len(labels))
+	for n := range labels {
+		names = append(names, n)
+	}
+	// Sorting by name as well as offset makes ties deterministic.
+	sort.Slice(names, func(i, j int) bool {
+		ni, nj := names[i], names[j]
+		if oi, oj := labels[ni], labels[nj]; oi != oj {
+			return oi < oj
+		}
+		return ni < nj
+	})
+
+	x := &Index{
+		offsets: make([]int, len(names)),
+		names:   names,
+	}
+	for i, n := range names {
+		x.offsets[i] = labels[n]
+	}
+	return x
+}
+
+// Nearest returns the name of the label with the greatest offset <= pc, and
+// true, or false if there is no such label. If multiple labels share the same
+// offset then the one with the lexicographically greatest name is returned.
+func (x *Index) Nearest(pc uint64) (string, bool) {
+	// Index of the first label *after* pc.
+	i := sort.Search(len(x.offsets), func(i int) bool {
+		return uint64(x.offsets[i]) > pc
+	})
+	if i == 0 {
+		return "", false
+	}
+	return x.names[i-1], true
+}
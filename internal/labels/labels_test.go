@@ -0,0 +1,34 @@
+package labels
+
+import "testing"
+
+func TestNearest(t *testing.T) {
+	x := New(map[string]int{
+		"a":    2,
+		"b":    5,
+		"c":    5,
+		"data": 10,
+	})
+
+	tests := []struct {
+		pc     uint64
+		want   string
+		wantOK bool
+	}{
+		{pc: 0},
+		{pc: 1},
+		{pc: 2, want: "a", wantOK: true},
+		{pc: 4, want: "a", wantOK: true},
+		{pc: 5, want: "c", wantOK: true},
+		{pc: 9, want: "c", wantOK: true},
+		{pc: 10, want: "data", wantOK: true},
+		{pc: 1000, want: "data", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		got, ok := x.Nearest(tt.pc)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Nearest(%d) got (%q, %t); want (%q, %t)", tt.pc, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
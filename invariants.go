@@ -0,0 +1,96 @@
+package specops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Assert returns the Code with an additional compiler hint that causes
+// compilation to fail if fn returns an error when called with the final,
+// compiled bytecode. It allows contract-level invariants to be enforced by
+// compilation, e.g. that the code doesn't contain SELFDESTRUCT or is smaller
+// than the EIP-170 limit. See Code.Compile() for aggregation of errors.
+//
+// The hint generates no bytecode and can be placed anywhere in the Code,
+// including within a nested Code, with the same effect. The receiver is not
+// modified so multiple Asserts can branch from the same Code.
+func (c Code) Assert(fn func(bytecode []byte) error) Code {
+	return append(c[:len(c):len(c)], bytecodeAssertion(fn))
+}
+
+// A bytecodeAssertion is a compiler hint added by Code.Assert().
+type bytecodeAssertion func([]byte) error
+
+// Bytecode always returns an error; the assertion must be included in a Code.
+func (a bytecodeAssertion) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", a)
+}
+
+// checkAssertions calls every assertion with the compiled bytecode, returning
+// all resulting errors, in order, as a single error.
+func checkAssertions(bytecode []byte, assertions []bytecodeAssertion) error {
+	var errs []error
+	for i, a := range assertions {
+		// Cloned so that assertions can't modify the compiled output.
+		if err := a(bytes.Clone(bytecode)); err != nil {
+			errs = append(errs, fmt.Errorf("Code.Assert()[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AssertNoOps compiles the Code and returns a *ForbiddenOpsError if it contains
+// any of the opcodes, e.g. SELFDESTRUCT or DELEGATECALL for a security policy.
+// PUSH data is skipped but all other bytes are treated as opcodes, including
+// those of unreachable data sections, which may therefore result in false
+// positives. A compilation error is returned as is.
+//
+// For a check that is applied on every compilation, use it with Code.Assert():
+//
+//	code.Assert(func([]byte) error { return code.AssertNoOps(vm.SELFDESTRUCT) })
+func (c Code) AssertNoOps(ops ...vm.OpCode) error {
+	code, err := c.Compile()
+	if err != nil {
+		return err
+	}
+	forbidden := make(map[vm.OpCode]bool, len(ops))
+	for _, op := range ops {
+		forbidden[op] = true
+	}
+
+	e := new(ForbiddenOpsError)
+	for _, in := range disassemble(code) {
+		if forbidden[in.op] {
+			e.Found = append(e.Found, ForbiddenOp{PC: in.pc, Op: in.op})
+		}
+	}
+	if len(e.Found) == 0 {
+		return nil
+	}
+	return e
+}
+
+// A ForbiddenOp is an opcode found by Code.AssertNoOps().
+type ForbiddenOp struct {
+	PC int
+	Op vm.OpCode
+}
+
+// A ForbiddenOpsError is returned by Code.AssertNoOps() if any forbidden
+// opcodes are found, in order of PC.
+type ForbiddenOpsError struct {
+	Found []ForbiddenOp
+}
+
+// Error returns a description of all forbidden opcodes.
+func (e *ForbiddenOpsError) Error() string {
+	found := make([]string, len(e.Found))
+	for i, f := range e.Found {
+		found[i] = fmt.Sprintf("%v at PC %d", f.Op, f.PC)
+	}
+	return "forbidden opcodes: " + strings.Join(found, ", ")
+}
@@ -0,0 +1,171 @@
+package specops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAssert(t *testing.T) {
+	errTooLong := errors.New("too long")
+	maxLen := func(n int) func([]byte) error {
+		return func(b []byte) error {
+			if len(b) > n {
+				return fmt.Errorf("%w: %d > %d", errTooLong, len(b), n)
+			}
+			return nil
+		}
+	}
+
+	errSelfDestruct := errors.New("contains SELFDESTRUCT")
+	noSelfDestruct := func(b []byte) error {
+		for _, in := range disassemble(b) {
+			if in.op == vm.SELFDESTRUCT {
+				return errSelfDestruct
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		code     Code
+		wantErrs []error
+	}{
+		{
+			name: "all pass",
+			code: Code{PUSH0, SELFDESTRUCT}.Assert(maxLen(2)),
+		},
+		{
+			name:     "one fails",
+			code:     Code{PUSH0, SELFDESTRUCT}.Assert(maxLen(2)).Assert(noSelfDestruct),
+			wantErrs: []error{errSelfDestruct},
+		},
+		{
+			name:     "all fail",
+			code:     Code{PUSH(0xff), SELFDESTRUCT}.Assert(maxLen(2)).Assert(noSelfDestruct),
+			wantErrs: []error{errTooLong, errSelfDestruct},
+		},
+		{
+			name: "nested",
+			code: Code{
+				Code{PUSH(0xff)}.Assert(maxLen(2)),
+				SELFDESTRUCT,
+			},
+			wantErrs: []error{errTooLong},
+		},
+		{
+			name: "opcode data not misinterpreted",
+			code: Code{PUSH(byte(vm.SELFDESTRUCT))}.Assert(noSelfDestruct),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.code.Compile()
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("%T.Compile() error %v", tt.code, err)
+				}
+				return
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("%T.Compile() got error %v; want wrapping %v", tt.code, err, want)
+				}
+			}
+		})
+	}
+
+	t.Run("branching from same Code", func(t *testing.T) {
+		base := make(Code, 0, 8) // spare capacity would be shared by append()
+		base = append(base, PUSH0, SELFDESTRUCT)
+
+		pass := base.Assert(maxLen(2))
+		fail := base.Assert(noSelfDestruct)
+
+		if _, err := pass.Compile(); err != nil {
+			t.Errorf("%T.Compile() of first branch error %v", pass, err)
+		}
+		if _, err := fail.Compile(); !errors.Is(err, errSelfDestruct) {
+			t.Errorf("%T.Compile() of second branch got error %v; want %v", fail, err, errSelfDestruct)
+		}
+	})
+
+	t.Run("output unmodified", func(t *testing.T) {
+		code := Code{PUSH0, STOP}
+		want, err := code.Compile()
+		if err != nil {
+			t.Fatalf("%T.Compile() error %v", code, err)
+		}
+
+		code = code.Assert(func(b []byte) error {
+			for i := range b {
+				b[i] = 0
+			}
+			return nil
+		})
+		got, err := code.Compile()
+		if err != nil {
+			t.Fatalf("%T.Compile() error %v", code, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%T.Compile() with modifying assertion got %#x; want %#x", code, got, want)
+		}
+	})
+}
+
+func TestAssertNoOps(t *testing.T) {
+	code := Code{
+		PUSH(byte(vm.SELFDESTRUCT)), // PUSH data is skipped
+		Fn(DELEGATECALL, GAS, CALLER, PUSH0, PUSH0, PUSH0, PUSH0),
+		Fn(SELFDESTRUCT, CALLER),
+	}
+
+	tests := []struct {
+		ops  []vm.OpCode
+		want []ForbiddenOp
+	}{
+		{
+			ops: nil,
+		},
+		{
+			ops: []vm.OpCode{vm.CREATE2},
+		},
+		{
+			ops:  []vm.OpCode{vm.SELFDESTRUCT},
+			want: []ForbiddenOp{{PC: 10, Op: vm.SELFDESTRUCT}},
+		},
+		{
+			ops: []vm.OpCode{vm.SELFDESTRUCT, vm.CALLER},
+			want: []ForbiddenOp{
+				{PC: 6, Op: vm.CALLER},
+				{PC: 9, Op: vm.CALLER},
+				{PC: 10, Op: vm.SELFDESTRUCT},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		err := code.AssertNoOps(tt.ops...)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%T.AssertNoOps(%v) got error %v; want nil", code, tt.ops, err)
+			}
+			continue
+		}
+
+		var got *ForbiddenOpsError
+		if !errors.As(err, &got) {
+			t.Errorf("%T.AssertNoOps(%v) got error %v; want %T", code, tt.ops, err, got)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got.Found); diff != "" {
+			t.Errorf("%T.AssertNoOps(%v) found diff (-want +got):\n%s", code, tt.ops, diff)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package specops
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// CompileJSON compiles the Code and returns a single JSON document describing
+// the output, for consumption by non-Go tooling (e.g. a browser playground).
+// The document has the following fields:
+//
+//   - bytecode: the compiled bytecode as a 0x-prefixed hex string;
+//   - labels: the byte offset of every JUMPDEST and Label, keyed by name;
+//   - disassembly: an array of {pc, op, data} instructions, where data is only
+//     present for PUSH1 to PUSH32 and is hex encoded;
+//   - metrics: {size, instructions, labels, expandPasses}.
+func (c Code) CompileJSON() ([]byte, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	instrs := disassemble(comp.bytecode)
+	out := jsonCompilation{
+		Bytecode:    comp.bytecode,
+		Labels:      comp.labels(),
+		Disassembly: make([]jsonInstruction, len(instrs)),
+		Metrics: jsonMetrics{
+			Size:         len(comp.bytecode),
+			Instructions: len(instrs),
+			Labels:       len(comp.splices.allTags),
+			ExpandPasses: comp.splices.expandPasses,
+		},
+	}
+	for i, in := range instrs {
+		out.Disassembly[i] = jsonInstruction{
+			PC:   in.pc,
+			Op:   in.op.String(),
+			Data: in.data,
+		}
+	}
+
+	buf, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal(%T): %v", out, err)
+	}
+	return buf, nil
+}
+
+type jsonCompilation struct {
+	Bytecode    hexutil.Bytes     `json:"bytecode"`
+	Labels      map[string]int    `json:"labels"`
+	Disassembly []jsonInstruction `json:"disassembly"`
+	Metrics     jsonMetrics       `json:"metrics"`
+}
+
+type jsonInstruction struct {
+	PC   int           `json:"pc"`
+	Op   string        `json:"op"`
+	Data hexutil.Bytes `json:"data,omitempty"`
+}
+
+type jsonMetrics struct {
+	Size         int `json:"size"`
+	Instructions int `json:"instructions"`
+	Labels       int `json:"labels"`
+	ExpandPasses int `json:"expandPasses"`
+}
@@ -0,0 +1,58 @@
+package specops
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestCompileJSON(t *testing.T) {
+	code := Code{
+		Fn(JUMP, PUSH("end")),
+		Label("data"),
+		Raw{byte(INVALID), byte(ADD)},
+		JUMPDEST("end"), stack.SetDepth(0),
+		STOP,
+	}
+
+	buf, err := code.CompileJSON()
+	if err != nil {
+		t.Fatalf("%T.CompileJSON() error %v", code, err)
+	}
+	t.Logf("JSON: %s", buf)
+
+	var got jsonCompilation
+	if err := json.Unmarshal(buf, &got); err != nil {
+		t.Fatalf("json.Unmarshal(%T.CompileJSON(), %T) error %v", code, &got, err)
+	}
+
+	want := jsonCompilation{
+		Bytecode: asBytes(vm.PUSH1, 5, vm.JUMP, vm.INVALID, vm.ADD, vm.JUMPDEST, vm.STOP),
+		Labels: map[string]int{
+			"data": 3,
+			"end":  5,
+		},
+		Disassembly: []jsonInstruction{
+			{PC: 0, Op: "PUSH1", Data: []byte{5}},
+			{PC: 2, Op: "JUMP"},
+			// Raw data is indistinguishable from opcodes once compiled.
+			{PC: 3, Op: "INVALID"},
+			{PC: 4, Op: "ADD"},
+			{PC: 5, Op: "JUMPDEST"},
+			{PC: 6, Op: "STOP"},
+		},
+		Metrics: jsonMetrics{
+			Size:         7,
+			Instructions: 6,
+			Labels:       2,
+			ExpandPasses: 1,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%T.CompileJSON() diff (-want +got):\n%s", code, diff)
+	}
+}
@@ -0,0 +1,76 @@
+package specops
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/types"
+)
+
+// PUSHLinked returns a placeholder for a PUSH of the address of an external
+// dependency with the name, e.g. a library, which MUST be resolved with
+// Code.Link() before compilation. This allows the same Code to be compiled for
+// different networks, on which the dependency has different addresses, akin to
+// solc library linking.
+//
+// Linked addresses are always pushed with PUSH20, regardless of leading zero
+// bytes, so the layout of the bytecode is the same for all networks; only the
+// 20 bytes of each address differ.
+func PUSHLinked[T ~string](name T) types.Bytecoder {
+	return pushLinked(name)
+}
+
+// A pushLinked is an unresolved PUSHLinked() placeholder.
+type pushLinked string
+
+func (p pushLinked) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("unlinked %s; resolve with %T.Link()", bytecoderString(p), Code{})
+}
+
+// A linkedPush is a PUSHLinked() placeholder resolved by Code.Link().
+type linkedPush struct {
+	name string
+	addr common.Address
+}
+
+func (p linkedPush) Bytecode() ([]byte, error) {
+	return append([]byte{byte(vm.PUSH20)}, p.addr[:]...), nil
+}
+
+// Link returns a flattened equivalent of the Code with every PUSHLinked()
+// placeholder resolved to the address with the same name. An error is returned
+// if any name is missing from addrs, which MAY contain names that aren't used.
+// The receiver is not modified.
+func (c Code) Link(addrs map[string]common.Address) (Code, error) {
+	flat := c.flatten()
+	out := make(Code, len(flat))
+	missing := make(map[string]bool)
+
+	for i, bc := range flat {
+		p, ok := bc.(pushLinked)
+		if !ok {
+			out[i] = bc
+			continue
+		}
+		addr, ok := addrs[string(p)]
+		if !ok {
+			missing[string(p)] = true
+			continue
+		}
+		out[i] = linkedPush{name: string(p), addr: addr}
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for n := range missing {
+			names = append(names, fmt.Sprintf("%q", n))
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("no address for linked name(s) %s", strings.Join(names, ", "))
+	}
+	return out, nil
+}
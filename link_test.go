@@ -0,0 +1,66 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLink(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, PUSHLinked("Lib")),
+		Fn(RETURN, PUSH(12), PUSH(20)),
+	}
+
+	if _, err := code.Compile(); err == nil || !strings.Contains(err.Error(), `unlinked PUSHLinked("Lib")`) {
+		t.Errorf("%T.Compile() without Link() got err %v; want unlinked", code, err)
+	}
+
+	networks := []common.Address{
+		common.HexToAddress("0xbebebebebebebebebebebebebebebebebebebebe"),
+		{19: 1}, // leading zeroes MUST NOT change the layout
+	}
+	var sizes []int
+	for _, addr := range networks {
+		linked, err := code.Link(map[string]common.Address{
+			"Lib":    addr,
+			"Unused": {},
+		})
+		if err != nil {
+			t.Fatalf("%T.Link() error %v", code, err)
+		}
+
+		compiled, err := linked.Compile()
+		if err != nil {
+			t.Fatalf("%T.Link(%v).Compile() error %v", code, addr, err)
+		}
+		sizes = append(sizes, len(compiled))
+
+		want := append([]byte{byte(vm.PUSH20)}, addr[:]...)
+		if diff := cmp.Diff(want, compiled[:len(want)]); diff != "" {
+			t.Errorf("%T.Link(%v).Compile() prefix diff (-want +got):\n%s", code, addr, diff)
+		}
+
+		res, err := linked.Run(nil)
+		if err != nil {
+			t.Fatalf("%T.Link(%v).Run() error %v", code, addr, err)
+		}
+		if got := common.BytesToAddress(res.ReturnData); got != addr {
+			t.Errorf("%T.Link(%v).Run() returned %v", code, addr, got)
+		}
+	}
+	if sizes[0] != sizes[1] {
+		t.Errorf("%T.Link() compiled sizes %v differ between addresses", code, sizes)
+	}
+}
+
+func TestLinkMissing(t *testing.T) {
+	code := Code{PUSHLinked("B"), PUSHLinked("A"), PUSHLinked("B"), PUSHLinked("C")}
+	_, err := code.Link(map[string]common.Address{"C": {}})
+	if want := `no address for linked name(s) "A", "B"`; err == nil || err.Error() != want {
+		t.Errorf("%T.Link() got err %v; want %q", code, err, want)
+	}
+}
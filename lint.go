@@ -0,0 +1,145 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// A Diagnostic describes a likely mistake found by Code.Lint(). All
+// Diagnostics are warnings; they don't prevent compilation.
+type Diagnostic struct {
+	PC      int    // Offset of the offending opcode in the compiled bytecode
+	Rule    string // Name of the rule that produced the Diagnostic
+	Message string
+}
+
+// String returns a human-readable representation of the Diagnostic.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("pc %d: [%s] %s", d.PC, d.Rule, d.Message)
+}
+
+// Lint compiles the Code and inspects the result for patterns that are almost
+// always mistakes, returning a Diagnostic for each. A nil slice is returned if
+// no problems are found. Lint only returns an error if compilation fails.
+//
+// Values on the stack are tracked only where they are pushed as constants
+// within a linear run of code; the tracking is reset at every JUMPDEST.
+func (c Code) Lint() ([]Diagnostic, error) {
+	compiled, err := c.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return lint(disassemble(compiled)), nil
+}
+
+// A lintRule inspects a single instruction, along with the abstract stack
+// immediately before its execution, returning a non-empty message if the
+// instruction is problematic.
+type lintRule struct {
+	name  string
+	check func(instruction, *abstractStack) string
+}
+
+var lintRules = []lintRule{
+	{
+		name:  "mstore8-truncation",
+		check: checkMSTORE8Truncation,
+	},
+}
+
+func lint(instrs []instruction) []Diagnostic {
+	var (
+		diags []Diagnostic
+		stack abstractStack
+	)
+	for _, in := range instrs {
+		for _, r := range lintRules {
+			if msg := r.check(in, &stack); msg != "" {
+				diags = append(diags, Diagnostic{
+					PC:      in.pc,
+					Rule:    r.name,
+					Message: msg,
+				})
+			}
+		}
+		stack.apply(in)
+	}
+	return diags
+}
+
+// checkMSTORE8Truncation flags an MSTORE8 of a constant that doesn't fit in a
+// single byte, as all but the least-significant byte are discarded.
+func checkMSTORE8Truncation(in instruction, s *abstractStack) string {
+	if in.op != vm.MSTORE8 {
+		return ""
+	}
+	v := s.peek(1)
+	if v == nil || v.LtUint64(256) {
+		return ""
+	}
+	return fmt.Sprintf("value %#x will be truncated to %#x; MSTORE8 only writes the least-significant byte", v, v.Uint64()&0xff)
+}
+
+// An abstractStack tracks the values on the EVM stack, where known. A nil
+// entry is a value that can't be determined without execution.
+type abstractStack struct {
+	vals []*uint256.Int // top of the stack is the last element
+}
+
+// peek returns the nth value from the top of the stack (0-indexed), or nil if
+// it is unknown.
+func (s *abstractStack) peek(n int) *uint256.Int {
+	i := len(s.vals) - 1 - n
+	if i < 0 {
+		return nil
+	}
+	return s.vals[i]
+}
+
+// pop removes n values from the top of the stack. Popping beyond the known
+// values is equivalent to popping unknown ones.
+func (s *abstractStack) pop(n int) {
+	if n > len(s.vals) {
+		n = len(s.vals)
+	}
+	s.vals = s.vals[:len(s.vals)-n]
+}
+
+// apply updates the stack to reflect execution of the instruction.
+func (s *abstractStack) apply(in instruction) {
+	op := in.op
+
+	switch {
+	case op == vm.JUMPDEST:
+		// Reachable from elsewhere so nothing is known.
+		s.vals = nil
+
+	case op.IsPush():
+		s.vals = append(s.vals, new(uint256.Int).SetBytes(in.data))
+
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		s.vals = append(s.vals, s.peek(int(op-vm.DUP1)))
+
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		n := int(op-vm.SWAP1) + 1
+		for len(s.vals) <= n {
+			// Values beyond those known are unknown, not absent.
+			s.vals = append([]*uint256.Int{nil}, s.vals...)
+		}
+		i, j := len(s.vals)-1, len(s.vals)-1-n
+		s.vals[i], s.vals[j] = s.vals[j], s.vals[i]
+
+	default:
+		d, ok := stackDeltas[op]
+		if !ok {
+			s.vals = nil
+			return
+		}
+		s.pop(int(d.pop))
+		for i := uint(0); i < d.push; i++ {
+			s.vals = append(s.vals, nil)
+		}
+	}
+}
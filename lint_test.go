@@ -0,0 +1,84 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestLintMSTORE8Truncation(t *testing.T) {
+	const rule = "mstore8-truncation"
+
+	tests := []struct {
+		name string
+		code Code
+		want []Diagnostic
+	}{
+		{
+			name: "single byte",
+			code: Code{Fn(MSTORE8, PUSH0, PUSH(0xff))},
+		},
+		{
+			name: "zero",
+			code: Code{Fn(MSTORE8, PUSH0, PUSH0)},
+		},
+		{
+			name: "two bytes",
+			code: Code{Fn(MSTORE8, PUSH0, PUSH(0x1234))},
+			want: []Diagnostic{{
+				PC:      4, // PUSH2 0x1234 PUSH0 MSTORE8
+				Rule:    rule,
+				Message: "value 0x1234 will be truncated to 0x34; MSTORE8 only writes the least-significant byte",
+			}},
+		},
+		{
+			name: "only one non-zero byte",
+			code: Code{Fn(MSTORE8, PUSH0, PUSH(0x0100))},
+			want: []Diagnostic{{
+				PC:      4,
+				Rule:    rule,
+				Message: "value 0x100 will be truncated to 0x0; MSTORE8 only writes the least-significant byte",
+			}},
+		},
+		{
+			name: "via DUP and SWAP",
+			code: Code{
+				PUSH(0x1234), PUSH(1), // [0x1234, 1]
+				DUP2, SWAP1, POP, // [0x1234, 0x1234]
+				PUSH0, SWAP1, POP, // [0x1234, 0]
+				MSTORE8,
+			},
+			want: []Diagnostic{{
+				PC:      11,
+				Rule:    rule,
+				Message: "value 0x1234 will be truncated to 0x34; MSTORE8 only writes the least-significant byte",
+			}},
+		},
+		{
+			name: "unknown value",
+			code: Code{Fn(MSTORE8, PUSH0, CALLVALUE)},
+		},
+		{
+			name: "reset at JUMPDEST",
+			code: Code{
+				PUSH(0x1234),
+				JUMPDEST("x"), stack.SetDepth(1),
+				Fn(MSTORE8, PUSH0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Lint()
+			if err != nil {
+				t.Fatalf("%T.Lint() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.Lint() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
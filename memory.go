@@ -0,0 +1,97 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/arr4n/specops/types"
+)
+
+// A Memory is a map of named, non-overlapping memory regions, laid out
+// contiguously from offset zero in the order in which they are added. It
+// replaces magic offsets in MSTORE, MLOAD, etc. with symbolic references:
+//
+//	mem := NewMemory().Region("selector", 32).Region("args", 64)
+//	Code{
+//		Fn(MSTORE, mem.At("args"), CALLVALUE),
+//		Fn(RETURN, mem.At("args"), mem.SizeOf("args")),
+//	}
+//
+// The methods that return Bytecoders panic if the name is unknown, in the same
+// way that PUSH() panics on invalid arguments.
+type Memory struct {
+	regions map[string]memRegion
+	size    uint64
+}
+
+type memRegion struct {
+	offset, size uint64
+}
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{regions: make(map[string]memRegion)}
+}
+
+// Region allocates size bytes, immediately after the previously added region,
+// and returns m to allow for chaining. It panics if the name is already in use
+// or if size is zero.
+func (m *Memory) Region(name string, size uint64) *Memory {
+	if _, ok := m.regions[name]; ok {
+		panic(fmt.Sprintf("duplicate %T region %q", m, name))
+	}
+	if size == 0 {
+		panic(fmt.Sprintf("%T region %q with zero size", m, name))
+	}
+	m.regions[name] = memRegion{offset: m.size, size: size}
+	m.size += size
+	return m
+}
+
+// Words is equivalent to Region(name, 32*n).
+func (m *Memory) Words(name string, n uint64) *Memory {
+	return m.Region(name, 32*n)
+}
+
+func (m *Memory) region(name string) memRegion {
+	r, ok := m.regions[name]
+	if !ok {
+		panic(fmt.Sprintf("unknown %T region %q", m, name))
+	}
+	return r
+}
+
+// Offset returns the offset of the named region.
+func (m *Memory) Offset(name string) uint64 {
+	return m.region(name).offset
+}
+
+// Size returns the total number of bytes allocated to all regions.
+func (m *Memory) Size() uint64 {
+	return m.size
+}
+
+// At returns a Bytecoder that PUSHes the offset of the named region.
+func (m *Memory) At(name string) types.Bytecoder {
+	return PUSH(m.Offset(name))
+}
+
+// SizeOf returns a Bytecoder that PUSHes the size of the named region.
+func (m *Memory) SizeOf(name string) types.Bytecoder {
+	return PUSH(m.region(name).size)
+}
+
+// End returns a Bytecoder that PUSHes the offset immediately after the named
+// region.
+func (m *Memory) End(name string) types.Bytecoder {
+	r := m.region(name)
+	return PUSH(r.offset + r.size)
+}
+
+// ReturnMemory returns Fn(RETURN, offset, size). RETURN reads directly from
+// memory so a buffer SHOULD be returned in place rather than first being
+// copied to offset zero; if a copy is unavoidable (e.g. to concatenate
+// regions), MCOPY is cheaper than an MLOAD/MSTORE loop, even for a single
+// word. See BenchmarkMemoryMove for a comparison of gas costs.
+func ReturnMemory(offset, size types.Bytecoder) types.BytecodeHolder {
+	return Fn(RETURN, offset, size)
+}
@@ -0,0 +1,150 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/arr4n/specops/runopts"
+	"github.com/arr4n/specops/stack"
+)
+
+func TestMemory(t *testing.T) {
+	mem := NewMemory().
+		Region("pad", 3).
+		Words("a", 1).
+		Region("b", 5)
+
+	if got, want := mem.Offset("a"), uint64(3); got != want {
+		t.Errorf(`%T.Offset("a") = %d; want %d`, mem, got, want)
+	}
+	if got, want := mem.Offset("b"), uint64(35); got != want {
+		t.Errorf(`%T.Offset("b") = %d; want %d`, mem, got, want)
+	}
+	if got, want := mem.Size(), uint64(40); got != want {
+		t.Errorf("%T.Size() = %d; want %d", mem, got, want)
+	}
+
+	code := Code{
+		Fn(MSTORE, mem.At("a"), MaxUint256),
+		// The word ends where "b" does, so its 5 least-significant bytes fill
+		// "b" exactly.
+		Fn(MSTORE, Fn(SUB, mem.End("b"), PUSH(32)), PUSH(0x0102030405)),
+		Fn(RETURN, mem.At("b"), mem.SizeOf("b")),
+	}
+	got, err := code.Run(nil)
+	if err != nil {
+		t.Fatalf("%T.Run() error %v", code, err)
+	}
+	if want := []byte{1, 2, 3, 4, 5}; !bytes.Equal(got.ReturnData, want) {
+		t.Errorf("%T.Run() returned %#x; want %#x", code, got.ReturnData, want)
+	}
+}
+
+func TestMemoryPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{
+			name: "duplicate region",
+			fn:   func() { NewMemory().Region("x", 1).Region("x", 1) },
+		},
+		{
+			name: "zero size",
+			fn:   func() { NewMemory().Region("x", 0) },
+		},
+		{
+			name: "unknown region",
+			fn:   func() { NewMemory().Region("x", 1).At("y") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestReturnMemory(t *testing.T) {
+	mem := NewMemory().Words("pad", 1).Region("out", 3)
+	code := Code{
+		Fn(MSTORE, mem.At("pad"), MaxUint256),
+		// Only the first 3 bytes of the word are in "out"
+		Fn(MSTORE, mem.At("out"), PUSH(common.Hash{0x0a, 0x0b, 0x0c, 0xff})),
+		ReturnMemory(mem.At("out"), mem.SizeOf("out")),
+	}
+	res, err := code.Run(nil)
+	if err != nil {
+		t.Fatalf("%T.Run() error %v", code, err)
+	}
+	if got, want := res.ReturnData, []byte{0x0a, 0x0b, 0x0c}; !bytes.Equal(got, want) {
+		t.Errorf("%T.Run() got %#x; want %#x", code, got, want)
+	}
+}
+
+// BenchmarkMemoryMove compares the gas cost of moving memory with MCOPY
+// against an MLOAD/MSTORE loop, reported as the gas/op metric. The gas
+// includes that used to populate the source from call data and to return the
+// destination, which is the same for both.
+func BenchmarkMemoryMove(b *testing.B) {
+	for _, size := range []int{32, 256, 1024, 4096} {
+		src, dst := PUSH0, PUSH(size)
+
+		impls := []struct {
+			name string
+			move Code
+		}{
+			{
+				name: "MCOPY",
+				move: Code{Fn(MCOPY, dst, src, PUSH(size))},
+			},
+			{
+				name: "loop",
+				move: Code{
+					PUSH0, // i
+					JUMPDEST("move"), stack.SetDepth(1),
+					Fn(MSTORE, Fn(ADD, DUP3, dst), Fn(MLOAD, Fn(ADD, DUP2, src))),
+					Fn(ADD, PUSH(32)),
+					Fn(JUMPI, PUSH("move"), Fn(LT, DUP2, PUSH(size))),
+					POP,
+				},
+			},
+		}
+
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("%s/%d", impl.name, size), func(b *testing.B) {
+				code := Code{
+					Fn(CALLDATACOPY, src, PUSH0, PUSH(size)),
+					impl.move,
+					ReturnMemory(dst, PUSH(size)),
+				}
+				gas := runopts.CaptureGasUsed()
+
+				for i := 0; i < b.N; i++ {
+					res, err := code.Run(data, gas)
+					if err != nil {
+						b.Fatalf("%T.Run() error %v", code, err)
+					}
+					if !bytes.Equal(res.ReturnData, data) {
+						b.Fatalf("%T.Run() got %#x; want %#x", code, res.ReturnData, data)
+					}
+				}
+				b.ReportMetric(float64(gas.Val), "gas/op")
+			})
+		}
+	}
+}
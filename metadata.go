@@ -0,0 +1,97 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Metadata describes compiled bytecode in terms of the Code from which it was
+// compiled.
+type Metadata struct {
+	// Labels maps the name of every JUMPDEST and Label to its byte offset.
+	Labels map[string]int
+	// PushSites describes every PUSH of JUMPDEST / Label offsets (including
+	// PUSHSize()), in the order in which they appear in the bytecode.
+	PushSites []PushSite
+}
+
+// A PushSite describes a PUSH<N> of label offsets, the width of which can only
+// be determined during compilation. The least number of bytes is used to
+// represent each offset, growing from 1 to 2 as later code is found to be
+// beyond the 256th byte. See Metadata.PushSites.
+type PushSite struct {
+	PC     int       // Offset of the PUSH<N> opcode
+	Op     vm.OpCode // PUSH0 to PUSH32, after stripping of leading zeroes
+	Labels []string  // In the order pushed; for PUSHSize(), the two ends
+	Size   bool      // Whether the pushed value is the PUSHSize() of Labels
+	// Width is the number of bytes (1 or 2) used for each label offset (or
+	// the size), before stripping of leading zeroes.
+	Width int
+}
+
+// CompileWithMetadata is equivalent to Compile() but additionally returns
+// metadata describing the compiled bytecode.
+func (c Code) CompileWithMetadata() ([]byte, *Metadata, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+	sites, err := comp.splices.pushSites()
+	if err != nil {
+		return nil, nil, err
+	}
+	return comp.bytecode, &Metadata{
+		Labels:    comp.labels(),
+		PushSites: sites,
+	}, nil
+}
+
+// pushSites returns a PushSite for every pushTag, pushTags, and pushSize. It
+// MUST NOT be called before s.reserve() nor s.expand().
+func (s *spliceConcat) pushSites() ([]PushSite, error) {
+	var (
+		sites []PushSite
+		pc    int
+	)
+	for _, sp := range s.splices {
+		pc += sp.buf.Len()
+
+		site := PushSite{
+			PC: pc,
+			Op: vm.PUSH0 + vm.OpCode(sp.extraBytesNeeded()-1),
+		}
+		switch op := sp.op.(type) {
+		case pushTag:
+			site.Labels = []string{string(op)}
+			site.Width = sp.bytesPerTag()
+
+		case pushWide:
+			site.Labels = []string{string(op)}
+			site.Width = sp.bytesPerTag()
+
+		case pushTags:
+			site.Labels = make([]string, len(op))
+			for i, t := range op {
+				site.Labels[i] = string(t)
+			}
+			site.Width = sp.bytesPerTag()
+
+		case pushSize:
+			site.Labels = []string{string(op[0]), string(op[1])}
+			site.Size = true
+			site.Width = sp.bytesForSize()
+
+		case tagged, nil, rjump, rjumpi, rjumpv:
+			pc += sp.extraBytesNeeded()
+			continue
+
+		default:
+			return nil, fmt.Errorf("BUG: %T.pushSites() encountered %T.op of unsupported type %T", s, sp, op)
+		}
+
+		sites = append(sites, site)
+		pc += sp.extraBytesNeeded()
+	}
+	return sites, nil
+}
@@ -0,0 +1,66 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestCompileWithMetadata(t *testing.T) {
+	code := Code{
+		Label("start"),
+		PUSH([]string{"start", "near"}), // leading zero stripped
+		PUSH("near"),
+		PUSH("far"),
+		PUSHSize("near", "far"),
+		JUMPDEST("near"), stack.SetDepth(0),
+		make(Raw, 300),
+		JUMPDEST("far"), stack.SetDepth(0),
+	}
+
+	_, got, err := code.CompileWithMetadata()
+	if err != nil {
+		t.Fatalf("%T.CompileWithMetadata() error %v", code, err)
+	}
+
+	want := &Metadata{
+		Labels: map[string]int{
+			"start": 0,
+			"near":  10,
+			"far":   311,
+		},
+		PushSites: []PushSite{
+			{
+				PC:     0,
+				Op:     vm.PUSH1,
+				Labels: []string{"start", "near"},
+				Width:  1,
+			},
+			{
+				PC:     2,
+				Op:     vm.PUSH1,
+				Labels: []string{"near"},
+				Width:  1,
+			},
+			{
+				PC:     4,
+				Op:     vm.PUSH2,
+				Labels: []string{"far"},
+				Width:  2,
+			},
+			{
+				PC:     7,
+				Op:     vm.PUSH2,
+				Labels: []string{"near", "far"},
+				Size:   true,
+				Width:  2,
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%T.CompileWithMetadata() diff (-want +got):\n%s", code, diff)
+	}
+}
@@ -0,0 +1,96 @@
+package specops
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/evmdebug"
+	"github.com/arr4n/specops/runopts"
+)
+
+// An OutcomeKind describes how execution of the outermost frame ended.
+type OutcomeKind int
+
+// Kinds of Outcome.
+const (
+	// Stopped is execution that halted without returning data; i.e. by STOP,
+	// by running past the end of the code, or by SELFDESTRUCT.
+	Stopped OutcomeKind = iota + 1
+	// Returned is execution that halted by RETURN, possibly with empty data.
+	Returned
+	// Reverted is execution that halted by REVERT, possibly with empty data.
+	Reverted
+	// Faulted is execution that halted with an exceptional error, e.g. an
+	// INVALID opcode, invalid jump, stack underflow, or exhaustion of gas.
+	Faulted
+)
+
+// String returns the name of the constant.
+func (k OutcomeKind) String() string {
+	switch k {
+	case Stopped:
+		return "Stopped"
+	case Returned:
+		return "Returned"
+	case Reverted:
+		return "Reverted"
+	case Faulted:
+		return "Faulted"
+	default:
+		return fmt.Sprintf("OutcomeKind(%d)", int(k))
+	}
+}
+
+// An Outcome is returned by Code.RunOutcome(), describing the result of
+// execution in terms of how it ended instead of as a [core.ExecutionResult].
+type Outcome struct {
+	Kind OutcomeKind
+	// Data are those returned by RETURN or REVERT; empty for other kinds.
+	Data []byte
+	// Err is the EVM error for Reverted and Faulted kinds; nil otherwise.
+	Err     error
+	GasUsed uint64
+	// Result is the underlying result from which the Outcome was derived.
+	Result *core.ExecutionResult
+}
+
+// RunOutcome is equivalent to Run() except that it returns an Outcome
+// describing how execution ended. Reverting and faulting are reflected in the
+// Outcome and are therefore not errors; the returned error is only non-nil if
+// the Code can't be compiled or run (e.g. invalid Options).
+func (c Code) RunOutcome(callData []byte, opts ...runopts.Option) (*Outcome, error) {
+	var last vm.OpCode
+	lastOp := runopts.OnStep(func(s *evmdebug.CapturedState) {
+		if s.Depth == 1 {
+			last = s.Op
+		}
+	})
+
+	opts = append(opts[:len(opts):len(opts)], lastOp, runopts.NoErrorOnRevert())
+	res, err := c.Run(callData, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Outcome{
+		Err:     res.Err,
+		GasUsed: res.UsedGas,
+		Result:  res,
+	}
+	switch {
+	case errors.Is(res.Err, vm.ErrExecutionReverted):
+		out.Kind = Reverted
+		out.Data = res.Revert()
+	case res.Err != nil:
+		out.Kind = Faulted
+	case last == vm.RETURN:
+		out.Kind = Returned
+		out.Data = res.Return()
+	default:
+		out.Kind = Stopped
+	}
+	return out, nil
+}
@@ -0,0 +1,138 @@
+package specops
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/arr4n/specops/runopts"
+)
+
+func TestRunOutcome(t *testing.T) {
+	calleeAddr := common.HexToAddress("0xca11ee")
+	callee, err := Code{Fn(RETURN, PUSH0, PUSH0)}.Compile()
+	if err != nil {
+		t.Fatalf("Compile() callee error %v", err)
+	}
+	alloc := runopts.GenesisAlloc(types.GenesisAlloc{
+		calleeAddr: {Code: callee},
+	})
+
+	tests := []struct {
+		name     string
+		code     Code
+		wantKind OutcomeKind
+		wantData []byte
+		wantErr  error
+	}{
+		{
+			name:     "explicit STOP",
+			code:     Code{STOP, INVALID},
+			wantKind: Stopped,
+		},
+		{
+			name:     "end of code",
+			code:     Code{PUSH0, POP},
+			wantKind: Stopped,
+		},
+		{
+			name:     "empty code",
+			wantKind: Stopped,
+		},
+		{
+			name: "RETURN",
+			code: Code{
+				Fn(MSTORE8, PUSH0, PUSH(42)),
+				Fn(RETURN, PUSH0, PUSH(1)),
+			},
+			wantKind: Returned,
+			wantData: []byte{42},
+		},
+		{
+			name:     "empty RETURN",
+			code:     Code{Fn(RETURN, PUSH0, PUSH0)},
+			wantKind: Returned,
+		},
+		{
+			name: "RETURN from subcall then STOP",
+			code: Code{
+				Fn(CALL, PUSH(0xffff), PUSH(calleeAddr), PUSH0, PUSH0, PUSH0, PUSH0, PUSH0),
+				STOP,
+			},
+			wantKind: Stopped,
+		},
+		{
+			name: "REVERT",
+			code: Code{
+				Fn(MSTORE8, PUSH0, PUSH(0xff)),
+				Fn(REVERT, PUSH0, PUSH(1)),
+			},
+			wantKind: Reverted,
+			wantData: []byte{0xff},
+			wantErr:  vm.ErrExecutionReverted,
+		},
+		{
+			name:     "INVALID",
+			code:     Code{INVALID},
+			wantKind: Faulted,
+			wantErr:  &vm.ErrInvalidOpCode{},
+		},
+		{
+			name:     "stack underflow",
+			code:     Code{Raw{byte(vm.POP)}},
+			wantKind: Faulted,
+			wantErr:  &vm.ErrStackUnderflow{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.RunOutcome(nil, alloc)
+			if err != nil {
+				t.Fatalf("%T.RunOutcome() error %v", tt.code, err)
+			}
+			if got.Kind != tt.wantKind {
+				t.Errorf("%T.RunOutcome().Kind = %v; want %v", tt.code, got.Kind, tt.wantKind)
+			}
+			if diff := cmp.Diff(tt.wantData, got.Data, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%T.RunOutcome().Data diff (-want +got):\n%s", tt.code, diff)
+			}
+			if got, want := got.Err, tt.wantErr; (got == nil) != (want == nil) || (want != nil && fmt.Sprintf("%T", got) != fmt.Sprintf("%T", want)) {
+				t.Errorf("%T.RunOutcome().Err = %v (%[2]T); want %v (%[3]T)", tt.code, got, want)
+			}
+			if got.GasUsed == 0 {
+				t.Errorf("%T.RunOutcome().GasUsed = 0; want non-zero (intrinsic gas)", tt.code)
+			}
+		})
+	}
+
+	t.Run("options error", func(t *testing.T) {
+		code := Code{STOP}
+		opt := runopts.Func(func(*runopts.Configuration) error {
+			return errors.New("uh oh")
+		})
+		if _, err := code.RunOutcome(nil, opt); err == nil {
+			t.Errorf("%T.RunOutcome(<erroring Option>) got nil error", code)
+		}
+	})
+}
+
+func TestOutcomeKindString(t *testing.T) {
+	for k, want := range map[OutcomeKind]string{
+		Stopped:  "Stopped",
+		Returned: "Returned",
+		Reverted: "Reverted",
+		Faulted:  "Faulted",
+		0:        "OutcomeKind(0)",
+	} {
+		if got := k.String(); got != want {
+			t.Errorf("OutcomeKind(%d).String() = %q; want %q", int(k), got, want)
+		}
+	}
+}
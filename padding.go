@@ -0,0 +1,89 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// CompilePadded compiles the Code and pads the output to exactly `length`
+// bytes with the `fill` byte, returning an error if the compiled code is
+// already too long. This is useful for deployments requiring a deterministic
+// code length, e.g. mining for a vanity hash of the runtime code.
+//
+// The padding is guaranteed to never be executed. If the code doesn't already
+// end with a terminating opcode (STOP, RETURN, REVERT, INVALID, SELFDESTRUCT,
+// or JUMP) then a STOP is appended before the padding, and counts towards
+// `length`. An error is returned if `fill` is the JUMPDEST opcode, as the
+// padding could then be JUMPed to.
+func (c Code) CompilePadded(length int, fill byte) ([]byte, error) {
+	if vm.OpCode(fill) == vm.JUMPDEST {
+		return nil, fmt.Errorf("padding with %v; would be a valid JUMP destination", vm.JUMPDEST)
+	}
+	code, err := c.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	term := terminated(code)
+	if n := len(term); n > length {
+		return nil, fmt.Errorf("compiled code length %d exceeds padded length %d", n, length)
+	}
+	return append(term, bytes.Repeat([]byte{fill}, length-len(term))...), nil
+}
+
+// CompileMinSize compiles the Code and, if the output is shorter than n bytes,
+// pads it to exactly n bytes with STOPs. As with CompilePadded(), the padding
+// is guaranteed to never be executed, and a STOP is appended before it if
+// necessary. Code that already compiles to at least n bytes is returned
+// unchanged.
+func (c Code) CompileMinSize(n int) ([]byte, error) {
+	code, err := c.Compile()
+	if err != nil {
+		return nil, err
+	}
+	if len(code) >= n {
+		return code, nil
+	}
+	term := terminated(code)
+	return append(term, make([]byte, max(n-len(term), 0))...), nil
+}
+
+// terminated returns the code, with a STOP appended if necessary, such that
+// any bytes appended to the returned slice will never be executed, provided
+// that they don't include a JUMPDEST.
+func terminated(code []byte) []byte {
+	instrs := disassemble(code)
+	if len(instrs) == 0 {
+		// Empty code implicitly STOPs, but anything appended to it would be
+		// executed.
+		return []byte{byte(vm.STOP)}
+	}
+
+	last := instrs[len(instrs)-1]
+	switch {
+	case last.truncated:
+		// The missing PUSH<N> bytes, which will be treated as zero, then a
+		// STOP.
+		missing := int(last.op-vm.PUSH0) - len(last.data)
+		return append(code, make([]byte, missing+1)...)
+
+	case isTerminal(last.op):
+		return code
+
+	default:
+		return append(code, byte(vm.STOP))
+	}
+}
+
+// isTerminal returns whether execution never continues to the next opcode
+// after op.
+func isTerminal(op vm.OpCode) bool {
+	switch op {
+	case vm.STOP, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT, vm.JUMP:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,142 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompilePadded(t *testing.T) {
+	const fill = 0xfe
+
+	tests := []struct {
+		name    string
+		code    Code
+		length  int
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			code:   Code{},
+			length: 3,
+			want:   []byte{byte(vm.STOP), fill, fill},
+		},
+		{
+			name:   "already terminated",
+			code:   Code{Fn(RETURN, PUSH0, PUSH0)},
+			length: 5,
+			want:   []byte{byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.RETURN), fill, fill},
+		},
+		{
+			name:   "exact length without padding",
+			code:   Code{Fn(RETURN, PUSH0, PUSH0)},
+			length: 3,
+			want:   []byte{byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.RETURN)},
+		},
+		{
+			name:   "STOP added",
+			code:   Code{Fn(MSTORE, PUSH0, PUSH(1))},
+			length: 6,
+			want:   []byte{byte(vm.PUSH1), 1, byte(vm.PUSH0), byte(vm.MSTORE), byte(vm.STOP), fill},
+		},
+		{
+			name:   "truncated PUSH completed",
+			code:   Code{Raw{byte(vm.PUSH2), 1}},
+			length: 5,
+			want:   []byte{byte(vm.PUSH2), 1, 0, byte(vm.STOP), fill},
+		},
+		{
+			name:    "too long",
+			code:    Code{Fn(RETURN, PUSH0, PUSH0)},
+			length:  2,
+			wantErr: true,
+		},
+		{
+			name:    "too long with STOP",
+			code:    Code{PUSH0, PUSH0},
+			length:  2,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.CompilePadded(tt.length, fill)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("%T.CompilePadded(%d, %#x) got err %v; want err %t", tt.code, tt.length, fill, err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.CompilePadded(%d, %#x) diff (-want +got):\n%s", tt.code, tt.length, fill, diff)
+			}
+		})
+	}
+
+	t.Run("JUMPDEST fill", func(t *testing.T) {
+		code := Code{STOP}
+		if _, err := code.CompilePadded(4, byte(vm.JUMPDEST)); err == nil {
+			t.Errorf("%T.CompilePadded(4, JUMPDEST) got nil error", code)
+		}
+	})
+}
+
+func TestCompileMinSize(t *testing.T) {
+	stop := byte(vm.STOP)
+
+	tests := []struct {
+		name string
+		code Code
+		n    int
+		want []byte
+	}{
+		{
+			name: "empty",
+			code: Code{},
+			n:    2,
+			want: []byte{stop, stop},
+		},
+		{
+			name: "already terminated",
+			code: Code{Fn(RETURN, PUSH0, PUSH0)},
+			n:    5,
+			want: []byte{byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.RETURN), stop, stop},
+		},
+		{
+			name: "STOP added exceeds minimum",
+			code: Code{PUSH0, PUSH0},
+			n:    3,
+			want: []byte{byte(vm.PUSH0), byte(vm.PUSH0), stop},
+		},
+		{
+			name: "truncated PUSH completed",
+			code: Code{Raw{byte(vm.PUSH2), 1}},
+			n:    3,
+			want: []byte{byte(vm.PUSH2), 1, 0, stop},
+		},
+		{
+			name: "already long enough",
+			code: Code{PUSH0, PUSH0},
+			n:    2,
+			want: []byte{byte(vm.PUSH0), byte(vm.PUSH0)},
+		},
+		{
+			name: "longer than minimum",
+			code: Code{Fn(MSTORE, PUSH0, PUSH(1))},
+			n:    1,
+			want: []byte{byte(vm.PUSH1), 1, byte(vm.PUSH0), byte(vm.MSTORE)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.CompileMinSize(tt.n)
+			if err != nil {
+				t.Fatalf("%T.CompileMinSize(%d) error %v", tt.code, tt.n, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.CompileMinSize(%d) diff (-want +got):\n%s", tt.code, tt.n, diff)
+			}
+		})
+	}
+}
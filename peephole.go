@@ -0,0 +1,107 @@
+package specops
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// Optimized returns a flattened equivalent of the Code with obviously wasteful
+// sequences removed by a peephole optimiser. Only adjacent Bytecoders are
+// considered, so any intervening hint or Label (e.g. stack.Name()) inhibits
+// optimisation. Specifically:
+//
+//   - A Fn() of a pure opcode (e.g. ADD, SHL, or AND), all of the arguments of
+//     which are constant PUSHes, is folded into a single PUSH of the result,
+//     after first folding any nested Fn()s;
+//   - A PUSH or DUP immediately followed by POP is removed;
+//   - Identical, adjacent SWAPs are removed;
+//   - A PUSH of a JUMPDEST, immediately followed by JUMP and then the JUMPDEST
+//     itself, is reduced to only the JUMPDEST;
+//   - The equivalent with JUMPI is reduced to POP (of the condition) followed by
+//     the JUMPDEST; and
+//   - Code following a halting opcode or unconditional JUMP, up to the next
+//     JUMPDEST or the end of the Code, is removed (see Code.CheckDeadCode()),
+//     along with stack assertions in the same span. Raw is assumed to be data
+//     so is retained, as is dead code followed by a Label, which depends on
+//     the code's effect on the stack.
+//
+// Reductions are applied repeatedly so, for example, `PUSH0 DUP1 SWAP1 SWAP1
+// POP POP` is removed entirely. Optimisation is performed before locations of
+// JUMPDESTs and Labels are determined, so any offsets computed by hand (i.e.
+// not with PUSH(label) or PUSHSize()) may be invalidated. The receiver is not
+// modified.
+func (c Code) Optimized() Code {
+	flat := withoutDeadCode(c.foldConstants().flatten())
+	out := make(Code, 0, len(flat))
+
+	var emit func(types.Bytecoder)
+	emit = func(bc types.Bytecoder) {
+		n := len(out)
+		var prev types.Bytecoder
+		if n > 0 {
+			prev = out[n-1]
+		}
+
+		switch {
+		case bc == POP && pushesWithoutEffects(prev):
+			out = out[:n-1]
+			return
+
+		case isPlainSwap(bc) && prev == bc:
+			out = out[:n-1]
+			return
+		}
+
+		if dest, ok := bc.(JUMPDEST); ok && n >= 2 && pushesTag(out[n-2], tag(dest)) {
+			switch prev {
+			case JUMP:
+				out = out[:n-2]
+			case JUMPI:
+				out = out[:n-2]
+				emit(POP)
+			}
+		}
+		out = append(out, bc)
+	}
+
+	for _, bc := range flat {
+		emit(bc)
+	}
+	return out
+}
+
+// pushesWithoutEffects returns whether bc only pushes a single value to the
+// stack, without any other effect.
+func pushesWithoutEffects(bc types.Bytecoder) bool {
+	switch bc := bc.(type) {
+	case types.StackPusher, pushTag, pushTags, pushWide, pushSize, pushLinked, linkedPush, stack.Ref:
+		return true
+	case types.OpCode:
+		op := vm.OpCode(bc)
+		return op == vm.PUSH0 || (op >= vm.DUP1 && op <= vm.DUP16)
+	default:
+		return false
+	}
+}
+
+// isPlainSwap returns whether bc is a SWAP<N> opcode, not modified by
+// Inverted() or similar.
+func isPlainSwap(bc types.Bytecoder) bool {
+	op, ok := bc.(types.OpCode)
+	return ok && vm.OpCode(op) >= vm.SWAP1 && vm.OpCode(op) <= vm.SWAP16
+}
+
+// pushesTag returns whether bc pushes the location of the tag, and nothing
+// else.
+func pushesTag(bc types.Bytecoder, t tag) bool {
+	switch bc := bc.(type) {
+	case pushTag:
+		return tag(bc) == t
+	case pushWide:
+		return tag(bc) == t
+	default:
+		return false
+	}
+}
@@ -0,0 +1,108 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestOptimized(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want Code
+	}{
+		{
+			name: "PUSH POP",
+			code: Code{CALLER, PUSH(42), POP, PUSH0, POP, PUSH("x"), POP, JUMPDEST("x")},
+			want: Code{CALLER, JUMPDEST("x")},
+		},
+		{
+			name: "DUP POP",
+			code: Code{CALLER, DUP1, POP, CALLVALUE, DUP2, POP},
+			want: Code{CALLER, CALLVALUE},
+		},
+		{
+			name: "SWAP SWAP",
+			code: Code{CALLER, CALLVALUE, SWAP1, SWAP1, SWAP1, SWAP2, SWAP2},
+			want: Code{CALLER, CALLVALUE, SWAP1},
+		},
+		{
+			name: "nested",
+			code: Code{PUSH0, DUP1, SWAP1, SWAP1, POP, POP},
+			want: Code{},
+		},
+		{
+			name: "JUMP to next",
+			code: Code{
+				Fn(JUMP, PUSH("next")),
+				JUMPDEST("next"), stack.SetDepth(0),
+				STOP,
+			},
+			want: Code{
+				JUMPDEST("next"), stack.SetDepth(0),
+				STOP,
+			},
+		},
+		{
+			name: "JUMPI to next",
+			code: Code{
+				Fn(JUMPI, PUSHWide("next"), CALLVALUE),
+				JUMPDEST("next"), stack.SetDepth(0),
+			},
+			want: Code{
+				CALLVALUE, POP,
+				JUMPDEST("next"), stack.SetDepth(0),
+			},
+		},
+		{
+			name: "JUMPI to next with removable condition",
+			code: Code{
+				Fn(JUMPI, PUSH("next"), PUSH(1)),
+				JUMPDEST("next"), stack.SetDepth(0),
+			},
+			want: Code{
+				JUMPDEST("next"), stack.SetDepth(0),
+			},
+		},
+		{
+			name: "not adjacent",
+			code: Code{
+				PUSH(1), stack.Name("x"), POP,
+				CALLER, SWAP1, Label("l"), SWAP1,
+				Fn(JUMP, PUSH("elsewhere")),
+				JUMPDEST("next"), stack.SetDepth(0),
+				JUMPDEST("elsewhere"), stack.SetDepth(0),
+			},
+			want: Code{
+				PUSH(1), stack.Name("x"), POP,
+				CALLER, SWAP1, Label("l"), SWAP1,
+				PUSH("elsewhere"), JUMP,
+				JUMPDEST("next"), stack.SetDepth(0),
+				JUMPDEST("elsewhere"), stack.SetDepth(0),
+			},
+		},
+		{
+			name: "effects retained",
+			code: Code{CALLER, CALLVALUE, POP, SWAP1, SWAP2, Inverted(SWAP1), Inverted(SWAP1)},
+			want: Code{CALLER, CALLVALUE, POP, SWAP1, SWAP2, Inverted(SWAP1), Inverted(SWAP1)},
+		},
+		{
+			name: "address",
+			code: Code{PUSH(common.Address{1}), POP},
+			want: Code{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.code.Optimized()
+			if diff := cmp.Diff(tt.want.String(), got.String()); diff != "" {
+				t.Errorf("%T.Optimized() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
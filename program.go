@@ -0,0 +1,84 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/arr4n/specops/stack"
+)
+
+// A Program is the result of compiling multiple sections of Code, e.g. a
+// constructor, runtime, and data, into a single bytecode with a shared label
+// namespace. See CompileProgram().
+type Program struct {
+	bytecode []byte
+	labels   map[string]int
+	bounds   []int // len(sections)+1 offsets, bounding each section
+}
+
+// CompileProgram compiles the sections, in order, into a single bytecode in which
+// every JUMPDEST and Label is visible to all sections; for example, allowing a
+// constructor to CODECOPY and RETURN the runtime with PUSH() and PUSHSize() of
+// the runtime's labels. Each section starts with a stack depth of zero, as
+// sections are typically executed independently of each other.
+//
+// The location of a label is its offset in the entire bytecode, not within its
+// section. A section that is executed from a different location, such as a
+// runtime deployed by a constructor, can therefore only JUMP to its own labels
+// if it is the first section; PUSHSize() is, however, independent of location.
+func CompileProgram(sections ...Code) (*Program, error) {
+	var (
+		all    Code
+		starts = make([]int, len(sections)) // indices in the flattened Code
+	)
+	for i, s := range sections {
+		all = append(all, stack.SetDepth(0))
+		starts[i] = len(all)
+		all = append(all, s.flatten()...)
+	}
+
+	comp, err := all.compile()
+	if err != nil {
+		return nil, fmt.Errorf("CompileProgram(): %v", err)
+	}
+
+	bounds := make([]int, len(sections)+1)
+	for i, idx := range starts {
+		bounds[i] = comp.pcOf(idx)
+	}
+	bounds[len(sections)] = len(comp.bytecode)
+
+	return &Program{
+		bytecode: comp.bytecode,
+		labels:   comp.labels(),
+		bounds:   bounds,
+	}, nil
+}
+
+// Bytecode returns a copy of the compiled bytecode of all sections.
+func (p *Program) Bytecode() []byte {
+	return common.CopyBytes(p.bytecode)
+}
+
+// NumSections returns the number of sections passed to CompileProgram().
+func (p *Program) NumSections() int {
+	return len(p.bounds) - 1
+}
+
+// Section returns a copy of the compiled bytecode of the i-th section, along
+// with its offset in Bytecode(). It panics if i is out of range.
+func (p *Program) Section(i int) ([]byte, int) {
+	start, end := p.bounds[i], p.bounds[i+1]
+	return common.CopyBytes(p.bytecode[start:end]), start
+}
+
+// Labels returns the offset in Bytecode() of every JUMPDEST and Label, keyed by
+// name.
+func (p *Program) Labels() map[string]int {
+	ls := make(map[string]int, len(p.labels))
+	for k, v := range p.labels {
+		ls[k] = v
+	}
+	return ls
+}
@@ -0,0 +1,73 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holiman/uint256"
+)
+
+func TestCompileProgram(t *testing.T) {
+	constructor := Code{
+		Fn(CODECOPY, PUSH0, PUSH("runtime"), PUSHSize("runtime", "end")),
+		Fn(RETURN, PUSH0, PUSHSize("runtime", "end")),
+	}
+	runtime := Code{
+		Label("runtime"),
+		Fn(MSTORE, PUSH0, PUSH(42)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+		Label("end"),
+	}
+	data := Code{
+		Raw{0xde, 0xad, 0xbe, 0xef},
+	}
+
+	p, err := CompileProgram(constructor, runtime, data)
+	if err != nil {
+		t.Fatalf("CompileProgram() error %v", err)
+	}
+	if got, want := p.NumSections(), 3; got != want {
+		t.Fatalf("NumSections() = %d; want %d", got, want)
+	}
+
+	wantRuntime, err := runtime.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() of runtime error %v", runtime, err)
+	}
+	gotRuntime, offset := p.Section(1)
+	if diff := cmp.Diff(wantRuntime, gotRuntime); diff != "" {
+		t.Errorf("Section(1) diff (-want +got):\n%s", diff)
+	}
+	if got, want := p.Labels()["runtime"], offset; got != want {
+		t.Errorf("Labels()[runtime] = %d; want offset of Section(1) = %d", got, want)
+	}
+	if gotData, _ := p.Section(2); !cmp.Equal(gotData, []byte(data[0].(Raw))) {
+		t.Errorf("Section(2) = %#x; want %#x", gotData, data[0])
+	}
+
+	deploy := Code{Raw(p.Bytecode())}
+	res, err := deploy.Run(nil)
+	if err != nil {
+		t.Fatalf("Run(<program bytecode>) error %v", err)
+	}
+	if diff := cmp.Diff(wantRuntime, res.ReturnData); diff != "" {
+		t.Errorf("Run(<program bytecode>) returned runtime diff (-want +got):\n%s", diff)
+	}
+
+	deployed := Code{Raw(res.ReturnData)}
+	res, err = deployed.Run(nil)
+	if err != nil {
+		t.Fatalf("Run(<deployed runtime>) error %v", err)
+	}
+	if got, want := new(uint256.Int).SetBytes(res.ReturnData), uint256.NewInt(42); !got.Eq(want) {
+		t.Errorf("Run(<deployed runtime>) returned %v; want %v", got, want)
+	}
+}
+
+func TestCompileProgramSectionDepths(t *testing.T) {
+	// Each section starts with an empty stack, regardless of the preceding
+	// section's depth.
+	if _, err := CompileProgram(Code{PUSH0}, Code{POP}); err == nil {
+		t.Error("CompileProgram() popping from empty stack in second section; got nil error")
+	}
+}
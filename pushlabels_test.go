@@ -1,6 +1,7 @@
 package specops
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -181,3 +182,104 @@ func asBytes[T opCode](ops ...T) []byte {
 	}
 	return b
 }
+
+func TestPUSHLabelConstructors(t *testing.T) {
+	type name string
+	dests := []name{"a", "b"}
+
+	tags := Code{
+		JUMPDEST("a"), stack.SetDepth(0),
+		make(Raw, 300),
+		JUMPDEST("b"), stack.SetDepth(0),
+	}
+	tests := []struct {
+		got, want types.Bytecoder
+	}{
+		{PUSHLabel(dests[1]), PUSH("b")},
+		{PUSHLabel(Label("a")), PUSH("a")},
+		{PUSHLabels(dests...), PUSH([]string{"a", "b"})},
+		{PUSHLabels(dests[1], dests[0]), PUSH([]string{"b", "a"})},
+		{PUSHLabels[name](), PUSH([]string{})},
+	}
+
+	for _, tt := range tests {
+		got, err := Code{tt.got, tags}.Compile()
+		if err != nil {
+			t.Fatalf("Compile(%s) error %v", bytecoderString(tt.got), err)
+		}
+		want, err := Code{tt.want, tags}.Compile()
+		if err != nil {
+			t.Fatalf("Compile(%s) error %v", bytecoderString(tt.want), err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Compile(%s) got %#x; want %#x as for %s", bytecoderString(tt.got), got, want, bytecoderString(tt.want))
+		}
+	}
+}
+
+func TestPUSHWide(t *testing.T) {
+	t.Run("fixed width", func(t *testing.T) {
+		code := Code{
+			Label("zero"),
+			PUSHWide("zero"),          // 0, 1, 2
+			Fn(JUMP, PUSHWide("end")), // 3, 4, 5, 6
+			JUMPDEST("end"), stack.SetDepth(1),
+		}
+
+		got, err := code.Compile()
+		if err != nil {
+			t.Fatalf("%T.Compile() error %v", code, err)
+		}
+		want := []byte{
+			byte(vm.PUSH2), 0, 0,
+			byte(vm.PUSH2), 0, 7,
+			byte(vm.JUMP),
+			byte(vm.JUMPDEST),
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%T.Compile() got %#x; want %#x", code, got, want)
+		}
+	})
+
+	t.Run("stable offsets", func(t *testing.T) {
+		// With PUSHWide(), the location of "mid" is independent of the amount
+		// of padding, which moves "end" beyond the range of a single byte.
+		for _, pad := range []int{0, 300} {
+			code := Code{
+				Fn(JUMP, PUSHWide("end")),
+				Label("mid"),
+				make(Raw, pad),
+				JUMPDEST("end"), stack.SetDepth(0),
+			}
+			labels, err := code.Labels()
+			if err != nil {
+				t.Fatalf("%T.Labels() error %v", code, err)
+			}
+			if got, want := labels["mid"], 4; got != want {
+				t.Errorf("With %d bytes of padding; Label(mid) at %d; want %d", pad, got, want)
+			}
+			if got, want := labels["end"], 4+pad; got != want {
+				t.Errorf("With %d bytes of padding; JUMPDEST(end) at %d; want %d", pad, got, want)
+			}
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		code := Code{PUSHWide("x"), Label("x")}
+		_, meta, err := code.CompileWithMetadata()
+		if err != nil {
+			t.Fatalf("%T.CompileWithMetadata() error %v", code, err)
+		}
+		want := []PushSite{{PC: 0, Op: vm.PUSH2, Labels: []string{"x"}, Width: 2}}
+		if diff := cmp.Diff(want, meta.PushSites); diff != "" {
+			t.Errorf("%T.CompileWithMetadata() PushSites diff (-want +got):\n%s", code, diff)
+		}
+	})
+
+	t.Run("too far", func(t *testing.T) {
+		code := Code{PUSHWide("far"), make(Raw, 1<<16), Label("far")}
+		if _, err := code.Compile(); err == nil {
+			t.Errorf("%T.Compile() with PUSHWide() of location >= 2^16; got nil error", code)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package specops
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/types"
+)
+
+// EOF (EIP-4200) relative-jump opcodes, which aren't defined by geth.
+const (
+	opRJUMP  = vm.OpCode(0xe0)
+	opRJUMPI = vm.OpCode(0xe1)
+	opRJUMPV = vm.OpCode(0xe2)
+)
+
+// maxRJUMPVLabels is the greatest number of jump-table entries of an RJUMPV,
+// as its max_index immediate is a single byte.
+const maxRJUMPVLabels = 256
+
+// RJUMP returns a Bytecoder that jumps, unconditionally, to the Label or
+// JUMPDEST with the name. As with all relative jumps of EOF (EIP-4200), the
+// destination is encoded as a signed 16-bit offset from the end of the
+// instruction, which is computed by the compiler. Relative jumps are only
+// valid in EOF code (see Code.CompileEOF()), which doesn't require a JUMPDEST
+// so a Label SHOULD be used instead.
+func RJUMP[T ~string](name T) types.Bytecoder {
+	return rjump(name)
+}
+
+// RJUMPI is the conditional equivalent of RJUMP(), jumping i.f.f. the value
+// popped from the top of the stack is non-zero.
+func RJUMPI[T ~string](name T) types.Bytecoder {
+	return rjumpi(name)
+}
+
+// RJUMPV pops a value, i, from the top of the stack and jumps to the location
+// of the i-th name (zero-based), falling through if i is out of range. Between
+// 1 and 256 names MUST be provided.
+func RJUMPV[T ~string](names ...T) types.Bytecoder {
+	ts := make(rjumpv, len(names))
+	for i, n := range names {
+		ts[i] = tag(n)
+	}
+	return ts
+}
+
+type (
+	rjump  tag
+	rjumpi tag
+	rjumpv []tag
+)
+
+func (j rjump) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("direct call to %T.Bytecode()", j)
+}
+
+func (j rjumpi) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("direct call to %T.Bytecode()", j)
+}
+
+func (j rjumpv) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("direct call to %T.Bytecode()", j)
+}
+
+func (rjump) lazy()  {}
+func (rjumpi) lazy() {}
+func (rjumpv) lazy() {}
+
+// asRelJump returns the opcode and destination(s) of a relative jump, and true,
+// or false if l isn't one.
+func asRelJump(l lazyLocator) (vm.OpCode, []tag, bool) {
+	switch j := l.(type) {
+	case rjump:
+		return opRJUMP, []tag{tag(j)}, true
+	case rjumpi:
+		return opRJUMPI, []tag{tag(j)}, true
+	case rjumpv:
+		return opRJUMPV, j, true
+	default:
+		return 0, nil, false
+	}
+}
+
+// relJumpSize returns the number of bytes in the encoding of a relative jump
+// to the number of destinations, including the opcode itself.
+func relJumpSize(op vm.OpCode, dests int) int {
+	if op == opRJUMPV {
+		return 2 + 2*dests // max_index + int16 per destination
+	}
+	return 3
+}
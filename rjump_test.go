@@ -0,0 +1,119 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRelativeJumps(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want []byte
+	}{
+		{
+			name: "RJUMP and RJUMPI",
+			code: Code{
+				Label("top"),
+				PUSH0,
+				RJUMPI("end"),
+				RJUMP("top"),
+				Label("end"),
+				STOP,
+			},
+			want: []byte{
+				byte(PUSH0),
+				byte(opRJUMPI), 0x00, 0x03, // 7 - 4
+				byte(opRJUMP), 0xff, 0xf9, // 0 - 7
+				byte(STOP),
+			},
+		},
+		{
+			name: "RJUMPV",
+			code: Code{
+				PUSH0,
+				RJUMPV("a", "b"),
+				Label("a"),
+				STOP,
+				Label("b"),
+				INVALID,
+			},
+			want: []byte{
+				byte(PUSH0),
+				byte(opRJUMPV), 0x01, 0x00, 0x00, 0x00, 0x01,
+				byte(STOP),
+				byte(INVALID),
+			},
+		},
+		{
+			name: "does not expand with later PUSH of label",
+			code: Code{
+				RJUMP("end"),
+				Raw(make([]byte, 300)),
+				Label("end"),
+				PUSH("end"),
+			},
+			want: append(
+				append([]byte{byte(opRJUMP), 0x01, 0x2c}, make([]byte, 300)...),
+				byte(vm.PUSH2), 0x01, 0x2f,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Compile()
+			if err != nil {
+				t.Fatalf("%T.Compile() error %v", tt.code, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.Compile() diff (-want +got):\n%s", tt.code, diff)
+			}
+		})
+	}
+}
+
+func TestRelativeJumpErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        Code
+		errContains string
+	}{
+		{
+			name:        "missing label",
+			code:        Code{RJUMP("nope")},
+			errContains: "without corresponding",
+		},
+		{
+			name:        "RJUMPV without destinations",
+			code:        Code{PUSH0, RJUMPV[string]()},
+			errContains: "MUST be 1 to 256",
+		},
+		{
+			name:        "RJUMPI with empty stack",
+			code:        Code{Label("x"), RJUMPI("x")},
+			errContains: "stack depth 0",
+		},
+		{
+			name: "offset out of range",
+			code: Code{
+				Label("x"),
+				Raw(make([]byte, 1<<15)),
+				RJUMP("x"),
+			},
+			errContains: "can't be represented with 2 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.code.Compile()
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("%T.Compile() got err %v; want containing %q", tt.code, err, tt.errContains)
+			}
+		})
+	}
+}
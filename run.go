@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
@@ -24,13 +25,52 @@ import (
 // Run returns an error if the code reverts. The error will be a [revert.Error]
 // carrying the same revert error and data as the [core.ExecutionResult]
 // returned by Run. To only return errors in the [core.ExecutionResult], use
-// [runopts.NoErrorOnRevert].
+// [runopts.NoErrorOnRevert], and to return a different error, use
+// [runopts.WrapError].
 func (c Code) Run(callData []byte, opts ...runopts.Option) (*core.ExecutionResult, error) {
-	compiled, err := c.Compile()
+	comp, err := c.compile()
 	if err != nil {
 		return nil, fmt.Errorf("%T.Compile(): %v", c, err)
 	}
-	return runBytecode(compiled, callData, opts...)
+	return runBytecode(comp.bytecode, callData, comp.withLabels(opts)...)
+}
+
+// withLabels returns opts, prepended with an Option that sets the Contract's
+// Labels to those of the compilation.
+func (c *compilation) withLabels(opts []runopts.Option) []runopts.Option {
+	ls := c.labels()
+	setLabels := runopts.Func(func(cfg *runopts.Configuration) error {
+		cfg.Contract.Labels = ls
+		return nil
+	})
+	return append([]runopts.Option{setLabels}, opts...)
+}
+
+// RunAll is equivalent to calling c.Run() for each of the inputs, except that
+// c is only compiled once. Each input is run against a freshly instantiated
+// [vm.EVM] and state, with the same options, and results are returned in the
+// same order as inputs. Captured values (see [runopts.Captured]) will therefore
+// reflect only the last run.
+//
+// RunAll stops at the first error, returning it along with the results up to
+// and including the failed input, which may be non-nil in the case of a
+// revert. The error wraps that which Run() would have returned.
+func (c Code) RunAll(inputs [][]byte, opts ...runopts.Option) ([]*core.ExecutionResult, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return nil, fmt.Errorf("%T.Compile(): %v", c, err)
+	}
+	opts = comp.withLabels(opts)
+
+	results := make([]*core.ExecutionResult, 0, len(inputs))
+	for i, in := range inputs {
+		res, err := runBytecode(comp.bytecode, in, opts...)
+		results = append(results, res)
+		if err != nil {
+			return results, fmt.Errorf("input[%d]: %w", i, err)
+		}
+	}
+	return results, nil
 }
 
 // StartDebugging appends a runopts.Debugger (`dbg`) to the Options, calls
@@ -46,13 +86,13 @@ func (c Code) Run(callData []byte, opts ...runopts.Option) (*core.ExecutionResul
 // can be errors.Unwrap()d to access the same error available in
 // `dbg.State().Err`.
 func (c Code) StartDebugging(callData []byte, opts ...runopts.Option) (*evmdebug.Debugger, func() (*core.ExecutionResult, error), error) {
-	compiled, err := c.Compile()
+	comp, err := c.compile()
 	if err != nil {
 		return nil, nil, fmt.Errorf("%T.Compile(): %v", c, err)
 	}
 
 	dbg, opt := runopts.WithNewDebugger()
-	opts = append(opts, opt)
+	opts = append(comp.withLabels(opts), opt)
 
 	var (
 		result *core.ExecutionResult
@@ -60,7 +100,7 @@ func (c Code) StartDebugging(callData []byte, opts ...runopts.Option) (*evmdebug
 	)
 	done := make(chan struct{})
 	go func() {
-		result, resErr = runBytecode(compiled, callData, opts...)
+		result, resErr = runBytecode(comp.bytecode, callData, opts...)
 		close(done)
 	}()
 
@@ -76,6 +116,17 @@ func (c Code) StartDebugging(callData []byte, opts ...runopts.Option) (*evmdebug
 // returning the Debugger and results function, it calls
 // Debugger.RunTerminalUI().
 func (c Code) RunTerminalDebugger(callData []byte, opts ...runopts.Option) error {
+	return c.runTerminalDebugger(callData, false, opts...)
+}
+
+// RunTerminalDebuggerAtFault is equivalent to RunTerminalDebugger() except
+// that the UI starts with execution fast-forwarded to the first fault (e.g.
+// REVERT or INVALID), if any. See evmdebug.Context.StartAtFault.
+func (c Code) RunTerminalDebuggerAtFault(callData []byte, opts ...runopts.Option) error {
+	return c.runTerminalDebugger(callData, true, opts...)
+}
+
+func (c Code) runTerminalDebugger(callData []byte, startAtFault bool, opts ...runopts.Option) error {
 	bytecode := runopts.CaptureBytecode()
 	opts = append(opts, bytecode)
 	dbg, results, err := c.StartDebugging(callData, opts...)
@@ -85,9 +136,10 @@ func (c Code) RunTerminalDebugger(callData []byte, opts ...runopts.Option) error
 	defer dbg.FastForward()
 
 	dbgCtx := &evmdebug.Context{
-		CallData: callData,
-		Bytecode: bytecode.Val,
-		Results:  results,
+		CallData:     callData,
+		Bytecode:     bytecode.Val,
+		Results:      results,
+		StartAtFault: startAtFault,
 	}
 	return dbg.RunTerminalUI(dbgCtx)
 }
@@ -116,16 +168,102 @@ func runBytecode(compiled, callData []byte, opts ...runopts.Option) (*core.Execu
 		GasTipCap: big.NewInt(0),
 		GasPrice:  big.NewInt(0),
 		GasLimit:  gp.Gas(),
+		// Senders with code are otherwise rejected, as per EIP-3607, but are
+		// installed by runopts.FromContract().
+		SkipAccountChecks: cfg.StateDB.GetCodeSize(cfg.From) > 0,
+	}
+
+	var (
+		res      *core.ExecutionResult
+		applyErr error
+	)
+	// As with core.ApplyTransaction(), which calls ApplyMessage() but also
+	// signals the start and end of the transaction to the tracer.
+	if t := evm.Config.Tracer; t != nil && t.OnTxStart != nil {
+		tx := types.NewTx(&types.LegacyTx{
+			To:    msg.To,
+			Value: msg.Value,
+			Data:  msg.Data,
+			Gas:   msg.GasLimit,
+		})
+		t.OnTxStart(evm.GetVMContext(), tx, msg.From)
+	}
+	if t := evm.Config.Tracer; t != nil && t.OnTxEnd != nil {
+		defer func() {
+			t.OnTxEnd(receipt(res), applyErr)
+		}()
+	}
+
+	if cfg.Static {
+		res, applyErr = staticCall(evm, cfg, callData, gp.Gas())
+	} else {
+		res, applyErr = core.ApplyMessage(evm, msg, &gp)
+	}
+	if applyErr != nil {
+		return nil, applyErr
+	}
+	return res, resultErr(cfg, res)
+}
+
+// receipt returns a minimal Receipt describing the result, for use by tracers,
+// or nil if the result is nil.
+func receipt(res *core.ExecutionResult) *types.Receipt {
+	if res == nil {
+		return nil
+	}
+	r := &types.Receipt{
+		Type:              types.LegacyTxType,
+		Status:            types.ReceiptStatusSuccessful,
+		GasUsed:           res.UsedGas,
+		CumulativeGasUsed: res.UsedGas,
 	}
+	if res.Failed() {
+		r.Status = types.ReceiptStatusFailed
+	}
+	return r
+}
+
+// resultErr returns the error, possibly nil, to be returned by Run() along with
+// the result.
+func resultErr(cfg *runopts.Configuration, res *core.ExecutionResult) error {
+	switch {
+	case cfg.NoErrorOnRevert, !res.Failed():
+		return nil
+	case cfg.WrapError != nil:
+		return cfg.WrapError(res)
+	default:
+		return revert.ErrFrom(res)
+	}
+}
 
-	res, err := core.ApplyMessage(evm, msg, &gp)
+// staticCall is the equivalent of core.ApplyMessage() for a Configuration with
+// the Static flag set, performing the top-level call with evm.StaticCall()
+// instead of evm.Call(). As static calls can neither transfer value nor earn
+// gas refunds, only the intrinsic gas and access list need to be accounted
+// for. Unlike a transaction, the caller's nonce is not incremented.
+func staticCall(evm *vm.EVM, cfg *runopts.Configuration, callData []byte, gasLimit uint64) (*core.ExecutionResult, error) {
+	if !cfg.Value.IsZero() {
+		return nil, fmt.Errorf("static call with non-zero value %v", cfg.Value)
+	}
+
+	ctx := evm.Context
+	rules := evm.ChainConfig().Rules(ctx.BlockNumber, ctx.Random != nil, ctx.Time)
+	intrinsic, err := core.IntrinsicGas(callData, nil, false, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
 	if err != nil {
 		return nil, err
 	}
-	if cfg.NoErrorOnRevert {
-		return res, nil
+	if gasLimit < intrinsic {
+		return nil, fmt.Errorf("%w: have %d, want %d", core.ErrIntrinsicGas, gasLimit, intrinsic)
 	}
-	return res, revert.ErrFrom(res) /* may be nil */
+	to := cfg.Contract.Address
+	cfg.StateDB.Prepare(rules, cfg.From, ctx.Coinbase, &to, vm.ActivePrecompiles(rules), nil)
+
+	ret, gasLeft, vmErr := evm.StaticCall(vm.AccountRef(cfg.From), to, callData, gasLimit-intrinsic)
+	return &core.ExecutionResult{
+		UsedGas:    gasLimit - gasLeft,
+		Err:        vmErr,
+		ReturnData: ret,
+	}, nil
 }
 
 func newRunConfig(compiled []byte, opts ...runopts.Option) (*runopts.Configuration, error) {
@@ -172,6 +310,8 @@ func newRunConfig(compiled []byte, opts ...runopts.Option) (*runopts.Configurati
 		return nil, fmt.Errorf("runopts.Options MUST NOT set the code of the contract")
 	}
 	sdb.SetCode(a, compiled)
+	// Redundant when run via core.ApplyMessage(), which resets the access list
+	// and adds the recipient of the transaction, as required by EIP-2929.
 	sdb.AddAddressToAccessList(a)
 
 	sdb.AddBalance(cfg.From, cfg.Value, tracing.BalanceChangeUnspecified)
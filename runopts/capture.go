@@ -1,6 +1,18 @@
 package runopts
 
-import "github.com/ethereum/go-ethereum/core/vm"
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+
+	"github.com/arr4n/specops/internal/labels"
+)
 
 // A Captured value is an [Option] that stores part of the [Configuration] for
 // later inspection. After Run() and similar functions return, the Val field
@@ -52,3 +64,266 @@ func CaptureStateDB() *Captured[vm.StateDB] {
 		return c.StateDB
 	})
 }
+
+// CaptureStorageDiff captures every storage slot of the address that has a
+// different value after execution than before, mapped to the new value. A slot
+// that is written to but ends with its original value, including due to a
+// revert, is excluded. A slot that is cleared maps to the zero hash.
+//
+// Writes are attributed to the address whose storage they modify, so include
+// those performed by code DELEGATECALLed by the address.
+func CaptureStorageDiff(addr common.Address) *Captured[map[common.Hash]common.Hash] {
+	c := new(Captured[map[common.Hash]common.Hash])
+	c.apply = func(cfg *Configuration) error {
+		c.Val = make(map[common.Hash]common.Hash)
+		original := make(map[common.Hash]common.Hash)
+
+		cfg.addHooks(&tracing.Hooks{
+			OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+				if vm.OpCode(op) != vm.SSTORE || err != nil || scope.Address() != addr {
+					return
+				}
+				slot := common.Hash(scope.StackData()[len(scope.StackData())-1].Bytes32())
+				if _, ok := original[slot]; !ok {
+					// OnOpcode is called before execution so this is the value
+					// prior to the first write.
+					original[slot] = cfg.StateDB.GetState(addr, slot)
+				}
+			},
+			OnExit: func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+				if depth != 0 {
+					return
+				}
+				for slot, was := range original {
+					if now := cfg.StateDB.GetState(addr, slot); now != was {
+						c.Val[slot] = now
+					}
+				}
+			},
+		})
+		return nil
+	}
+	return c
+}
+
+// A SubcallResult describes a single call frame entered from within the
+// contract being run, i.e. via one of the CALL-family opcodes or
+// CREATE/CREATE2.
+type SubcallResult struct {
+	From, To common.Address
+	Input    []byte
+	Output   []byte
+	Success  bool
+}
+
+// CaptureSubcallReturns captures a SubcallResult for every call frame entered
+// below the top-level call to the contract, including those of nested calls.
+// Results are in the order in which the frames were entered and, as with all
+// Captured values, only reflect the last run.
+func CaptureSubcallReturns() *Captured[[]SubcallResult] {
+	c := new(Captured[[]SubcallResult])
+	c.apply = func(cfg *Configuration) error {
+		c.Val = nil
+		var open []int // indices into c.Val of frames yet to exit
+
+		cfg.addHooks(&tracing.Hooks{
+			OnEnter: func(depth int, typ byte, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+				if depth == 0 {
+					return
+				}
+				open = append(open, len(c.Val))
+				c.Val = append(c.Val, SubcallResult{
+					From:  from,
+					To:    to,
+					Input: common.CopyBytes(input),
+				})
+			},
+			OnExit: func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+				if depth == 0 || len(open) == 0 {
+					return
+				}
+				i := open[len(open)-1]
+				open = open[:len(open)-1]
+				c.Val[i].Output = common.CopyBytes(output)
+				c.Val[i].Success = err == nil
+			},
+		})
+		return nil
+	}
+	return c
+}
+
+// CaptureGasUsed captures the gas used by the execution of the contract's code,
+// including any subcalls. Unlike the UsedGas field of the ExecutionResult
+// returned by Run(), it excludes the intrinsic gas of the transaction (i.e. the
+// base cost and that of the call data) and isn't reduced by refunds.
+func CaptureGasUsed() *Captured[uint64] {
+	c := new(Captured[uint64])
+	c.apply = func(cfg *Configuration) error {
+		cfg.addHooks(&tracing.Hooks{
+			OnExit: func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+				if depth == 0 {
+					c.Val = gasUsed
+				}
+			},
+		})
+		return nil
+	}
+	return c
+}
+
+// CaptureGasUsedWithCosts is equivalent to CaptureGasUsed() except that the
+// gas is re-priced as if every executed instance of an opcode in `costs` had
+// cost the specified amount instead. This allows gas to be estimated for chains
+// with a different gas schedule to that of the EVM used by Run().
+//
+// Geth's per-fork jump tables can't be modified by a caller, so execution
+// itself is unaffected; e.g. a contract that checks GAS, or a subcall that
+// runs out of gas, behaves as it would under the regular schedule. Each
+// overridden cost replaces the entire cost of the opcode, including dynamic
+// components such as memory expansion and cold-access surcharges. The costs
+// of CALL- and CREATE-family opcodes include gas forwarded to the new frame so
+// can't be overridden, and an error is returned by Apply() if `costs` includes
+// any of them. Refunds are not affected, in the same manner as CaptureGasUsed().
+func CaptureGasUsedWithCosts(costs map[vm.OpCode]uint64) *Captured[uint64] {
+	c := new(Captured[uint64])
+	c.apply = func(cfg *Configuration) error {
+		for op := range costs {
+			switch op {
+			case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+				return fmt.Errorf("gas cost of %v can't be overridden", op)
+			}
+		}
+
+		var delta int64 // overridden minus actual
+		cfg.addHooks(&tracing.Hooks{
+			OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+				if override, ok := costs[vm.OpCode(op)]; ok && err == nil {
+					delta += int64(override) - int64(cost)
+				}
+			},
+			OnExit: func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+				if depth == 0 {
+					c.Val = uint64(max(int64(gasUsed)+delta, 0))
+				}
+			},
+		})
+		return nil
+	}
+	return c
+}
+
+// CaptureGethTrace captures a trace of every opcode executed, in the same
+// format as returned by geth's debug_traceTransaction RPC method with the
+// default struct logger (i.e. no tracer specified), allowing reuse of existing
+// trace viewers and analysis tools. The schema is that of geth's
+// logger.ExecutionResult:
+//
+//	{
+//	  "gas": <gas used by the transaction>,
+//	  "failed": <bool>,
+//	  "returnValue": <hex, without 0x prefix; empty if failed>,
+//	  "structLogs": [{
+//	    "pc", "op", "gas", "gasCost", "depth", "stack", "storage", "refund", "error"
+//	  }, ...]
+//	}
+//
+// As with geth's defaults, memory and return data aren't included, "stack" is
+// ordered from bottom to top, "storage" is only present for SLOAD and SSTORE,
+// and "refund" and "error" are omitted if zero and nil respectively.
+func CaptureGethTrace() *Captured[json.RawMessage] {
+	c := new(Captured[json.RawMessage])
+	c.apply = func(cfg *Configuration) error {
+		c.Val = nil
+		l := logger.NewStructLogger(nil)
+		h := l.Hooks()
+
+		onTxEnd := h.OnTxEnd
+		h.OnTxEnd = func(r *types.Receipt, err error) {
+			onTxEnd(r, err)
+			// GetResult() only errors if the logger was Stop()ped, which it
+			// never is.
+			c.Val, _ = l.GetResult()
+		}
+		cfg.addHooks(h)
+		return nil
+	}
+	return c
+}
+
+// CaptureNonce captures the nonce of the address after execution of the
+// contract's code, including any increments due to CREATE and CREATE2. As with
+// CaptureStorageDiff(), changes reverted by the contract aren't reflected.
+func CaptureNonce(addr common.Address) *Captured[uint64] {
+	c := new(Captured[uint64])
+	c.apply = func(cfg *Configuration) error {
+		cfg.addHooks(&tracing.Hooks{
+			OnExit: func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+				if depth == 0 {
+					c.Val = cfg.StateDB.GetNonce(addr)
+				}
+			},
+		})
+		return nil
+	}
+	return c
+}
+
+// CaptureSectionGas captures the gas used by each section of the contract's
+// code, keyed by section name. A section starts at a JUMPDEST or Label and
+// ends at the next one, taking the name of the former; code preceding the
+// first JUMPDEST or Label is keyed by the empty string. See [Contract.Labels].
+//
+// Gas is attributed to the section containing the opcode that consumed it.
+// Gas used by subcalls and contract creation is attributed to the section
+// containing the CALL- or CREATE-family opcode, which is only charged for the
+// gas actually used; i.e. gas forwarded but returned to the caller is excluded.
+func CaptureSectionGas() *Captured[map[string]uint64] {
+	c := new(Captured[map[string]uint64])
+	c.apply = func(cfg *Configuration) error {
+		c.Val = make(map[string]uint64)
+		var (
+			index     *labels.Index
+			section   string // of the last opcode in the contract
+			forwarded uint64 // to the current subcall
+			create    bool   // whether the current subcall is CREATE or CREATE2
+		)
+
+		cfg.addHooks(&tracing.Hooks{
+			OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+				if depth != 1 {
+					return
+				}
+				if index == nil {
+					// Deferred until execution so this Option can be
+					// applied before Labels are set.
+					index = labels.New(cfg.Contract.Labels)
+				}
+				section, _ = index.Nearest(pc)
+				c.Val[section] += cost
+			},
+			// The cost of a CALL-family opcode includes all gas forwarded to
+			// the callee, any remainder of which is refunded on return. Gas
+			// forwarded by CREATE and CREATE2 is instead deducted separately
+			// from the cost, so only that used by the initcode is added.
+			OnEnter: func(depth int, typ byte, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+				if depth == 1 {
+					forwarded = gas
+					create = vm.OpCode(typ) == vm.CREATE || vm.OpCode(typ) == vm.CREATE2
+				}
+			},
+			OnExit: func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+				if depth != 1 {
+					return
+				}
+				if create {
+					c.Val[section] += gasUsed
+				} else {
+					c.Val[section] -= min(forwarded-gasUsed, c.Val[section])
+				}
+			},
+		})
+		return nil
+	}
+	return c
+}
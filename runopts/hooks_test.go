@@ -0,0 +1,53 @@
+package runopts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddHooksChainsEveryHook(t *testing.T) {
+	var calls []string
+
+	// recordingHooks returns Hooks with every field set to a function that
+	// appends "<id>.<field name>" to calls.
+	recordingHooks := func(id string) *tracing.Hooks {
+		h := new(tracing.Hooks)
+		v := reflect.ValueOf(h).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			name := id + "." + v.Type().Field(i).Name
+			v.Field(i).Set(reflect.MakeFunc(v.Field(i).Type(), func([]reflect.Value) []reflect.Value {
+				calls = append(calls, name)
+				return nil
+			}))
+		}
+		return h
+	}
+
+	var c Configuration
+	c.addHooks(recordingHooks("a"))
+	c.addHooks(recordingHooks("b"))
+
+	v := reflect.ValueOf(c.VMConfig.Tracer).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		fn := v.Field(i)
+		if fn.IsNil() {
+			t.Errorf("After chaining %T.%s = nil", c.VMConfig.Tracer, name)
+			continue
+		}
+
+		calls = nil
+		args := make([]reflect.Value, fn.Type().NumIn())
+		for j := range args {
+			args[j] = reflect.Zero(fn.Type().In(j))
+		}
+		fn.Call(args)
+
+		if diff := cmp.Diff([]string{"a." + name, "b." + name}, calls); diff != "" {
+			t.Errorf("Calling chained %T.%s; diff (-want +got):\n%s", c.VMConfig.Tracer, name, diff)
+		}
+	}
+}
@@ -2,7 +2,16 @@
 package runopts
 
 import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"sync/atomic"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -25,6 +34,10 @@ type Configuration struct {
 	From            common.Address
 	Value           *uint256.Int
 	NoErrorOnRevert bool // see Run() re errors
+	Static          bool // see Static()
+	// WrapError, if non-nil, replaces revert.ErrFrom() in converting a failed
+	// result into the error returned by Run(); see WrapError().
+	WrapError func(*core.ExecutionResult) error
 	// vm.NewEVM()
 	BlockCtx    vm.BlockContext
 	TxCtx       vm.TxContext
@@ -37,7 +50,12 @@ type Configuration struct {
 // being run. [DefaultContractAddress] returns the default address with which
 // Contracts are constructed.
 type Contract struct {
-	Address  common.Address
+	Address common.Address
+	// Labels maps the names of JUMPDESTs and Labels to their offsets in the
+	// bytecode. It is populated by specops.Code.Run() and similar methods,
+	// before any Options are applied, but MAY be nil if the bytecode wasn't
+	// compiled from specops.Code.
+	Labels   map[string]int
 	bytecode []byte
 }
 
@@ -55,17 +73,49 @@ func (c *Contract) Bytecode() []byte {
 }
 
 // DefaultContractAddress returns the default address used as
-// [Contract.Address].
+// [Contract.Address]. See [SetDefaults] for overriding it.
 func DefaultContractAddress() common.Address {
+	if a := defaults.Load().ContractAddress; a != (common.Address{}) {
+		return a
+	}
 	return addressFromString("specops:contract")
 }
 
 // DefaultFromAddress returns the default address from which the contract is
-// called.
+// called. See [SetDefaults] for overriding it.
 func DefaultFromAddress() common.Address {
+	if a := defaults.Load().FromAddress; a != (common.Address{}) {
+		return a
+	}
 	return addressFromString("specops:from")
 }
 
+// Defaults override the values returned by [DefaultContractAddress] and
+// [DefaultFromAddress], and therefore those used by specops.Code.Run() in the
+// absence of [ContractAddress] and [From] Options. Zero-value fields retain
+// the original defaults.
+type Defaults struct {
+	ContractAddress common.Address
+	FromAddress     common.Address
+}
+
+var defaults atomic.Pointer[Defaults]
+
+func init() {
+	defaults.Store(new(Defaults))
+}
+
+// SetDefaults sets the package-wide Defaults, returning a function that
+// restores the previous ones. It is intended to be called once, e.g. in
+// TestMain(), by test suites that want stable custom addresses without
+// repeating Options on every run. Although SetDefaults is safe for concurrent
+// use, it affects all concurrent runs so SHOULD NOT be called from parallel
+// tests.
+func SetDefaults(d Defaults) (restore func()) {
+	prev := defaults.Swap(&d)
+	return func() { defaults.Store(prev) }
+}
+
 func addressFromString(s string) common.Address {
 	return common.BytesToAddress(crypto.Keccak256([]byte(s)))
 }
@@ -83,11 +133,17 @@ func (f Func) Apply(c *Configuration) error {
 	return f(c)
 }
 
-// WithDebugger returns an Option that sets Configuration.VMConfig.Tracer to
-// dbg.Tracer(), intercepting every opcode execution. See evmdebug for details.
+// WithDebugger returns an Option that adds dbg.Tracer() to
+// Configuration.VMConfig.Tracer, intercepting every opcode execution. Any
+// tracer already configured, e.g. by OnStep(), will also be called. The
+// Contract's Labels, if already set, are passed to dbg.SetLabels(). See
+// evmdebug for details.
 func WithDebugger(dbg *evmdebug.Debugger) Option {
 	return Func(func(c *Configuration) error {
-		c.VMConfig.Tracer = dbg.Tracer()
+		if ls := c.Contract.Labels; ls != nil {
+			dbg.SetLabels(ls)
+		}
+		c.addHooks(dbg.Tracer())
 		return nil
 	})
 }
@@ -109,6 +165,30 @@ func NoErrorOnRevert() Option {
 	})
 }
 
+// WrapError replaces the conversion of a failed execution into the error
+// returned by Run(), which defaults to revert.ErrFrom(). The function is only
+// called if the execution failed, and its return value, which MAY be nil, is
+// returned as is. This allows, for example, typed errors to be returned based
+// on the revert data. NoErrorOnRevert() takes precedence.
+func WrapError(fn func(*core.ExecutionResult) error) Option {
+	return Func(func(c *Configuration) error {
+		c.WrapError = fn
+		return nil
+	})
+}
+
+// Static signals to Run() that the contract must be called in a static
+// context, as if via STATICCALL, such that any state-modifying opcode (e.g.
+// SSTORE, LOG, CREATE, SELFDESTRUCT), including in subcalls, faults. It can be
+// used to verify that a view-only contract is free of side effects. As a static
+// call can't transfer value, running with a non-zero Value() is an error.
+func Static() Option {
+	return Func(func(c *Configuration) error {
+		c.Static = true
+		return nil
+	})
+}
+
 // ContractAddress sets the address to which the compiled bytecode will be
 // "deployed" before being run.
 func ContractAddress(a common.Address) Option {
@@ -127,27 +207,89 @@ func From(a common.Address) Option {
 	})
 }
 
-// An Unsigned type is an unsigned integer.
+// FromContract is equivalent to From() but additionally installs the code at
+// the address, such that the caller is a contract; e.g. EXTCODESIZE(CALLER) is
+// non-zero. This allows testing of access control that distinguishes between
+// EOAs and contracts. The EIP-3607 rejection of transactions from senders with
+// code is bypassed.
+func FromContract(a common.Address, code []byte) Option {
+	return Func(func(c *Configuration) error {
+		c.From = a
+		c.StateDB.CreateAccount(a)
+		c.StateDB.SetCode(a, code)
+		return nil
+	})
+}
+
+// PrevRandao sets the randomness of the block in which the contract is run;
+// i.e. the value pushed to the stack by the PREVRANDAO opcode (formerly
+// DIFFICULTY). It defaults to zero.
+func PrevRandao(r [32]byte) Option {
+	return Func(func(c *Configuration) error {
+		h := common.Hash(r)
+		c.BlockCtx.Random = &h
+		return nil
+	})
+}
+
+// PrevRandaoSequence is equivalent to PrevRandao() except that the value
+// differs every time the Option is applied, while being reproducible for a
+// given seed. This is intended for multi-run sessions, e.g. RunAll() or
+// fuzzing, in which every run of the contract must see a different value. The
+// value for the i-th application, counting from zero, is keccak256(seed ‖ i),
+// with i encoded as a big-endian uint64. It is safe for concurrent use.
+func PrevRandaoSequence(seed [32]byte) Option {
+	var n atomic.Uint64
+	return Func(func(c *Configuration) error {
+		h := crypto.Keccak256Hash(seed[:], binary.BigEndian.AppendUint64(nil, n.Add(1)-1))
+		c.BlockCtx.Random = &h
+		return nil
+	})
+}
+
+// An Unsigned type is an unsigned integer. Although a *big.Int can be negative,
+// it is included for compatibility with go-ethereum APIs; see Value().
 type Unsigned interface {
-	uint256.Int | *uint256.Int | uint | uint64
+	uint256.Int | *uint256.Int | uint | uint64 | *big.Int
 }
 
 // Value sets the value sent when calling the contract; i.e. the value pushed to
-// the stack by the CALLVALUE opcode.
+// the stack by the CALLVALUE opcode. A nil pointer, of either type, is treated
+// as zero. A *big.Int value that is negative or overflows 256 bits results in
+// an error when the Option is applied.
 func Value[U Unsigned](v U) Option {
-	var u *uint256.Int
+	var (
+		u   *uint256.Int
+		err error
+	)
 	switch v := any(v).(type) {
 	case uint256.Int:
 		u = &v
 	case *uint256.Int:
 		u = v
+		if u == nil {
+			u = new(uint256.Int)
+		}
 	case uint:
 		u = uint256.NewInt(uint64(v))
 	case uint64:
 		u = uint256.NewInt(v)
+	case *big.Int:
+		if v == nil {
+			u = new(uint256.Int)
+			break
+		}
+		var overflow bool
+		u, overflow = uint256.FromBig(v)
+		if v.Sign() < 0 || overflow {
+			err = fmt.Errorf("value %v out of range for uint256", v)
+		}
 	}
 
 	return Func(func(c *Configuration) error {
+		if err != nil {
+			return err
+		}
 		c.Value = u
 		return nil
 	})
@@ -155,11 +297,19 @@ func Value[U Unsigned](v U) Option {
 
 // GenesisAlloc preloads the state with code, storage values, and balances
 // described in the alloc. This can be used for testing interaction with other
-// contracts.
+// contracts. Accounts are applied in ascending order of address, regardless of
+// map iteration order, so the resulting state is deterministic.
 func GenesisAlloc(alloc types.GenesisAlloc) Option {
 	return Func(func(c *Configuration) error {
+		addrs := make([]common.Address, 0, len(alloc))
+		for addr := range alloc {
+			addrs = append(addrs, addr)
+		}
+		slices.SortFunc(addrs, common.Address.Cmp)
+
 		s := c.StateDB
-		for addr, acc := range alloc {
+		for _, addr := range addrs {
+			acc := alloc[addr]
 			s.CreateAccount(addr)
 			if len(acc.Code) > 0 {
 				s.SetCode(addr, acc.Code)
@@ -175,3 +325,30 @@ func GenesisAlloc(alloc types.GenesisAlloc) Option {
 		return nil
 	})
 }
+
+// GenesisJSON is equivalent to GenesisAlloc() but reads the allocation from
+// the "alloc" section of a standard geth genesis JSON document, such as those
+// used with `geth init`. All other fields are ignored, so need not be
+// present, but the "alloc" field is required.
+//
+// The reader is consumed immediately, and the allocation reused every time
+// the returned Option is applied. Any error reading or decoding the document
+// is returned when the Option is applied.
+func GenesisJSON(r io.Reader) Option {
+	var g struct {
+		Alloc *types.GenesisAlloc `json:"alloc"`
+	}
+	var err error
+	switch err = json.NewDecoder(r).Decode(&g); {
+	case err != nil:
+		err = fmt.Errorf("decoding genesis JSON: %v", err)
+	case g.Alloc == nil:
+		err = fmt.Errorf("genesis JSON missing required field %q", "alloc")
+	}
+	if err != nil {
+		return Func(func(*Configuration) error {
+			return err
+		})
+	}
+	return GenesisAlloc(*g.Alloc)
+}
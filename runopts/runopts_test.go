@@ -1,12 +1,19 @@
 package runopts_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -14,10 +21,16 @@ import (
 	"github.com/holiman/uint256"
 	"github.com/arr4n/specops/revert"
 	"github.com/arr4n/specops/runopts"
+	"github.com/arr4n/specops/stack"
 
 	. "github.com/arr4n/specops"
 )
 
+// forwardGas is the gas forwarded by subcalls in tests. The default chain
+// config predates EIP-150, so all gas can't be forwarded with GAS; without
+// the 63/64 rule, a call requesting more gas than is available fails.
+const forwardGas = 100_000
+
 func randomAddresses(n int, seed []byte) []common.Address {
 	keccak := crypto.NewKeccakState()
 	keccak.Write(seed)
@@ -73,6 +86,169 @@ func TestFromAddress(t *testing.T) {
 	}
 }
 
+func TestFromContract(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, Fn(EXTCODESIZE, CALLER)),
+		Fn(MSTORE, PUSH(32), CALLER),
+		Fn(RETURN, PUSH0, PUSH(64)),
+	}
+	caller := common.HexToAddress("0xc0de")
+
+	tests := []struct {
+		name     string
+		opt      runopts.Option
+		wantSize uint64
+	}{
+		{
+			name:     "EOA",
+			opt:      runopts.From(caller),
+			wantSize: 0,
+		},
+		{
+			name:     "contract",
+			opt:      runopts.FromContract(caller, []byte{byte(INVALID), byte(STOP), byte(STOP)}),
+			wantSize: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := code.Run(nil, tt.opt)
+			if err != nil {
+				t.Fatalf("%T.Run() error %v", code, err)
+			}
+			ret := res.ReturnData
+			if got, want := new(uint256.Int).SetBytes(ret[:32]).Uint64(), tt.wantSize; got != want {
+				t.Errorf("EXTCODESIZE(CALLER) = %d; want %d", got, want)
+			}
+			if got, want := common.BytesToAddress(ret[32:]), caller; got != want {
+				t.Errorf("CALLER = %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPrevRandao(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, DIFFICULTY), // PREVRANDAO post merge
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+	run := func(t *testing.T, opts ...runopts.Option) common.Hash {
+		t.Helper()
+		res, err := code.Run(nil, opts...)
+		if err != nil {
+			t.Fatalf("%T.Run() error %v", code, err)
+		}
+		return common.BytesToHash(res.ReturnData)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		if got := run(t); got != (common.Hash{}) {
+			t.Errorf("PREVRANDAO without Option = %v; want zero", got)
+		}
+	})
+
+	t.Run("fixed", func(t *testing.T) {
+		want := crypto.Keccak256Hash([]byte("randao"))
+		if got := run(t, runopts.PrevRandao(want)); got != want {
+			t.Errorf("PREVRANDAO with PrevRandao(%v) = %v", want, got)
+		}
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		seed := crypto.Keccak256Hash([]byte("seed"))
+		seq := runopts.PrevRandaoSequence(seed)
+
+		seen := make(map[common.Hash]bool)
+		for i := uint64(0); i < 5; i++ {
+			got := run(t, seq)
+			want := crypto.Keccak256Hash(seed[:], binary.BigEndian.AppendUint64(nil, i))
+			if got != want {
+				t.Errorf("PREVRANDAO on run %d with PrevRandaoSequence() = %v; want %v", i, got, want)
+			}
+			if seen[got] {
+				t.Errorf("PREVRANDAO on run %d with PrevRandaoSequence() repeated %v", i, got)
+			}
+			seen[got] = true
+		}
+
+		// A new sequence with the same seed is reproducible.
+		if got, want := run(t, runopts.PrevRandaoSequence(seed)), crypto.Keccak256Hash(seed[:], make([]byte, 8)); got != want {
+			t.Errorf("PREVRANDAO with new PrevRandaoSequence() of same seed = %v; want %v", got, want)
+		}
+	})
+}
+
+func TestSetDefaults(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH0, ADDRESS),
+		Fn(MSTORE, PUSH(32), CALLER),
+		Fn(RETURN, PUSH0, PUSH(64)),
+	}
+	run := func(t *testing.T, opts ...runopts.Option) (contract, from common.Address) {
+		t.Helper()
+		res, err := code.Run(nil, opts...)
+		if err != nil {
+			t.Fatalf("%T.Run() error %v", code, err)
+		}
+		ret := res.Return()
+		return common.BytesToAddress(ret[:32]), common.BytesToAddress(ret[32:])
+	}
+
+	origContract := runopts.DefaultContractAddress()
+	origFrom := runopts.DefaultFromAddress()
+	addrs := randomAddresses(3, []byte("defaults"))
+
+	tests := []struct {
+		name                   string
+		defaults               runopts.Defaults
+		opts                   []runopts.Option
+		wantContract, wantFrom common.Address
+	}{
+		{
+			name:         "both overridden",
+			defaults:     runopts.Defaults{ContractAddress: addrs[0], FromAddress: addrs[1]},
+			wantContract: addrs[0],
+			wantFrom:     addrs[1],
+		},
+		{
+			name:         "zero value retains original",
+			defaults:     runopts.Defaults{FromAddress: addrs[1]},
+			wantContract: origContract,
+			wantFrom:     addrs[1],
+		},
+		{
+			name:         "options take precedence",
+			defaults:     runopts.Defaults{ContractAddress: addrs[0], FromAddress: addrs[1]},
+			opts:         []runopts.Option{runopts.ContractAddress(addrs[2])},
+			wantContract: addrs[2],
+			wantFrom:     addrs[1],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := runopts.SetDefaults(tt.defaults)
+			defer restore()
+
+			gotContract, gotFrom := run(t, tt.opts...)
+			if gotContract != tt.wantContract {
+				t.Errorf("contract deployed to address %v; want %v", gotContract, tt.wantContract)
+			}
+			if gotFrom != tt.wantFrom {
+				t.Errorf("called from address %v; want %v", gotFrom, tt.wantFrom)
+			}
+		})
+	}
+
+	t.Run("restored", func(t *testing.T) {
+		gotContract, gotFrom := run(t)
+		if gotContract != origContract || gotFrom != origFrom {
+			t.Errorf("after restoring defaults, got contract %v and caller %v; want %v and %v", gotContract, gotFrom, origContract, origFrom)
+		}
+	})
+}
+
 func TestValue(t *testing.T) {
 	code := Code{
 		Fn(MSTORE, PUSH0, CALLVALUE),
@@ -98,6 +274,101 @@ func TestValue(t *testing.T) {
 			t.Errorf("contract received value %v; want %v", got, want)
 		}
 	}
+
+	t.Run("big.Int", func(t *testing.T) {
+		for _, val := range vals {
+			b := val.ToBig()
+			gotRes, err := code.Run(nil, runopts.Value(b))
+			if err != nil {
+				t.Fatalf("%T.Run() error %v", code, err)
+			}
+
+			got := new(uint256.Int).SetBytes(gotRes.Return())
+			if want := &val; !got.Eq(want) {
+				t.Errorf("contract received value %v; want %v", got, want)
+			}
+		}
+
+		for _, bad := range []*big.Int{
+			big.NewInt(-1),
+			new(big.Int).Lsh(big.NewInt(1), 256),
+		} {
+			if _, err := code.Run(nil, runopts.Value(bad)); err == nil {
+				t.Errorf("%T.Run(…, Value(%v)) got nil error; want out-of-range error", code, bad)
+			}
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		for _, opt := range []runopts.Option{
+			runopts.Value((*big.Int)(nil)),
+			runopts.Value((*uint256.Int)(nil)),
+		} {
+			gotRes, err := code.Run(nil, opt)
+			if err != nil {
+				t.Fatalf("%T.Run(…, Value(nil)) error %v", code, err)
+			}
+			if got := new(uint256.Int).SetBytes(gotRes.Return()); !got.IsZero() {
+				t.Errorf("contract received value %v; want 0", got)
+			}
+		}
+	})
+}
+
+func TestStatic(t *testing.T) {
+	view := Code{
+		Fn(MSTORE, PUSH0, Fn(SLOAD, PUSH0)),
+		Fn(RETURN, PUSH0, PUSH(32)),
+	}
+
+	t.Run("view", func(t *testing.T) {
+		want, err := view.Run(nil)
+		if err != nil {
+			t.Fatalf("%T.Run() error %v", view, err)
+		}
+		got, err := view.Run(nil, runopts.Static())
+		if err != nil {
+			t.Fatalf("%T.Run(…, Static()) error %v", view, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%T.Run(…, Static()) diff (-non-static +static):\n%s", view, diff)
+		}
+	})
+
+	t.Run("state modification", func(t *testing.T) {
+		for _, code := range []Code{
+			{Fn(SSTORE, PUSH0, PUSH(1)), STOP},
+			{Fn(LOG0, PUSH0, PUSH0), STOP},
+		} {
+			if _, err := code.Run(nil); err != nil {
+				t.Fatalf("%T.Run() error %v", code, err)
+			}
+			res, err := code.Run(nil, runopts.Static(), runopts.NoErrorOnRevert())
+			if err != nil {
+				t.Fatalf("%T.Run(…, Static()) error %v", code, err)
+			}
+			if got, want := res.Err, vm.ErrWriteProtection; got != want {
+				t.Errorf("%T.Run(…, Static()) got result error %v; want %v", code, got, want)
+			}
+		}
+	})
+
+	t.Run("non-zero value", func(t *testing.T) {
+		if _, err := view.Run(nil, runopts.Static(), runopts.Value(uint64(1))); err == nil {
+			t.Errorf("%T.Run(…, Static(), Value(1)) got nil error", view)
+		}
+	})
+
+	t.Run("insufficient intrinsic gas", func(t *testing.T) {
+		// At 16 gas per non-zero byte, this exceeds the gas limit.
+		callData := bytes.Repeat([]byte{0xff}, 2_000_000)
+		if _, err := view.Run(callData); !errors.Is(err, core.ErrIntrinsicGas) {
+			t.Fatalf("%T.Run(<%d bytes>) got err %v; want %v", view, len(callData), err, core.ErrIntrinsicGas)
+		}
+		if _, err := view.Run(callData, runopts.Static()); !errors.Is(err, core.ErrIntrinsicGas) {
+			t.Errorf("%T.Run(<%d bytes>, Static()) got err %v; want %v", view, len(callData), err, core.ErrIntrinsicGas)
+		}
+	})
 }
 
 func TestErrorOnRevert(t *testing.T) {
@@ -137,6 +408,54 @@ func TestErrorOnRevert(t *testing.T) {
 	}
 }
 
+// errSelector is a typed error for testing WrapError().
+type errSelector [4]byte
+
+func (e errSelector) Error() string { return fmt.Sprintf("reverted with selector %#x", e[:]) }
+
+func TestWrapError(t *testing.T) {
+	wrap := runopts.WrapError(func(res *core.ExecutionResult) error {
+		var sel errSelector
+		copy(sel[:], res.Revert())
+		return sel
+	})
+
+	tests := []struct {
+		name    string
+		code    Code
+		opts    []runopts.Option
+		wantErr error
+	}{
+		{
+			name: "success",
+			code: Code{STOP},
+			opts: []runopts.Option{wrap},
+		},
+		{
+			name: "revert",
+			code: Code{
+				Fn(MSTORE, PUSH0, PUSH(0xdeadbeef)),
+				Fn(REVERT, PUSH(28), PUSH(4)),
+			},
+			opts:    []runopts.Option{wrap},
+			wantErr: errSelector{0xde, 0xad, 0xbe, 0xef},
+		},
+		{
+			name: "NoErrorOnRevert takes precedence",
+			code: Code{Fn(REVERT, PUSH0, PUSH0)},
+			opts: []runopts.Option{wrap, runopts.NoErrorOnRevert()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.code.Run(nil, tt.opts...); err != tt.wantErr {
+				t.Errorf("%T.Run(…, WrapError(…)) got error %v; want %v", tt.code, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestStorage(t *testing.T) {
 	slot := common.Hash{'s', 'o', 'm', 'e', 'w', 'h', 'e', 'r', 'e'}
 	const initVal = 42
@@ -171,6 +490,135 @@ func TestStorage(t *testing.T) {
 	}
 }
 
+func TestCaptureStorageDiff(t *testing.T) {
+	contract := runopts.DefaultContractAddress()
+	library := common.Address{'l', 'i', 'b'}
+	other := common.Address{'o', 't', 'h', 'e', 'r'}
+
+	sstore := func(slot, val int) Code {
+		return Code{Fn(SSTORE, PUSH(slot), PUSH(val))}
+	}
+	compile := func(t *testing.T, c Code) []byte {
+		t.Helper()
+		b, err := c.Compile()
+		if err != nil {
+			t.Fatalf("%T.Compile() error %v", c, err)
+		}
+		return b
+	}
+	alloc := runopts.GenesisAlloc(types.GenesisAlloc{
+		library: {Code: compile(t, Code{sstore(6, 0x66)})},
+		other:   {Code: compile(t, Code{sstore(7, 0x77)})},
+	})
+	preload := runopts.Func(func(c *runopts.Configuration) error {
+		for slot, val := range map[int]int{1: 0x01, 2: 0x02, 5: 0x55} {
+			c.StateDB.SetState(contract, common.BigToHash(big.NewInt(int64(slot))), common.BigToHash(big.NewInt(int64(val))))
+		}
+		return nil
+	})
+
+	h := func(x int) common.Hash { return common.BigToHash(big.NewInt(int64(x))) }
+
+	tests := []struct {
+		name string
+		code Code
+		want map[common.Hash]common.Hash
+	}{
+		{
+			name: "writes",
+			code: Code{
+				sstore(1, 0x11),               // modified
+				sstore(2, 0x02),               // unchanged
+				sstore(3, 0x33),               // new
+				sstore(4, 0x44), sstore(4, 0), // net unchanged
+				sstore(5, 0), // cleared
+				Fn(DELEGATECALL, PUSH(forwardGas), PUSH(library), PUSH0, PUSH0, PUSH0, PUSH0),
+				Fn(CALL, PUSH(forwardGas), PUSH(other), PUSH0, PUSH0, PUSH0, PUSH0, PUSH0),
+				STOP,
+			},
+			want: map[common.Hash]common.Hash{
+				h(1): h(0x11),
+				h(3): h(0x33),
+				h(5): {},
+				h(6): h(0x66), // DELEGATECALL
+			},
+		},
+		{
+			name: "reverted",
+			code: Code{
+				sstore(1, 0x11),
+				Fn(REVERT, PUSH0, PUSH0),
+			},
+			want: map[common.Hash]common.Hash{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := runopts.CaptureStorageDiff(contract)
+			if _, err := tt.code.Run(nil, alloc, preload, diff, runopts.NoErrorOnRevert()); err != nil {
+				t.Fatalf("%T.Run() error %v", tt.code, err)
+			}
+			if d := cmp.Diff(tt.want, diff.Val); d != "" {
+				t.Errorf("%T.Run(%T) diff (-want +got):\n%s", tt.code, diff, d)
+			}
+		})
+	}
+}
+
+func TestCaptureNonce(t *testing.T) {
+	contract := runopts.DefaultContractAddress()
+	create := Fn(CREATE, PUSH0, PUSH0, PUSH0)
+
+	tests := []struct {
+		name string
+		code Code
+		opts []runopts.Option
+		want uint64
+	}{
+		{
+			name: "no creation",
+			code: Code{STOP},
+			want: 0,
+		},
+		{
+			// The default chain config predates EIP-161 so the contract's nonce
+			// starts at zero.
+			name: "two creations",
+			code: Code{create, create},
+			want: 2,
+		},
+		{
+			name: "from allocated nonce",
+			code: Code{create},
+			opts: []runopts.Option{
+				runopts.GenesisAlloc(types.GenesisAlloc{
+					contract: {Nonce: 5},
+				}),
+			},
+			want: 6,
+		},
+		{
+			name: "reverted",
+			code: Code{create, Fn(REVERT, PUSH0, PUSH0)},
+			opts: []runopts.Option{runopts.NoErrorOnRevert()},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce := runopts.CaptureNonce(contract)
+			if _, err := tt.code.Run(nil, append(tt.opts, nonce)...); err != nil {
+				t.Fatalf("%T.Run() error %v", tt.code, err)
+			}
+			if got := nonce.Val; got != tt.want {
+				t.Errorf("%T.Run(%T) got nonce %d; want %d", tt.code, nonce, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGenesisAlloc(t *testing.T) {
 	addr := common.Address{'a', 'd', 'd', 'r', 'e', 's', 's'}
 	code := []byte{'c', 'o', 'd', 'e'}
@@ -222,6 +670,444 @@ func TestGenesisAlloc(t *testing.T) {
 	}
 }
 
+func TestGenesisJSON(t *testing.T) {
+	const genesis = `{
+		"config": {"chainId": 1},
+		"alloc": {
+			"0x0000000000000000000000000000000000000abc": {
+				"balance": "0x2a",
+				"code": "0x6001600101"
+			},
+			"{{contract}}": {
+				"balance": "0x0",
+				"storage": {
+					"0x0000000000000000000000000000000000000000000000000000000000000001": "0x00000000000000000000000000000000000000000000000000000000deadbeef"
+				}
+			}
+		}
+	}`
+	addr := common.HexToAddress("0xabc")
+
+	code := Code{
+		Fn(MSTORE, PUSH0, Fn(BALANCE, PUSH(addr))),
+		Fn(MSTORE, PUSH(32), Fn(EXTCODESIZE, PUSH(addr))),
+		Fn(MSTORE, PUSH(64), Fn(SLOAD, PUSH(1))),
+		Fn(RETURN, PUSH0, PUSH(96)),
+	}
+
+	json := strings.Replace(genesis, "{{contract}}", runopts.DefaultContractAddress().Hex(), 1)
+	res, err := code.Run(nil, runopts.GenesisJSON(strings.NewReader(json)))
+	if err != nil {
+		t.Fatalf("%T.Run(…, GenesisJSON(…)) error %v", code, err)
+	}
+
+	want := make([]byte, 96)
+	want[31] = 42
+	want[63] = 5
+	copy(want[92:], []byte{0xde, 0xad, 0xbe, 0xef})
+	if diff := cmp.Diff(want, res.ReturnData); diff != "" {
+		t.Errorf("%T.Run(…, GenesisJSON(…)) diff (-want +got):\n%s", code, diff)
+	}
+
+	t.Run("reused", func(t *testing.T) {
+		// The reader is drained when the Option is created, so must not be
+		// required for subsequent runs.
+		opt := runopts.GenesisJSON(strings.NewReader(json))
+		for i := 0; i < 2; i++ {
+			res, err := code.Run(nil, opt)
+			if err != nil {
+				t.Fatalf("%T.Run(…, GenesisJSON(…)) error %v", code, err)
+			}
+			if diff := cmp.Diff(want, res.ReturnData); diff != "" {
+				t.Errorf("%T.Run(…, GenesisJSON(…)) run %d diff (-want +got):\n%s", code, i, diff)
+			}
+		}
+	})
+
+	for _, bad := range []string{
+		"",
+		"{",
+		`{"alloc": []}`,
+		`{"config": {}}`, // missing alloc
+	} {
+		if _, err := code.Run(nil, runopts.GenesisJSON(strings.NewReader(bad))); err == nil {
+			t.Errorf("%T.Run(…, GenesisJSON(%q)) got nil error; want error", code, bad)
+		}
+	}
+}
+
+func TestContractAccessListWarmth(t *testing.T) {
+	// The contract address is always warm because, per EIP-2929, it is the
+	// recipient of the transaction. Its storage, however, is cold until first
+	// accessed, so no option is required to observe cold-access gas.
+	tests := []struct {
+		name string
+		code Code
+		want uint64
+	}{
+		{
+			name: "own balance is warm",
+			code: Code{Fn(BALANCE, ADDRESS), STOP},
+			want: 2 + 100,
+		},
+		{
+			name: "first SLOAD is cold",
+			code: Code{Fn(SLOAD, PUSH0), STOP},
+			want: 2 + 2100,
+		},
+		{
+			name: "second SLOAD is warm",
+			code: Code{Fn(SLOAD, PUSH0), Fn(SLOAD, PUSH0), STOP},
+			want: 2 + 2100 + 2 + 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gas := runopts.CaptureGasUsed()
+			if _, err := tt.code.Run(nil, gas); err != nil {
+				t.Fatalf("%T.Run() error %v", tt.code, err)
+			}
+			if got := gas.Val; got != tt.want {
+				t.Errorf("%T.Run() used %d gas; want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureGasUsedWithCosts(t *testing.T) {
+	code := Code{
+		Fn(POP, Fn(ADD, PUSH0, PUSH0)),
+		Fn(POP, Fn(ADD, PUSH0, PUSH0)),
+		Fn(MSTORE, PUSH0, PUSH0), // includes memory expansion
+	}
+
+	gas := runopts.CaptureGasUsed()
+	if _, err := code.Run(nil, gas); err != nil {
+		t.Fatalf("%T.Run(%T) error %v", code, gas, err)
+	}
+	const (
+		addCost    = 3
+		mstoreCost = 3 + 3 // including expansion to 1 word
+	)
+
+	tests := []struct {
+		name  string
+		costs map[vm.OpCode]uint64
+		want  uint64
+	}{
+		{
+			name: "no overrides",
+			want: gas.Val,
+		},
+		{
+			name:  "unused opcode",
+			costs: map[vm.OpCode]uint64{vm.MUL: 1000},
+			want:  gas.Val,
+		},
+		{
+			name:  "increased",
+			costs: map[vm.OpCode]uint64{vm.ADD: 100},
+			want:  gas.Val + 2*(100-addCost),
+		},
+		{
+			name:  "decreased including dynamic",
+			costs: map[vm.OpCode]uint64{vm.ADD: 0, vm.MSTORE: 1},
+			want:  gas.Val - 2*addCost - (mstoreCost - 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runopts.CaptureGasUsedWithCosts(tt.costs)
+			if _, err := code.Run(nil, got); err != nil {
+				t.Fatalf("%T.Run(%T) error %v", code, got, err)
+			}
+			if got.Val != tt.want {
+				t.Errorf("%T.Run(CaptureGasUsedWithCosts(%v)) got %d; want %d", code, tt.costs, got.Val, tt.want)
+			}
+		})
+	}
+
+	t.Run("CALL", func(t *testing.T) {
+		opt := runopts.CaptureGasUsedWithCosts(map[vm.OpCode]uint64{vm.CALL: 100})
+		if _, err := code.Run(nil, opt); err == nil {
+			t.Errorf("%T.Run(CaptureGasUsedWithCosts({CALL: 100})) got nil error", code)
+		}
+	})
+}
+
+func TestCaptureGethTrace(t *testing.T) {
+	type structLog struct {
+		PC      uint64            `json:"pc"`
+		Op      string            `json:"op"`
+		GasCost uint64            `json:"gasCost"`
+		Depth   int               `json:"depth"`
+		Stack   []string          `json:"stack"`
+		Storage map[string]string `json:"storage"`
+	}
+	type trace struct {
+		Gas         uint64      `json:"gas"`
+		Failed      bool        `json:"failed"`
+		ReturnValue string      `json:"returnValue"`
+		StructLogs  []structLog `json:"structLogs"`
+	}
+
+	slot := func(x byte) string { return hex.EncodeToString(common.LeftPadBytes([]byte{x}, 32)) }
+
+	tests := []struct {
+		name string
+		code Code
+		want trace
+	}{
+		{
+			name: "return",
+			code: Code{
+				Fn(SSTORE, PUSH(1), PUSH(2)),
+				Fn(RETURN, PUSH(31), PUSH(1)),
+			},
+			want: trace{
+				ReturnValue: "00",
+				StructLogs: []structLog{
+					{PC: 0, Op: "PUSH1", GasCost: 3, Depth: 1, Stack: []string{}},
+					{PC: 2, Op: "PUSH1", GasCost: 3, Depth: 1, Stack: []string{"0x2"}},
+					{PC: 4, Op: "SSTORE", GasCost: 22100, Depth: 1, Stack: []string{"0x2", "0x1"}, Storage: map[string]string{slot(1): slot(2)}},
+					{PC: 5, Op: "PUSH1", GasCost: 3, Depth: 1, Stack: []string{}},
+					{PC: 7, Op: "PUSH1", GasCost: 3, Depth: 1, Stack: []string{"0x1"}},
+					{PC: 9, Op: "RETURN", GasCost: 3, Depth: 1, Stack: []string{"0x1", "0x1f"}},
+				},
+			},
+		},
+		{
+			name: "revert",
+			code: Code{
+				Fn(REVERT, PUSH0, PUSH0),
+			},
+			want: trace{
+				Failed: true,
+				StructLogs: []structLog{
+					{PC: 0, Op: "PUSH0", GasCost: 2, Depth: 1, Stack: []string{}},
+					{PC: 1, Op: "PUSH0", GasCost: 2, Depth: 1, Stack: []string{"0x0"}},
+					{PC: 2, Op: "REVERT", GasCost: 0, Depth: 1, Stack: []string{"0x0", "0x0"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := runopts.CaptureGethTrace()
+			res, err := tt.code.Run(nil, tr, runopts.NoErrorOnRevert())
+			if err != nil {
+				t.Fatalf("%T.Run() error %v", tt.code, err)
+			}
+
+			var got trace
+			if err := json.Unmarshal(tr.Val, &got); err != nil {
+				t.Fatalf("json.Unmarshal(%T.Val) error %v", tr, err)
+			}
+			tt.want.Gas = res.UsedGas
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%T.Run(%T) diff (-want +got):\n%s", tt.code, tr, diff)
+			}
+		})
+	}
+}
+
+func TestCaptureSectionGas(t *testing.T) {
+	code := Code{
+		PUSH0, POP, // 2 + 2
+		Label("a"),
+		PUSH0, PUSH0, ADD, POP, // 2 + 2 + 3 + 2
+		Fn(JUMP, PUSH("b")),              // 3 + 8
+		JUMPDEST("b"), stack.SetDepth(0), // 1
+		STOP, // 0
+	}
+
+	gas := runopts.CaptureSectionGas()
+	if _, err := code.Run(nil, gas); err != nil {
+		t.Fatalf("%T.Run() error %v", code, err)
+	}
+
+	want := map[string]uint64{
+		"":  4,
+		"a": 20,
+		"b": 1,
+	}
+	if diff := cmp.Diff(want, gas.Val); diff != "" {
+		t.Errorf("%T.Run(%T) diff (-want +got):\n%s", code, gas, diff)
+	}
+
+	t.Run("subcall", func(t *testing.T) {
+		callee := common.Address{'c', 'a', 'l', 'l', 'e', 'e'}
+		calleeCode, err := Code{Fn(SSTORE, PUSH0, PUSH(1)), STOP}.Compile()
+		if err != nil {
+			t.Fatalf("Compile() error %v", err)
+		}
+
+		code := Code{
+			PUSH0, POP,
+			Label("call"),
+			Fn(CALL, PUSH(forwardGas), PUSH(callee), PUSH0, PUSH0, PUSH0, PUSH0, PUSH0),
+			POP,
+			Label("end"),
+			STOP,
+		}
+
+		sections := runopts.CaptureSectionGas()
+		used := runopts.CaptureGasUsed()
+		alloc := runopts.GenesisAlloc(types.GenesisAlloc{
+			callee: {Code: calleeCode},
+		})
+		if _, err := code.Run(nil, alloc, sections, used); err != nil {
+			t.Fatalf("%T.Run() error %v", code, err)
+		}
+
+		var sum uint64
+		for _, g := range sections.Val {
+			sum += g
+		}
+		if sum != used.Val {
+			t.Errorf("%T.Run() sum of %T = %d; want %d as captured by %T", code, sections, sum, used.Val, used)
+		}
+		// The callee's SSTORE of a new, non-zero value costs at least 20k.
+		if got, want := sections.Val["call"], uint64(20_000); got <= want {
+			t.Errorf("%T.Run(%T) section with CALL used %d gas; want > %d, including callee", code, sections, got, want)
+		}
+	})
+
+	t.Run("create", func(t *testing.T) {
+		code := Code{
+			PUSH0, POP,
+			Label("create"),
+			Fn(CREATE, PUSH0, PUSH0, PUSH0),
+			POP,
+			STOP,
+		}
+
+		sections := runopts.CaptureSectionGas()
+		used := runopts.CaptureGasUsed()
+		if _, err := code.Run(nil, sections, used); err != nil {
+			t.Fatalf("%T.Run() error %v", code, err)
+		}
+
+		var sum uint64
+		for _, g := range sections.Val {
+			sum += g
+		}
+		if sum != used.Val {
+			t.Errorf("%T.Run() sum of %T = %d; want %d as captured by %T", code, sections, sum, used.Val, used)
+		}
+		if got, want := sections.Val["create"], uint64(32_000); got <= want {
+			t.Errorf("%T.Run(%T) section with CREATE used %d gas; want > %d", code, sections, got, want)
+		}
+	})
+}
+
+func TestCaptureSubcallReturns(t *testing.T) {
+	var (
+		proxy    = common.Address{'p', 'r', 'o', 'x', 'y'}
+		echo     = common.Address{'e', 'c', 'h', 'o'}
+		reverter = common.Address{'r', 'e', 'v', 'e', 'r', 't'}
+	)
+
+	returnAll := Code{
+		Fn(CALLDATACOPY, PUSH0, PUSH0, CALLDATASIZE),
+		Fn(RETURN, PUSH0, CALLDATASIZE),
+	}
+	forward := Code{
+		Fn(CALLDATACOPY, PUSH0, PUSH0, CALLDATASIZE),
+		Fn(CALL, PUSH(forwardGas), PUSH(echo), PUSH0, PUSH0, CALLDATASIZE, PUSH0, PUSH0),
+		POP,
+		Fn(RETURNDATACOPY, PUSH0, PUSH0, RETURNDATASIZE),
+		Fn(RETURN, PUSH0, RETURNDATASIZE),
+	}
+	revertWithData := Code{
+		Fn(MSTORE8, PUSH0, PUSH(0xff)),
+		Fn(REVERT, PUSH0, PUSH(1)),
+	}
+
+	alloc := make(types.GenesisAlloc)
+	for addr, code := range map[common.Address]Code{
+		proxy:    forward,
+		echo:     returnAll,
+		reverter: revertWithData,
+	} {
+		compiled, err := code.Compile()
+		if err != nil {
+			t.Fatalf("%T.Compile() error %v", code, err)
+		}
+		alloc[addr] = types.Account{Code: compiled}
+	}
+
+	input := []byte("hello")
+	code := Code{
+		Fn(MSTORE, PUSH0, PUSHBytes(input...)),
+		Fn(CALL, PUSH(10*forwardGas), PUSH(proxy), PUSH0, PUSH(32-len(input)), PUSH(len(input)), PUSH0, PUSH0),
+		Fn(CALL, PUSH(forwardGas), PUSH(reverter), PUSH0, PUSH0, PUSH0, PUSH0, PUSH0),
+		STOP,
+	}
+
+	subcalls := runopts.CaptureSubcallReturns()
+	if _, err := code.Run(nil, runopts.GenesisAlloc(alloc), subcalls); err != nil {
+		t.Fatalf("%T.Run() error %v", code, err)
+	}
+
+	contract := runopts.DefaultContractAddress()
+	want := []runopts.SubcallResult{
+		{
+			From:    contract,
+			To:      proxy,
+			Input:   input,
+			Output:  input,
+			Success: true,
+		},
+		{
+			From:    proxy,
+			To:      echo,
+			Input:   input,
+			Output:  input,
+			Success: true,
+		},
+		{
+			From:    contract,
+			To:      reverter,
+			Output:  []byte{0xff},
+			Success: false,
+		},
+	}
+	if diff := cmp.Diff(want, subcalls.Val); diff != "" {
+		t.Errorf("%T.Run(%T) diff (-want +got):\n%s", code, subcalls, diff)
+	}
+
+	t.Run("reused", func(t *testing.T) {
+		code := Code{
+			Fn(CALLDATACOPY, PUSH0, PUSH0, CALLDATASIZE),
+			Fn(CALL, PUSH(forwardGas), PUSH(echo), PUSH0, PUSH0, CALLDATASIZE, PUSH0, PUSH0),
+			STOP,
+		}
+
+		subcalls := runopts.CaptureSubcallReturns()
+		for _, in := range [][]byte{{1}, {2}, {3}} {
+			if _, err := code.Run(in, runopts.GenesisAlloc(alloc), subcalls); err != nil {
+				t.Fatalf("%T.Run(%#x) error %v", code, in, err)
+			}
+		}
+
+		// Only the last run is reflected.
+		want := []runopts.SubcallResult{{
+			From:    contract,
+			To:      echo,
+			Input:   []byte{3},
+			Output:  []byte{3},
+			Success: true,
+		}}
+		if diff := cmp.Diff(want, subcalls.Val); diff != "" {
+			t.Errorf("%T.Run(%T) diff (-want +got):\n%s", code, subcalls, diff)
+		}
+	})
+}
+
 func ExampleCaptured() {
 	const (
 		slot  = 42
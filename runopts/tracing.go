@@ -0,0 +1,217 @@
+package runopts
+
+import (
+	"io"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/arr4n/specops/evmdebug"
+)
+
+// OnStep returns an Option that calls fn before the execution of every opcode.
+// Unlike a Debugger, execution is never blocked, making this a lightweight
+// alternative for simple instrumentation such as counting occurrences of a
+// particular opcode.
+//
+// The same CapturedState is reused for every call to fn so MUST NOT be retained
+// after fn returns. As with Debugger.State(), ownership of pointers (e.g. the
+// stack and memory in the Context) is retained by the EVM; modify with caution!
+func OnStep(fn func(*evmdebug.CapturedState)) Option {
+	return Func(func(c *Configuration) error {
+		var s evmdebug.CapturedState
+		c.addHooks(&tracing.Hooks{
+			OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+				s = evmdebug.CapturedState{
+					PC:         pc,
+					Op:         vm.OpCode(op),
+					GasLeft:    gas,
+					GasCost:    cost,
+					Context:    scope,
+					ReturnData: rData,
+					Err:        err,
+					Depth:      depth,
+				}
+				fn(&s)
+			},
+		})
+		return nil
+	})
+}
+
+// TraceOnError returns an Option that buffers a trace of every opcode executed,
+// writing it to w, in the format of geth's logger.WriteTrace(), only if the
+// execution fails; i.e. if it reverts or faults. This provides a post-mortem
+// without the output of a trace for every successful execution, but the cost
+// of tracing is still incurred.
+func TraceOnError(w io.Writer) Option {
+	return Func(func(c *Configuration) error {
+		l := logger.NewStructLogger(nil)
+		h := l.Hooks()
+
+		onTxEnd := h.OnTxEnd
+		h.OnTxEnd = func(r *types.Receipt, err error) {
+			onTxEnd(r, err)
+			if err != nil || r == nil || r.Status == types.ReceiptStatusFailed {
+				logger.WriteTrace(w, l.StructLogs())
+			}
+		}
+		c.addHooks(h)
+		return nil
+	})
+}
+
+// addHooks sets c.VMConfig.Tracer to h if there is no existing tracer,
+// otherwise it chains h after the existing one such that every hook of both is
+// called.
+func (c *Configuration) addHooks(h *tracing.Hooks) {
+	curr := c.VMConfig.Tracer
+	if curr == nil {
+		c.VMConfig.Tracer = h
+		return
+	}
+	c.VMConfig.Tracer = &tracing.Hooks{
+		// VM events
+		OnTxStart: chain(curr.OnTxStart, h.OnTxStart, func(a, b tracing.TxStartHook) tracing.TxStartHook {
+			return func(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+				a(vm, tx, from)
+				b(vm, tx, from)
+			}
+		}),
+		OnTxEnd: chain(curr.OnTxEnd, h.OnTxEnd, func(a, b tracing.TxEndHook) tracing.TxEndHook {
+			return func(receipt *types.Receipt, err error) {
+				a(receipt, err)
+				b(receipt, err)
+			}
+		}),
+		OnEnter: chain(curr.OnEnter, h.OnEnter, func(a, b tracing.EnterHook) tracing.EnterHook {
+			return func(depth int, typ byte, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+				a(depth, typ, from, to, input, gas, value)
+				b(depth, typ, from, to, input, gas, value)
+			}
+		}),
+		OnExit: chain(curr.OnExit, h.OnExit, func(a, b tracing.ExitHook) tracing.ExitHook {
+			return func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+				a(depth, output, gasUsed, err, reverted)
+				b(depth, output, gasUsed, err, reverted)
+			}
+		}),
+		OnOpcode: chain(curr.OnOpcode, h.OnOpcode, func(a, b tracing.OpcodeHook) tracing.OpcodeHook {
+			return func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+				a(pc, op, gas, cost, scope, rData, depth, err)
+				b(pc, op, gas, cost, scope, rData, depth, err)
+			}
+		}),
+		OnFault: chain(curr.OnFault, h.OnFault, func(a, b tracing.FaultHook) tracing.FaultHook {
+			return func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+				a(pc, op, gas, cost, scope, depth, err)
+				b(pc, op, gas, cost, scope, depth, err)
+			}
+		}),
+		OnGasChange: chain(curr.OnGasChange, h.OnGasChange, func(a, b tracing.GasChangeHook) tracing.GasChangeHook {
+			return func(old, new uint64, reason tracing.GasChangeReason) {
+				a(old, new, reason)
+				b(old, new, reason)
+			}
+		}),
+		// Chain events
+		OnBlockchainInit: chain(curr.OnBlockchainInit, h.OnBlockchainInit, func(a, b tracing.BlockchainInitHook) tracing.BlockchainInitHook {
+			return func(cfg *params.ChainConfig) {
+				a(cfg)
+				b(cfg)
+			}
+		}),
+		OnClose: chain(curr.OnClose, h.OnClose, func(a, b tracing.CloseHook) tracing.CloseHook {
+			return func() {
+				a()
+				b()
+			}
+		}),
+		OnBlockStart: chain(curr.OnBlockStart, h.OnBlockStart, func(a, b tracing.BlockStartHook) tracing.BlockStartHook {
+			return func(ev tracing.BlockEvent) {
+				a(ev)
+				b(ev)
+			}
+		}),
+		OnBlockEnd: chain(curr.OnBlockEnd, h.OnBlockEnd, func(a, b tracing.BlockEndHook) tracing.BlockEndHook {
+			return func(err error) {
+				a(err)
+				b(err)
+			}
+		}),
+		OnSkippedBlock: chain(curr.OnSkippedBlock, h.OnSkippedBlock, func(a, b tracing.SkippedBlockHook) tracing.SkippedBlockHook {
+			return func(ev tracing.BlockEvent) {
+				a(ev)
+				b(ev)
+			}
+		}),
+		OnGenesisBlock: chain(curr.OnGenesisBlock, h.OnGenesisBlock, func(a, b tracing.GenesisBlockHook) tracing.GenesisBlockHook {
+			return func(genesis *types.Block, alloc types.GenesisAlloc) {
+				a(genesis, alloc)
+				b(genesis, alloc)
+			}
+		}),
+		OnSystemCallStart: chain(curr.OnSystemCallStart, h.OnSystemCallStart, func(a, b tracing.OnSystemCallStartHook) tracing.OnSystemCallStartHook {
+			return func() {
+				a()
+				b()
+			}
+		}),
+		OnSystemCallEnd: chain(curr.OnSystemCallEnd, h.OnSystemCallEnd, func(a, b tracing.OnSystemCallEndHook) tracing.OnSystemCallEndHook {
+			return func() {
+				a()
+				b()
+			}
+		}),
+		// State events
+		OnBalanceChange: chain(curr.OnBalanceChange, h.OnBalanceChange, func(a, b tracing.BalanceChangeHook) tracing.BalanceChangeHook {
+			return func(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+				a(addr, prev, new, reason)
+				b(addr, prev, new, reason)
+			}
+		}),
+		OnNonceChange: chain(curr.OnNonceChange, h.OnNonceChange, func(a, b tracing.NonceChangeHook) tracing.NonceChangeHook {
+			return func(addr common.Address, prev, new uint64) {
+				a(addr, prev, new)
+				b(addr, prev, new)
+			}
+		}),
+		OnCodeChange: chain(curr.OnCodeChange, h.OnCodeChange, func(a, b tracing.CodeChangeHook) tracing.CodeChangeHook {
+			return func(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+				a(addr, prevCodeHash, prevCode, codeHash, code)
+				b(addr, prevCodeHash, prevCode, codeHash, code)
+			}
+		}),
+		OnStorageChange: chain(curr.OnStorageChange, h.OnStorageChange, func(a, b tracing.StorageChangeHook) tracing.StorageChangeHook {
+			return func(addr common.Address, slot common.Hash, prev, new common.Hash) {
+				a(addr, slot, prev, new)
+				b(addr, slot, prev, new)
+			}
+		}),
+		OnLog: chain(curr.OnLog, h.OnLog, func(a, b tracing.LogHook) tracing.LogHook {
+			return func(log *types.Log) {
+				a(log)
+				b(log)
+			}
+		}),
+	}
+}
+
+// chain returns `both(a, b)` iff neither a nor b is nil, otherwise it returns
+// whichever is non-nil (or nil if both are). F MUST be a function type.
+func chain[F any](a, b F, both func(F, F) F) F {
+	switch {
+	case reflect.ValueOf(a).IsNil():
+		return b
+	case reflect.ValueOf(b).IsNil():
+		return a
+	default:
+		return both(a, b)
+	}
+}
@@ -0,0 +1,121 @@
+package runopts_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/evmdebug"
+	"github.com/arr4n/specops/runopts"
+
+	. "github.com/arr4n/specops"
+)
+
+func TestOnStep(t *testing.T) {
+	code := Code{
+		Fn(SLOAD, PUSH0),
+		Fn(SLOAD, PUSH(1)),
+		Fn(SSTORE, PUSH(2), ADD),
+		Fn(SLOAD, PUSH(2)),
+		STOP,
+	}
+
+	var (
+		sloads, steps, lastPC uint64
+		depths                = make(map[int]bool)
+	)
+	countSLOADs := runopts.OnStep(func(s *evmdebug.CapturedState) {
+		if s.Op == vm.SLOAD {
+			sloads++
+		}
+	})
+	countSteps := runopts.OnStep(func(s *evmdebug.CapturedState) {
+		steps++
+		lastPC = s.PC
+		depths[s.Depth] = true
+	})
+
+	if _, err := code.Run(nil, countSLOADs, countSteps); err != nil {
+		t.Fatalf("%T.Run() error %v", code, err)
+	}
+
+	if got, want := sloads, uint64(3); got != want {
+		t.Errorf("%T.Run(%T(<count SLOADs>)) got %d; want %d", code, countSLOADs, got, want)
+	}
+	if got, want := steps, uint64(10); got != want {
+		t.Errorf("%T.Run(%T(<count steps>)) got %d; want %d", code, countSteps, got, want)
+	}
+	if got, want := lastPC, uint64(12); got != want {
+		t.Errorf("last %T.PC = %d; want %d", &evmdebug.CapturedState{}, got, want)
+	}
+	if diff := cmp.Diff(map[int]bool{1: true}, depths); diff != "" {
+		t.Errorf("%T.Depth values diff (-want +got):\n%s", &evmdebug.CapturedState{}, diff)
+	}
+}
+
+func TestOnStepWithDebugger(t *testing.T) {
+	code := Code{PUSH0, PUSH0, PUSH0, STOP}
+
+	var steps int
+	dbg, results, err := code.StartDebugging(nil, runopts.OnStep(func(*evmdebug.CapturedState) {
+		steps++
+	}))
+	if err != nil {
+		t.Fatalf("%T.StartDebugging() error %v", code, err)
+	}
+	dbg.FastForward()
+	if _, err := results(); err != nil {
+		t.Fatalf("%T.StartDebugging() results error %v", code, err)
+	}
+
+	if got, want := steps, len(code); got != want {
+		t.Errorf("%T.StartDebugging(%T) got %d steps; want %d", code, runopts.OnStep(nil), got, want)
+	}
+}
+
+func TestTraceOnError(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      Code
+		wantTrace bool
+	}{
+		{
+			name: "success",
+			code: Code{PUSH(1), STOP},
+		},
+		{
+			name:      "revert",
+			code:      Code{PUSH(1), Fn(REVERT, PUSH0, PUSH0)},
+			wantTrace: true,
+		},
+		{
+			name:      "fault",
+			code:      Code{PUSH(1), INVALID},
+			wantTrace: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			_, _ = tt.code.Run(nil, runopts.TraceOnError(&buf))
+
+			got := buf.String()
+			if !tt.wantTrace {
+				if got != "" {
+					t.Errorf("%T.Run(%T) wrote trace on success:\n%s", tt.code, runopts.TraceOnError(nil), got)
+				}
+				return
+			}
+			// The stack is traced before each opcode, so the PUSHed value is
+			// in that of the second.
+			for _, want := range []string{"PUSH1", "pc=00000000", "0x1"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("%T.Run(%T) wrote trace without %q:\n%s", tt.code, runopts.TraceOnError(nil), want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A SelectorTable maps function selectors to the JUMPDESTs that implement
+// them, ordered by selector value, which allows Dispatch() to perform a binary
+// search.
+type SelectorTable []SelectorEntry
+
+// A SelectorEntry is a single row of a SelectorTable.
+type SelectorEntry struct {
+	Signature string
+	Selector  [4]byte
+	Dest      JUMPDEST
+}
+
+// NewSelectorTable returns a SelectorTable mapping each function signature
+// (e.g. "transfer(address,uint256)") to its destination. An error is returned
+// if two signatures have the same selector.
+func NewSelectorTable(dests map[string]JUMPDEST) (SelectorTable, error) {
+	t := make(SelectorTable, 0, len(dests))
+	for sig, dest := range dests {
+		e := SelectorEntry{
+			Signature: sig,
+			Dest:      dest,
+		}
+		copy(e.Selector[:], crypto.Keccak256([]byte(sig)))
+		t = append(t, e)
+	}
+
+	sort.Slice(t, func(i, j int) bool {
+		return bytes.Compare(t[i].Selector[:], t[j].Selector[:]) < 0
+	})
+	for i := 1; i < len(t); i++ {
+		if prev, curr := t[i-1], t[i]; prev.Selector == curr.Selector {
+			return nil, fmt.Errorf("signatures %q and %q have the same selector %#x", prev.Signature, curr.Signature, curr.Selector)
+		}
+	}
+	return t, nil
+}
+
+// Dispatch returns Code that reads the selector from the first 4 bytes of the
+// call data and JUMPs to the respective destination, or to the fallback if
+// there is no match.
+//
+// The destination is found by a binary search over the ordered selectors,
+// switching to sequential comparisons once at most dispatchLinearMax remain,
+// so the cost of dispatch grows logarithmically with the size of the table.
+// The JUMPDESTs used internally by the search are named after the fallback so
+// a single fallback MUST NOT be shared by more than one Dispatch() of the same
+// SelectorTable.
+//
+// The selector is left on the stack at all destinations, including the
+// fallback, which SHOULD therefore be immediately followed by
+// stack.SetDepth(1) (or a greater depth if Dispatch() is used with a non-empty
+// stack).
+func (t SelectorTable) Dispatch(fallback JUMPDEST) Code {
+	return Code{
+		Fn(SHR, PUSH(224), Fn(CALLDATALOAD, PUSH0)),
+		t.search(fallback),
+	}
+}
+
+// dispatchLinearMax is the number of entries at or below which a binary
+// search is no more efficient than sequential comparisons.
+const dispatchLinearMax = 4
+
+// search returns Code that JUMPs to the destination of the selector at the top
+// of the stack if it is in t, otherwise to the fallback.
+func (t SelectorTable) search(fallback JUMPDEST) Code {
+	if len(t) <= dispatchLinearMax {
+		var code Code
+		for _, e := range t {
+			sel := e.Selector // PUSH() retains the slice
+			code = append(code, Fn(JUMPI, PUSH(e.Dest), Fn(EQ, PUSH(sel[:]), DUP1)))
+		}
+		return append(code, Fn(JUMP, PUSH(fallback)))
+	}
+
+	mid := len(t) / 2
+	sel := t[mid].Selector
+	lower := JUMPDEST(fmt.Sprintf("%s/dispatch<%#x", fallback, sel))
+	return Code{
+		Fn(JUMPI, PUSH(lower), Fn(GT, PUSH(sel[:]), DUP1)),
+		t[mid:].search(fallback),
+		// The only entry to the lower half is via the JUMPI, after which the
+		// stack depth is the same as when falling through to the upper half.
+		JUMPDEST(lower), retainDepth{},
+		t[:mid].search(fallback),
+	}
+}
@@ -0,0 +1,119 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/arr4n/specops/evmdebug"
+	"github.com/arr4n/specops/runopts"
+	"github.com/arr4n/specops/stack"
+)
+
+func TestSelectorTableDispatch(t *testing.T) {
+	sigs := map[string]JUMPDEST{
+		"foo()":                     "foo",
+		"bar(uint256)":              "bar",
+		"transfer(address,uint256)": "transfer",
+	}
+	table, err := NewSelectorTable(sigs)
+	if err != nil {
+		t.Fatalf("NewSelectorTable() error %v", err)
+	}
+
+	for i := 1; i < len(table); i++ {
+		if bytes.Compare(table[i-1].Selector[:], table[i].Selector[:]) >= 0 {
+			t.Errorf("%T not ordered by selector: %#x then %#x", table, table[i-1].Selector, table[i].Selector)
+		}
+	}
+
+	returnByte := func(b byte) Code {
+		return Code{
+			Fn(MSTORE8, PUSH0, PUSH(b)),
+			Fn(RETURN, PUSH0, PUSH(1)),
+		}
+	}
+	code := Code{
+		table.Dispatch("fallback"),
+		JUMPDEST("foo"), stack.SetDepth(1), returnByte('f'),
+		JUMPDEST("bar"), stack.SetDepth(1), returnByte('b'),
+		JUMPDEST("transfer"), stack.SetDepth(1), returnByte('t'),
+		JUMPDEST("fallback"), stack.SetDepth(1), returnByte('?'),
+	}
+
+	tests := []struct {
+		callData []byte
+		want     byte
+	}{
+		{crypto.Keccak256([]byte("foo()"))[:4], 'f'},
+		{crypto.Keccak256([]byte("bar(uint256)")), 'b'}, // trailing data ignored
+		{crypto.Keccak256([]byte("transfer(address,uint256)"))[:4], 't'},
+		{crypto.Keccak256([]byte("baz()"))[:4], '?'},
+		{nil, '?'},
+	}
+
+	for _, tt := range tests {
+		res, err := code.Run(tt.callData)
+		if err != nil {
+			t.Fatalf("%T.Run(%#x) error %v", code, tt.callData, err)
+		}
+		if got := res.ReturnData; !bytes.Equal(got, []byte{tt.want}) {
+			t.Errorf("%T.Run(%#x) got %q; want %q", code, tt.callData, got, tt.want)
+		}
+	}
+}
+
+func TestSelectorTableDispatchIsLogarithmic(t *testing.T) {
+	const n = 64
+	sigs := make(map[string]JUMPDEST)
+	code := Code{nil} // placeholder for the Dispatch()
+	for i := 0; i < n; i++ {
+		dest := JUMPDEST(fmt.Sprintf("f%d", i))
+		sigs[fmt.Sprintf("f%d()", i)] = dest
+		code = append(code,
+			JUMPDEST(dest), stack.SetDepth(1),
+			Fn(MSTORE8, PUSH0, PUSH(i)),
+			Fn(RETURN, PUSH0, PUSH(1)),
+		)
+	}
+	code = append(code, JUMPDEST("fallback"), stack.SetDepth(1), Fn(RETURN, PUSH0, PUSH0))
+
+	table, err := NewSelectorTable(sigs)
+	if err != nil {
+		t.Fatalf("NewSelectorTable() error %v", err)
+	}
+	code[0] = table.Dispatch("fallback")
+
+	// log2(n/dispatchLinearMax) GTs to find the sequence of EQs to check.
+	const maxComparisons = 4 + dispatchLinearMax
+
+	for i := 0; i <= n; i++ {
+		sig := fmt.Sprintf("f%d()", i) // i == n is a miss
+		var comparisons int
+		countComparisons := runopts.OnStep(func(s *evmdebug.CapturedState) {
+			if s.Op == vm.EQ || s.Op == vm.GT {
+				comparisons++
+			}
+		})
+
+		callData := crypto.Keccak256([]byte(sig))[:4]
+		res, err := code.Run(callData, countComparisons)
+		if err != nil {
+			t.Fatalf("%T.Run([selector of %q]) error %v", code, sig, err)
+		}
+
+		var want []byte
+		if i < n {
+			want = []byte{byte(i)}
+		}
+		if got := res.ReturnData; !bytes.Equal(got, want) {
+			t.Errorf("%T.Run([selector of %q]) got %#x; want %#x", code, sig, got, want)
+		}
+		if comparisons > maxComparisons {
+			t.Errorf("%T.Run([selector of %q]) performed %d comparisons; want <= %d", code, sig, comparisons, maxComparisons)
+		}
+	}
+}
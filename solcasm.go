@@ -0,0 +1,92 @@
+package specops
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// SolcAsm compiles the Code and returns it in the legacy assembly text format
+// emitted by solc --asm, allowing it to be diffed against, or imported into,
+// Solidity assembly workflows. Opcodes are lowercase mnemonics and PUSHed
+// values are hex literals. Each JUMPDEST is rendered as a numbered tag
+// declaration, e.g. `tag_1:`, and a PUSH of its location as the bare tag, e.g.
+// `tag_1`; tags are numbered in order of their offsets. As with solc, tag
+// declarations are indented by 2 spaces and all else by 4.
+//
+// Labels, which solc has no equivalent of, and PUSHes of all other compiler-
+// computed values (e.g. PUSHSize()) are rendered as literals, with the
+// original specops form in a trailing comment.
+//
+// SolcAsm returns an error if the bytecode contains an invalid opcode or a
+// truncated PUSH, as can be introduced with Raw.
+func (c Code) SolcAsm() (string, error) {
+	comp, err := c.compile()
+	if err != nil {
+		return "", err
+	}
+	flat := c.flatten()
+	offsets := comp.labels()
+
+	var destPCs []int
+	for _, bc := range flat {
+		if d, ok := bc.(JUMPDEST); ok {
+			destPCs = append(destPCs, offsets[string(d)])
+		}
+	}
+	sort.Ints(destPCs)
+	tagNums := make(map[int]int, len(destPCs)) // keyed by pc
+	for i, pc := range destPCs {
+		tagNums[pc] = i + 1
+	}
+
+	pushedTags := make(map[int]int)    // tag number keyed by pc of PUSH
+	comments := make(map[int][]string) // keyed by pc
+	for i, bc := range flat {
+		pc := comp.pcOf(i)
+		switch bc := bc.(type) {
+		case Label:
+			comments[pc] = append(comments[pc], bytecoderString(bc))
+		case pushTag:
+			if n, ok := tagNums[offsets[string(bc)]]; ok {
+				pushedTags[pc] = n
+				continue
+			}
+			comments[pc] = append(comments[pc], bytecoderString(bc))
+		case pushTags, pushSize, pushWide:
+			comments[pc] = append(comments[pc], bytecoderString(bc))
+		}
+	}
+
+	var out strings.Builder
+	for _, in := range disassemble(comp.bytecode) {
+		if _, ok := stackDeltas[in.op]; !ok || in.truncated {
+			return "", fmt.Errorf("invalid or truncated instruction %v at pc %d", in, in.pc)
+		}
+
+		indent := "    "
+		var line string
+		switch n, isPushedTag := pushedTags[in.pc]; {
+		case in.op == vm.JUMPDEST && tagNums[in.pc] > 0:
+			indent = "  "
+			line = fmt.Sprintf("tag_%d:", tagNums[in.pc])
+		case isPushedTag:
+			line = fmt.Sprintf("tag_%d", n)
+		case in.op == vm.PUSH0:
+			line = "0x00"
+		case in.op.IsPush():
+			line = fmt.Sprintf("%#x", in.data)
+		default:
+			line = strings.ToLower(in.op.String())
+		}
+
+		out.WriteString(indent + line)
+		if cs := comments[in.pc]; len(cs) > 0 {
+			fmt.Fprintf(&out, " /* %s */", strings.Join(cs, ", "))
+		}
+		out.WriteByte('\n')
+	}
+	return out.String(), nil
+}
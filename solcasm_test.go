@@ -0,0 +1,54 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestSolcAsm(t *testing.T) {
+	code := Code{
+		Fn(MSTORE, PUSH(0x40), PUSH(0x80)),
+		Fn(JUMPI, PUSH("end"), CALLVALUE),
+		PUSH0,
+		Label("data"),
+		PUSH("data"),
+		PUSHSize("data", "end"),
+		JUMPDEST("loop"), stack.SetDepth(3),
+		Fn(JUMP, PUSH("loop")),
+		JUMPDEST("end"), stack.SetDepth(0),
+		STOP,
+	}
+
+	got, err := code.SolcAsm()
+	if err != nil {
+		t.Fatalf("%T.SolcAsm() error %v", code, err)
+	}
+	want := `    0x80
+    0x40
+    mstore
+    callvalue
+    tag_2
+    jumpi
+    0x00
+    0x0a /* Label("data"), PUSH("data") */
+    0x08 /* PUSHSize("data", "end") */
+  tag_1:
+    tag_1
+    jump
+  tag_2:
+    stop
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%T.SolcAsm() diff (-want +got):\n%s", code, diff)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		code := Code{Raw{0x0c}}
+		if _, err := code.SolcAsm(); err == nil {
+			t.Errorf("%T{Raw{0x0c}}.SolcAsm() got nil error", code)
+		}
+	})
+}
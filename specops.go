@@ -16,6 +16,7 @@ import (
 	"reflect"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/holiman/uint256"
 
@@ -44,13 +45,57 @@ func (c Code) Bytecoders() []types.Bytecoder {
 //
 // Although the returned BytecodeHolder can contain JUMPDESTs, they're hard to
 // reason about so should be used with care.
+//
+// A stack.Transformation placed immediately after the first Bytecoder (the
+// "function") rearranges all of the remaining arguments before the function
+// consumes them. Because of the reversal, the Transformation is emitted after
+// the arguments that follow it and its indices refer to them in the order in
+// which they're written; e.g. Fn(DIV, stack.Permute(1, 0), x, y) computes y/x.
+// See the stack.Transformation examples.
+//
+// The returned value retains bcs in their original order, for use in
+// Code.String(). The order in which they will be compiled is also available
+// via its ReversedBytecoders() method, which can be accessed with a type
+// assertion.
 func Fn(bcs ...types.Bytecoder) types.BytecodeHolder {
-	c := Code(bcs)
-	for i, n := 0, len(c); i < n/2; i++ {
-		j := n - i - 1
-		c[i], c[j] = c[j], c[i]
+	return fnCall(append([]types.Bytecoder{}, bcs...))
+}
+
+// A fnCall is a Bytecoder returned by Fn(), holding its arguments in the
+// order in which they were passed (i.e. source order).
+type fnCall []types.Bytecoder
+
+// Bytecode always returns an error; the fnCall must be included in a Code.
+func (f fnCall) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", f)
+}
+
+// Bytecoders returns the result of f.ReversedBytecoders().
+func (f fnCall) Bytecoders() []types.Bytecoder {
+	return f.ReversedBytecoders()
+}
+
+// ReversedBytecoders returns the Bytecoders passed to Fn() in reverse, which is
+// the order in which they are compiled.
+func (f fnCall) ReversedBytecoders() []types.Bytecoder {
+	out := make([]types.Bytecoder, len(f))
+	for i, bc := range f {
+		out[len(f)-1-i] = bc
 	}
-	return c
+	return out
+}
+
+// Pipe is identical to Fn() except that it signals that bcs[0] deliberately
+// isn't an opcode that consumes the remaining arguments, which are instead left
+// on the stack for later use (i.e. "piped" to later code). Code.Analyze()
+// reports Fn()s that don't begin with such an opcode, but not Pipe()s.
+func Pipe(bcs ...types.Bytecoder) types.BytecodeHolder {
+	return pipeCall{fnCall(append([]types.Bytecoder{}, bcs...))}
+}
+
+// A pipeCall is a Bytecoder returned by Pipe().
+type pipeCall struct {
+	fnCall
 }
 
 // Raw is a Bytecoder that bypasses all compiler checks and simply appends its
@@ -62,6 +107,34 @@ func (r Raw) Bytecode() ([]byte, error) {
 	return []byte(r), nil
 }
 
+// Op converts an opcode into a Bytecoder, for use when the opcode is only known
+// at runtime instead of being one of the dot-imported constants. Unlike the
+// equivalent types.OpCode(op) conversion, the returned Bytecoder's Bytecode()
+// method returns an error if op is not a known opcode or if it is PUSH1 to
+// PUSH32, which require immediate data; use PUSH() instead.
+func Op(op vm.OpCode) types.Bytecoder {
+	if _, ok := stackDeltas[op]; !ok || (op.IsPush() && op != vm.PUSH0) {
+		return invalidOp(op)
+	}
+	return types.OpCode(op)
+}
+
+// An invalidOp is returned by Op() when its argument can't be used alone.
+type invalidOp vm.OpCode
+
+// Bytecode always returns an error.
+func (o invalidOp) Bytecode() ([]byte, error) {
+	if op := vm.OpCode(o); op.IsPush() {
+		return nil, fmt.Errorf("%v requires immediate data; use PUSH() instead of %v", op, o)
+	}
+	return nil, fmt.Errorf("unknown opcode %v", o)
+}
+
+// String returns a representation of the call to Op().
+func (o invalidOp) String() string {
+	return fmt.Sprintf("Op(%#02x)", byte(o))
+}
+
 // PUSHSelector returns a PUSH4 Bytecoder that pushes the selector of the
 // signature, i.e. `sha3(sig)[:4]`.
 func PUSHSelector(sig string) types.Bytecoder {
@@ -75,6 +148,16 @@ func PUSHBytes(bs ...byte) types.Bytecoder {
 	return types.BytecoderFromStackPusher(bytesPusher(bs))
 }
 
+// PushOpFor returns the PUSH<N> opcode that PUSHBytes(value...) would use,
+// without emitting it; i.e. the smallest opcode that can represent value after
+// stripping leading zeros, possibly PUSH0. The compiled size of the PUSH is
+// therefore `1 + int(PushOpFor(value) - vm.PUSH0)` bytes. It panics if value
+// has more than 32 significant bytes.
+func PushOpFor(value []byte) vm.OpCode {
+	op, _ := types.MinimalPush(value)
+	return op
+}
+
 type bytesPusher []byte
 
 func (p bytesPusher) ToPush() []byte { return []byte(p) }
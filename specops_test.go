@@ -2,8 +2,12 @@ package specops
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -12,10 +16,16 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/holiman/uint256"
 
+	"github.com/arr4n/specops/revert"
 	"github.com/arr4n/specops/stack"
 	"github.com/arr4n/specops/types"
 )
 
+// forwardGas is a fixed amount of gas for tests to forward to subcalls because
+// the default chain config predates EIP-150's 63/64 rule, so forwarding GAS
+// would fail.
+const forwardGas = 100_000
+
 // mustRunByteCode propagates arguments to runBytecode, calling log.Fatal() on
 // error, otherwise returning the result. It's useful for testable examples that
 // don't have access to t.Fatal().
@@ -27,45 +37,45 @@ func mustRunByteCode(compiled, callData []byte) []byte {
 	return out.ReturnData
 }
 
-func TestRunCompiled(t *testing.T) {
-	// hashOrEcho branches based on the first byte of calldata, which indicates
-	// whether it should hash (and return) the remaining bytes, or just echo
-	// them. It demonstrates JUMPDEST labeling as well as PUSH(<lbl>) to jump
-	// both backwards and forwards in the code.
-	hashOrEcho := Code{
-		Fn(SUB, CALLDATASIZE, PUSH(1)), // <cds-1> {}
-		// A separate Fn() moves us out of "function mode". Note that if we
-		// didn't need <cds-1> to stay on the stack we could elide DUP1 and have
-		// the result(s) of the last Fn() act as the inputs to this one. The
-		// stack.ExpectDepth(1) inside a Fn() asserts the incoming "piped" stack
-		// size and is equivalent to being placed between the two Fn()s.
-		Fn(CALLDATACOPY, PUSH0, PUSH(1), DUP1, stack.ExpectDepth(1)), // <cds-1> {cds[1:]}
-
-		Fn(SHR, PUSH(248), Fn(CALLDATALOAD, PUSH0)), // <cds-1, hash?> {cds[1:]}
-		Fn(JUMPI, PUSH("hash")),                     // <cds-1> {cds[1:]}
-
-		// Placing the return code here is unnecessarily convoluted, but acts to
-		// demonstrate backwards jumping from the end of the hashing code.
-		JUMPDEST("return"), // expecting <size> {ret}
-		stack.SetDepth(1),
-		Fn(RETURN, PUSH0),
-
-		JUMPDEST("hash"), // <cds-1> {cds[1:]}
-		stack.SetDepth(1),
-		// Nesting Fn()s provides even greater improvements to readability than
-		// chaining does. The next block is equivalent to the more complicated:
-		//
-		// Fn(KECCAK256, PUSH0)
-		// Fn(MSTORE, PUSH0 /*hash already on the stack*/)
-		Fn(
-			MSTORE, PUSH0, Fn(
-				KECCAK256, PUSH0, /*size already on the stack*/
-			),
-		), // <> {hash}
-		PUSH(0x20),               // <32>
-		Fn(JUMP, PUSH("return")), // here PUSH(string) pushes the location of the respective JUMPDEST
-	}
+// hashOrEcho branches based on the first byte of calldata, which indicates
+// whether it should hash (and return) the remaining bytes, or just echo
+// them. It demonstrates JUMPDEST labeling as well as PUSH(<lbl>) to jump
+// both backwards and forwards in the code.
+var hashOrEcho = Code{
+	Fn(SUB, CALLDATASIZE, PUSH(1)), // <cds-1> {}
+	// A separate Fn() moves us out of "function mode". Note that if we
+	// didn't need <cds-1> to stay on the stack we could elide DUP1 and have
+	// the result(s) of the last Fn() act as the inputs to this one. The
+	// stack.ExpectDepth(1) inside a Fn() asserts the incoming "piped" stack
+	// size and is equivalent to being placed between the two Fn()s.
+	Fn(CALLDATACOPY, PUSH0, PUSH(1), DUP1, stack.ExpectDepth(1)), // <cds-1> {cds[1:]}
+
+	Fn(SHR, PUSH(248), Fn(CALLDATALOAD, PUSH0)), // <cds-1, hash?> {cds[1:]}
+	Fn(JUMPI, PUSH("hash")),                     // <cds-1> {cds[1:]}
+
+	// Placing the return code here is unnecessarily convoluted, but acts to
+	// demonstrate backwards jumping from the end of the hashing code.
+	JUMPDEST("return"), // expecting <size> {ret}
+	stack.SetDepth(1),
+	Fn(RETURN, PUSH0),
+
+	JUMPDEST("hash"), // <cds-1> {cds[1:]}
+	stack.SetDepth(1),
+	// Nesting Fn()s provides even greater improvements to readability than
+	// chaining does. The next block is equivalent to the more complicated:
+	//
+	// Fn(KECCAK256, PUSH0)
+	// Fn(MSTORE, PUSH0 /*hash already on the stack*/)
+	Fn(
+		MSTORE, PUSH0, Fn(
+			KECCAK256, PUSH0, /*size already on the stack*/
+		),
+	), // <> {hash}
+	PUSH(0x20),               // <32>
+	Fn(JUMP, PUSH("return")), // here PUSH(string) pushes the location of the respective JUMPDEST
+}
 
+func TestRunCompiled(t *testing.T) {
 	type test struct {
 		name     string
 		code     Code
@@ -206,6 +216,46 @@ func TestRunCompiled(t *testing.T) {
 	}
 }
 
+func TestRunAll(t *testing.T) {
+	// Echoes the calldata, reverting if it's empty.
+	code := Code{
+		Fn(JUMPI, PUSH("echo"), CALLDATASIZE),
+		Fn(REVERT, PUSH0, PUSH0),
+		JUMPDEST("echo"), stack.SetDepth(0),
+		Fn(CALLDATACOPY, PUSH0, PUSH0, CALLDATASIZE),
+		Fn(RETURN, PUSH0, CALLDATASIZE),
+	}
+
+	inputs := [][]byte{
+		[]byte("hello"),
+		[]byte("world"),
+		{42},
+	}
+	results, err := code.RunAll(inputs)
+	if err != nil {
+		t.Fatalf("%T.RunAll(%q) error %v", code, inputs, err)
+	}
+	if got, want := len(results), len(inputs); got != want {
+		t.Fatalf("%T.RunAll(%q) got %d results; want %d", code, inputs, got, want)
+	}
+	for i, res := range results {
+		if got, want := res.ReturnData, inputs[i]; !bytes.Equal(got, want) {
+			t.Errorf("%T.RunAll(%q)[%d] got %q; want %q", code, inputs, i, got, want)
+		}
+	}
+
+	t.Run("stops at error", func(t *testing.T) {
+		inputs := [][]byte{{1}, nil, {2}}
+		results, err := code.RunAll(inputs)
+		if _, ok := revert.Data(err); !ok {
+			t.Errorf("%T.RunAll(%q) got err %v; want wrapped %T", code, inputs, err, &revert.Error{})
+		}
+		if got, want := len(results), 2; got != want {
+			t.Errorf("%T.RunAll(%q) got %d results; want %d", code, inputs, got, want)
+		}
+	})
+}
+
 func bytecode(t *testing.T, b types.Bytecoder) []byte {
 	t.Helper()
 	buf, err := b.Bytecode()
@@ -215,6 +265,148 @@ func bytecode(t *testing.T, b types.Bytecoder) []byte {
 	return buf
 }
 
+func TestCompileContext(t *testing.T) {
+	code := Code{
+		Fn(JUMP, PUSH("end")),
+		JUMPDEST("end"), stack.SetDepth(0),
+		STOP,
+	}
+
+	want, err := code.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", code, err)
+	}
+	got, err := code.CompileContext(context.Background())
+	if err != nil {
+		t.Fatalf("%T.CompileContext(context.Background()) error %v", code, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%T.CompileContext(context.Background()) got %#x; want %#x, as from Compile()", code, got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := code.CompileContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("%T.CompileContext([cancelled context]) got error %v; want %v", code, err, context.Canceled)
+	}
+}
+
+func TestCompileWithLogger(t *testing.T) {
+	code := Code{
+		Fn(JUMP, PUSH("end")),
+		JUMPDEST("end"), stack.SetDepth(0),
+		Fn(MSTORE8, PUSH0, PUSH(0x1234)), // lint diagnostic
+		stack.ExpectDepth(0),
+	}
+
+	want, err := code.Compile()
+	if err != nil {
+		t.Fatalf("%T.Compile() error %v", code, err)
+	}
+
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	got, err := code.CompileWithLogger(l)
+	if err != nil {
+		t.Fatalf("%T.CompileWithLogger() error %v", code, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%T.CompileWithLogger() got %#x; want %#x, as from Compile()", code, got, want)
+	}
+
+	msgs := make(map[string]int)
+	for dec := json.NewDecoder(&buf); dec.More(); {
+		var rec struct{ Msg string }
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("Decoding log record: %v", err)
+		}
+		msgs[rec.Msg]++
+	}
+	wantMsgs := map[string]int{
+		"compiled":       6, // flattened, excluding hints
+		"set depth":      1,
+		"expected depth": 1,
+		"expand pass":    1,
+		"resolved label": 1,
+		"diagnostic":     1,
+	}
+	if diff := cmp.Diff(wantMsgs, msgs); diff != "" {
+		t.Errorf("%T.CompileWithLogger() log messages diff (-want +got):\n%s", code, diff)
+	}
+
+	if _, err := code.CompileWithLogger(nil); err != nil {
+		t.Errorf("%T.CompileWithLogger(nil) error %v", code, err)
+	}
+}
+
+func TestExpandPasses(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want int
+	}{
+		{
+			name: "no tags",
+			code: Code{PUSH0, POP},
+			want: 1,
+		},
+		{
+			name: "no expansion",
+			code: Code{Fn(JUMP, PUSH("end")), JUMPDEST("end"), stack.SetDepth(0)},
+			want: 1,
+		},
+		{
+			// JUMPDESTs beyond offset 255 require PUSH2s to reach them.
+			name: "expansion",
+			code: Code{
+				Fn(JUMP, PUSH("a")),
+				Fn(JUMP, PUSH("b")),
+				Raw(make([]byte, 256)),
+				JUMPDEST("a"), stack.SetDepth(0),
+				JUMPDEST("b"), stack.SetDepth(0),
+			},
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.ExpandPasses()
+			if err != nil {
+				t.Fatalf("%T.ExpandPasses() error %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("%T.ExpandPasses() got %d; want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabels(t *testing.T) {
+	code := Code{
+		Fn(JUMP, PUSH("end")), // PUSH2 at 0, JUMP at 3
+		Label("data"),
+		Raw(make([]byte, 300)),
+		JUMPDEST("end"), stack.SetDepth(0),
+		STOP,
+	}
+	got, err := code.Labels()
+	if err != nil {
+		t.Fatalf("%T.Labels() error %v", code, err)
+	}
+	want := map[string]int{
+		"data": 4,
+		"end":  304,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%T.Labels() diff (-want +got):\n%s", code, diff)
+	}
+
+	if _, err := (Code{Fn(JUMP, PUSH("missing"))}).Labels(); err == nil {
+		t.Errorf("%T.Labels() with PUSH of undefined label; got nil error", Code{})
+	}
+}
+
 func TestPUSHZeroes(t *testing.T) {
 	push0 := []byte{byte(vm.PUSH0)}
 
@@ -261,6 +453,97 @@ func TestPUSHZeroes(t *testing.T) {
 	})
 }
 
+func TestPushOpFor(t *testing.T) {
+	for _, value := range [][]byte{
+		{},
+		{0},
+		{1},
+		{0, 0, 1},
+		{0xff, 0},
+		bytes.Repeat([]byte{0xff}, 32),
+		append(make([]byte, 8), bytes.Repeat([]byte{1}, 32)...),
+	} {
+		got := PushOpFor(value)
+
+		var want vm.OpCode
+		if len(value) == 0 {
+			want = vm.PUSH0 // PUSHBytes() requires [1,32] bytes
+		} else {
+			want = vm.OpCode(bytecode(t, PUSHBytes(value[max(0, len(value)-32):]...))[0])
+		}
+		if got != want {
+			t.Errorf("PushOpFor(%#x) got %v; want %v", value, got, want)
+		}
+	}
+
+	t.Run("too large", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("PushOpFor([33 non-zero bytes]) didn't panic")
+			}
+		}()
+		PushOpFor(bytes.Repeat([]byte{1}, 33))
+	})
+}
+
+func TestOp(t *testing.T) {
+	for _, op := range []vm.OpCode{vm.STOP, vm.ADD, vm.PUSH0, vm.DUP16, vm.INVALID} {
+		if got, want := bytecode(t, Op(op)), []byte{byte(op)}; !bytes.Equal(got, want) {
+			t.Errorf("Op(%v).Bytecode() got %#x; want %#x", op, got, want)
+		}
+	}
+
+	for _, op := range []vm.OpCode{0x0c, 0xef, vm.PUSH1, vm.PUSH32} {
+		code := Code{Op(op)}
+		if _, err := code.Compile(); err == nil {
+			t.Errorf("%T{Op(%#02x)}.Compile() got nil error; want non-nil", code, byte(op))
+		}
+	}
+}
+
+func TestStackFromTopAndBottom(t *testing.T) {
+	base := Code{PUSH(1), PUSH(2), PUSH(3)}
+
+	tests := []struct {
+		ref  types.Bytecoder
+		want types.Bytecoder
+	}{
+		{stack.FromTop(DUP1), DUP1},
+		{stack.FromTop(SWAP2), SWAP2},
+		{stack.FromBottom(DUP1), Inverted(DUP1)},
+		{stack.FromBottom(DUP1), DUP3},
+		{stack.FromBottom(SWAP1), SWAP2},
+		{stack.FromBottom(SWAP2), SWAP1},
+	}
+
+	for _, tt := range tests {
+		code := Code{base, tt.ref}
+		got, err := code.Compile()
+		if err != nil {
+			t.Errorf("%T{…, %v}.Compile() error %v", code, tt.ref, err)
+			continue
+		}
+		want, err := Code{base, tt.want}.Compile()
+		if err != nil {
+			t.Fatalf("Compile() error %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%T{…, %v}.Compile() got %#x; want %#x as with %v", code, tt.ref, got, want, tt.want)
+		}
+	}
+
+	for _, bad := range []types.Bytecoder{
+		stack.FromTop(ADD),
+		stack.FromBottom(ADD),
+		stack.FromBottom(SWAP3), // would be a no-op
+	} {
+		code := Code{base, bad}
+		if _, err := code.Compile(); err == nil {
+			t.Errorf("%T{…, %v}.Compile() got nil error", code, bad)
+		}
+	}
+}
+
 func TestNoCallBytecode(t *testing.T) {
 	// Some special Bytecoder implementations are only compiler hints and should
 	// never have their Bytecode() method called. This artificially reduces test
@@ -274,7 +557,13 @@ func TestNoCallBytecode(t *testing.T) {
 		pushSize{},
 		stack.ExpectDepth(0),
 		stack.SetDepth(0),
+		stack.Assert(),
 		Inverted(0),
+		stack.FromTop(0),
+		stack.FromBottom(0),
+		bytecodeAssertion(nil),
+		tableWidth{},
+		retainDepth{},
 	} {
 		if _, err := b.Bytecode(); err == nil {
 			t.Errorf("Special Bytecoder %T.Bytecode() returned non-nil error", b)
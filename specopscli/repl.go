@@ -0,0 +1,101 @@
+package specopscli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops"
+	"github.com/arr4n/specops/evmdebug"
+	"github.com/arr4n/specops/runopts"
+)
+
+const replPrompt = "> "
+
+// repl reads lines of text assembly (see specops.ParseAssembly()) from in,
+// appending each to the program and re-executing it through a debugger,
+// writing the resulting stack to out. Lines that fail to parse, compile, or
+// execute to the end of the program are reported and discarded.
+func repl(in io.Reader, out io.Writer) error {
+	var code specops.Code
+
+	fmt.Fprint(out, replPrompt)
+	s := bufio.NewScanner(in)
+	for s.Scan() {
+		next, err := replLine(code, s.Text(), out)
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		} else {
+			code = next
+		}
+		fmt.Fprint(out, replPrompt)
+	}
+	fmt.Fprintln(out)
+	return s.Err()
+}
+
+// replLine parses the line and appends it to the code, which it then executes,
+// writing the stack to out. The extended Code is returned, unless there is an
+// error.
+func replLine(code specops.Code, line string, out io.Writer) (specops.Code, error) {
+	add, err := specops.ParseAssembly(line)
+	if err != nil || len(add) == 0 {
+		return code, err
+	}
+	code = append(slices.Clip(code), add...)
+
+	stack, err := stackAtEnd(code)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		buf := stack[i].Bytes()
+		if stack[i].IsZero() {
+			buf = []byte{0}
+		}
+		fmt.Fprintf(out, "%4d %#x\n", i+1, buf)
+	}
+	return code, nil
+}
+
+// stackAtEnd runs the code through a debugger, returning a copy of the stack
+// once the last instruction has been executed.
+func stackAtEnd(code specops.Code) ([]uint256.Int, error) {
+	bytecode := runopts.CaptureBytecode()
+	dbg, results, err := code.StartDebugging(nil, bytecode)
+	if err != nil {
+		return nil, err
+	}
+	defer dbg.FastForward()
+
+	end := uint64(len(bytecode.Val))
+	atEnd := func(s *evmdebug.CapturedState) bool {
+		switch s.Op {
+		case vm.STOP, vm.RETURN, vm.REVERT, vm.INVALID:
+			// There is no subsequent stack to inspect.
+			return false
+		}
+		size := uint64(1)
+		if s.Op.IsPush() {
+			size += uint64(s.Op - vm.PUSH0)
+		}
+		return s.Err == nil && s.PC+size == end
+	}
+	if !dbg.RunUntil(atEnd) {
+		if _, err := results(); err != nil {
+			return nil, err
+		}
+		if err := dbg.State().Err; err != nil {
+			return nil, err
+		}
+		return nil, errors.New("execution halted before end of code")
+	}
+	// The EVM is blocked before the implicit STOP at the end of the code, so
+	// the stack is still valid.
+	return slices.Clone(dbg.State().Context.StackData()), nil
+}
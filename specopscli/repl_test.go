@@ -0,0 +1,48 @@
+package specopscli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestREPL(t *testing.T) {
+	in := strings.Join([]string{
+		"PUSH 1",
+		"PUSH 0x2a",
+		"NOPE", // unknown opcode
+		"ADD",  // 1+42
+		"ADD",  // underflow
+		"",     // ignored
+		"DUP1",
+		"STOP", // halts before the end
+		"PUSH0",
+		"REVERT", // uses the top 2 items
+	}, "\n")
+
+	var out strings.Builder
+	if err := repl(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("repl() error %v", err)
+	}
+
+	want := strings.Join([]string{
+		`>    1 0x01`,
+		`>    2 0x2a`,
+		`   1 0x01`,
+		`> Error: line 1: unknown opcode "NOPE"`,
+		`>    1 0x2b`,
+		`> Error: specops.Code.Compile(): specops.Code[3]: Bytecode()[0] popping 2 values with stack depth 1`,
+		`> >    2 0x2b`,
+		`   1 0x2b`,
+		`> Error: execution halted before end of code`,
+		`>    3 0x00`,
+		`   2 0x2b`,
+		`   1 0x2b`,
+		`> Error: execution reverted`,
+		"> \n",
+	}, "\n")
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("repl() output diff (-want +got):\n%s", diff)
+	}
+}
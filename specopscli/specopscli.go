@@ -2,8 +2,12 @@
 package specopscli
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"time"
 
 	"github.com/arr4n/specops"
 	"github.com/spf13/cobra"
@@ -48,13 +52,45 @@ func run(code specops.Code) error {
 		},
 	}
 
+	var atFault bool
 	debug := &cobra.Command{
 		Use:   "debug",
 		Short: "Compile then debug bytecode execution",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if atFault {
+				return code.RunTerminalDebuggerAtFault(callData)
+			}
 			return code.RunTerminalDebugger(callData)
 		},
 	}
+	debug.Flags().BoolVar(&atFault, "at-fault", false, "Start at the first fault (e.g. REVERT), if any")
+
+	replCmd := &cobra.Command{
+		Use:   "repl",
+		Short: "Interactively append opcodes, one per line, printing the resulting stack (ignores Code)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return repl(cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	var watchFile bool
+	assembleCmd := &cobra.Command{
+		Use:   "assemble <file>",
+		Short: "Compile text assembly from a file, one opcode per line (ignores Code)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, out := args[0], cmd.OutOrStdout()
+			if !watchFile {
+				return assemble(path, out)
+			}
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+			return watch(ctx, path, 250*time.Millisecond, cmd.ErrOrStderr(), func() error {
+				return assemble(path, out)
+			})
+		},
+	}
+	assembleCmd.Flags().BoolVarP(&watchFile, "watch", "w", false, "Recompile whenever the file changes, until interrupted")
 
 	for _, c := range []*cobra.Command{exec, debug} {
 		c.Flags().BytesHexVarP(&callData, "calldata", "d", nil, "Call data")
@@ -70,6 +106,8 @@ func run(code specops.Code) error {
 		compile,
 		exec,
 		debug,
+		replCmd,
+		assembleCmd,
 	)
 	return cmd.Execute()
 }
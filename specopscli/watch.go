@@ -0,0 +1,61 @@
+package specopscli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/arr4n/specops"
+)
+
+// assemble reads text assembly (see specops.ParseAssembly()) from the file,
+// writing the compiled bytecode to out.
+func assemble(path string, out io.Writer) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	code, err := specops.ParseAssembly(string(src))
+	if err != nil {
+		return err
+	}
+	bytecode, err := code.Compile()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%#x\n", bytecode)
+	return nil
+}
+
+// watch calls fn immediately and then every time that the file at path is
+// modified, until ctx is cancelled. Modification is detected by polling the
+// file's size and modification time at the specified interval, which avoids
+// platform-specific notification mechanisms for what is only a development
+// loop. Errors returned by fn are written to errOut and don't stop the
+// watching; watch only returns an error if the file can't be stat()ed.
+func watch(ctx context.Context, path string, interval time.Duration, errOut io.Writer, fn func() error) error {
+	var last os.FileInfo
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+			last = info
+			if err := fn(); err != nil {
+				fmt.Fprintf(errOut, "Error: %v\n", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package specopscli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuilder is a strings.Builder that is safe for concurrent use.
+type syncBuilder struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *syncBuilder) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuilder) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestWatchAssemble(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "code.asm")
+	write := func(src string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0600); err != nil {
+			t.Fatalf("os.WriteFile() error %v", err)
+		}
+	}
+	write("PUSH 1\nPUSH 2\nADD")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out, errOut syncBuilder
+	done := make(chan error)
+	go func() {
+		done <- watch(ctx, path, time.Millisecond, &errOut, func() error {
+			return assemble(path, &out)
+		})
+	}()
+
+	waitFor := func(b *syncBuilder, want string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if b.String() == want {
+				return
+			}
+		}
+		t.Fatalf("got output %q; want %q", b.String(), want)
+	}
+
+	waitFor(&out, "0x6001600201\n")
+	write("NOPE") // different size, regardless of modification-time granularity
+	waitFor(&errOut, "Error: line 1: unknown opcode \"NOPE\"\n")
+	write("PUSH0\nPUSH0\nMUL")
+	waitFor(&out, "0x6001600201\n0x5f5f02\n")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watch() error %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package specops
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// labelStabilityCompilations is the number of times that CheckLabelStability()
+// compiles the unmodified Code.
+const labelStabilityCompilations = 8
+
+// CheckLabelStability returns an error if the layout of the compiled Code
+// depends on anything other than the Code itself, such as the order in which
+// labels are processed. It is a test-oriented utility for guarding against
+// order-dependence in the algorithm that sizes PUSHes of JUMPDEST and Label
+// locations.
+//
+// The Code is compiled multiple times, which exercises the random iteration
+// order of Go maps, and then once more after renaming every label such that
+// their lexical order is reversed. The bytecode of every compilation, and the
+// location of every label, MUST be identical.
+func CheckLabelStability(code Code) error {
+	want, err := code.compile()
+	if err != nil {
+		return err
+	}
+	wantLabels := want.labels()
+
+	for i := 1; i < labelStabilityCompilations; i++ {
+		got, err := code.compile()
+		if err != nil {
+			return fmt.Errorf("compilation %d: %v", i, err)
+		}
+		if !bytes.Equal(got.bytecode, want.bytecode) {
+			return fmt.Errorf("compilation %d bytecode %#x differs from first %#x", i, got.bytecode, want.bytecode)
+		}
+	}
+
+	renamed, names := code.reverseLabelOrder()
+	got, err := renamed.compile()
+	if err != nil {
+		return fmt.Errorf("compilation with renamed labels: %v", err)
+	}
+	if !bytes.Equal(got.bytecode, want.bytecode) {
+		return fmt.Errorf("bytecode %#x with renamed labels differs from original %#x", got.bytecode, want.bytecode)
+	}
+	gotLabels := got.labels()
+	for name, loc := range wantLabels {
+		if r := string(names[tag(name)]); gotLabels[r] != loc {
+			return fmt.Errorf("label %q at %d but renamed to %q at %d", name, loc, r, gotLabels[r])
+		}
+	}
+	return nil
+}
+
+// reverseLabelOrder returns a flattened equivalent of the Code in which every
+// JUMPDEST and Label, along with every reference to them, is renamed such that
+// the lexical order of the names is reversed. The returned map is keyed by
+// original name.
+func (c Code) reverseLabelOrder() (Code, map[tag]tag) {
+	flat := c.flatten()
+
+	var tags []tag
+	seen := make(map[tag]bool)
+	add := func(ts ...tag) {
+		for _, t := range ts {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	for _, bc := range flat {
+		switch bc := bc.(type) {
+		case tagged:
+			add(bc.tag())
+		case pushTag:
+			add(tag(bc))
+		case pushWide:
+			add(tag(bc))
+		case pushTags:
+			add(bc...)
+		case pushSize:
+			add(bc[:]...)
+		case rjump:
+			add(tag(bc))
+		case rjumpi:
+			add(tag(bc))
+		case rjumpv:
+			add(bc...)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	names := make(map[tag]tag, len(tags))
+	for i, t := range tags {
+		names[t] = tag(fmt.Sprintf("label%06d", len(tags)-1-i))
+	}
+
+	out := make(Code, len(flat))
+	for i, bc := range flat {
+		switch bc := bc.(type) {
+		case JUMPDEST:
+			out[i] = JUMPDEST(names[tag(bc)])
+		case Label:
+			out[i] = Label(names[tag(bc)])
+		case pushTag:
+			out[i] = pushTag(names[tag(bc)])
+		case pushWide:
+			out[i] = pushWide(names[tag(bc)])
+		case pushTags:
+			ts := make(pushTags, len(bc))
+			for j, t := range bc {
+				ts[j] = names[t]
+			}
+			out[i] = ts
+		case pushSize:
+			out[i] = pushSize{names[bc[0]], names[bc[1]]}
+		case rjump:
+			out[i] = rjump(names[tag(bc)])
+		case rjumpi:
+			out[i] = rjumpi(names[tag(bc)])
+		case rjumpv:
+			ts := make(rjumpv, len(bc))
+			for j, t := range bc {
+				ts[j] = names[t]
+			}
+			out[i] = ts
+		default:
+			out[i] = bc
+		}
+	}
+	return out, names
+}
@@ -0,0 +1,77 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestCheckLabelStability(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    Code
+		wantErr bool
+	}{
+		{
+			name: "no labels",
+			code: Code{PUSH0, STOP},
+		},
+		{
+			// Forward and backward references that straddle the boundary at
+			// which PUSH1 no longer suffices, along with jump tables and sizes.
+			name: "expansion",
+			code: Code{
+				Fn(JUMP, PUSH("z")),
+				JUMPDEST("a"), stack.SetDepth(0),
+				PUSH([]string{"a", "m", "z"}),
+				Label("m"),
+				Raw(make([]byte, 250)),
+				PUSHSize("a", "m"),
+				Fn(JUMP, PUSH("a")),
+				JUMPDEST("z"), stack.SetDepth(0),
+				Fn(JUMP, PUSH("m")),
+			},
+		},
+		{
+			name:    "compilation error",
+			code:    Code{Fn(JUMP, PUSH("missing"))},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckLabelStability(tt.code); (err != nil) != tt.wantErr {
+				t.Errorf("CheckLabelStability() got err %v; want error = %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReverseLabelOrder(t *testing.T) {
+	code := Code{
+		JUMPDEST("b"),
+		Label("a"),
+		Fn(JUMP, PUSH("c")),
+		JUMPDEST("c"),
+		PUSHSize("a", "b"),
+	}
+	got, names := code.reverseLabelOrder()
+
+	want := Code{
+		JUMPDEST("label000001"),
+		Label("label000002"),
+		pushTag("label000000"),
+		JUMP,
+		JUMPDEST("label000000"),
+		pushSize{"label000002", "label000001"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("reverseLabelOrder() Code diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[tag]tag{"a": "label000002", "b": "label000001", "c": "label000000"}, names); diff != "" {
+		t.Errorf("reverseLabelOrder() names diff (-want +got):\n%s", diff)
+	}
+}
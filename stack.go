@@ -27,10 +27,22 @@ type stackDelta struct {
 // `Inverted(SWAP1)` being the bottom of a (sub-16-depth) stack.
 //
 // See stack.SetDepth() for caveats. It is best practice to use `Inverted` in
-// conjunction with stack.{Set/Expect}Depth().
+// conjunction with stack.{Set/Expect}Depth(). The equivalent stack.FromBottom,
+// along with stack.FromTop, can be used to make the direction explicit.
 type Inverted vm.OpCode
 
 // Bytecode always returns an error.
 func (i Inverted) Bytecode() ([]byte, error) {
 	return nil, fmt.Errorf("call to %T.Bytecode()", i)
 }
+
+// retainDepth is a compiler hint that, like stack.SetDepth, satisfies the
+// requirement for a JUMPDEST to be followed by an explicit depth, but retains
+// the depth tracked through the preceding code. It MUST only be used where
+// every JUMP to the JUMPDEST occurs at that same depth.
+type retainDepth struct{}
+
+// Bytecode always returns an error.
+func (r retainDepth) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", r)
+}
@@ -2,7 +2,11 @@
 // code.
 package stack
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
 
 // ExpectDepth is a sentinel value that singals to Code.Compile() that it must
 // assert the expected stack depth, returning an error if incorrect. See
@@ -15,6 +19,24 @@ func (d ExpectDepth) Bytecode() ([]byte, error) {
 	return nil, fmt.Errorf("call to %T.Bytecode()", d)
 }
 
+// ExpectDepthRuntime is a sentinel value that signals to
+// specops.Code.Compile() that it must assert the expected stack depth, exactly
+// as with ExpectDepth, and also emit bytecode that checks the depth at
+// runtime. The check causes an exceptional halt if the concrete depth is
+// greater than expected or, for expectations up to 16, less than expected;
+// deeper expectations are only checked for having at least 16 items.
+//
+// This is intended only for debugging cases in which the crude compile-time
+// tracker is wrong (e.g. after a computed JUMP), as it adds dozens of bytes and
+// tens of thousands of gas; it works by temporarily filling the stack to its
+// limit of 1024 items. Expected depths greater than 1019 are not supported.
+type ExpectDepthRuntime uint
+
+// Bytecode always returns an error.
+func (d ExpectDepthRuntime) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", d)
+}
+
 // SetDepth is a sentinel value that signals to specops.Code.Compile() that it
 // must modify its internal counter reflecting the current stack depth.
 //
@@ -28,3 +50,85 @@ type SetDepth uint
 func (d SetDepth) Bytecode() ([]byte, error) {
 	return nil, fmt.Errorf("call to %T.Bytecode()", d)
 }
+
+// Assert returns a sentinel value that signals to specops.Code.Compile() that
+// it must assert both the stack depth, as with ExpectDepth, and the names of
+// all items on the stack, returning an error if either differs from its model.
+// The items are named from the top of the stack, such that items[0] is the
+// top, consistent with the indices used by Permute() and Transform().
+//
+// Names are tracked through straight-line code: DUPs copy a name and SWAPs
+// exchange them, whereas all other opcodes (including PUSHes) consume their
+// arguments and produce unnamed values. An unnamed value adopts the name given
+// to it by the next Assert(), while a named value must retain the same name.
+// An empty string passed to Assert() matches any item without naming it.
+// SetDepth, typically used after a JUMPDEST, clears all names.
+func Assert(items ...string) Model {
+	return Model(items)
+}
+
+// A Model is a sentinel value returned by Assert().
+type Model []string
+
+// Bytecode always returns an error.
+func (m Model) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", m)
+}
+
+// Name returns a sentinel value that signals to specops.Code.Compile() that it
+// must name the top len(items) items on the stack, such that items[0] is the
+// top, for later reference by Ref. Unlike Assert(), deeper items aren't
+// checked and existing names are replaced; an empty string leaves the
+// respective item's name unchanged. It is typically placed after an Fn() that
+// leaves values on the stack (i.e. "pipes" them to later code) to make their
+// use explicit; names are tracked as described by Assert().
+func Name(items ...string) Names {
+	return Names(items)
+}
+
+// Names is a sentinel value returned by Name().
+type Names []string
+
+// Bytecode always returns an error.
+func (n Names) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", n)
+}
+
+// Ref is a sentinel value that signals to specops.Code.Compile() that it must
+// DUP the stack item with the name, as set by Name() or Assert(), returning an
+// error if there is no such item within reach of DUP16. If multiple items have
+// the name (e.g. because it was already DUPed) then the one closest to the top
+// is used. The named item remains on the stack, so it MAY be Ref()ed again
+// but typically needs to be explicitly POPped once no longer needed.
+//
+// Unlike a literal DUP<N>, the required N is determined at the point of use,
+// so needn't be recalculated when preceding code, such as other arguments to
+// the same Fn(), changes the stack depth.
+type Ref string
+
+// Bytecode always returns an error.
+func (r Ref) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", r)
+}
+
+// FromTop is a DUP<N> or SWAP<N> opcode that refers to stack items counted from
+// the top, exactly as the regular opcode does. It exists only to make the
+// direction explicit when used alongside FromBottom, and compiles to the
+// opcode itself; e.g. `FromTop(DUP1)` duplicates the top of the stack.
+type FromTop vm.OpCode
+
+// Bytecode always returns an error.
+func (t FromTop) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", t)
+}
+
+// FromBottom is a DUP<N> or SWAP<N> opcode that refers to stack items counted
+// from the bottom, as tracked by specops.Code.Compile(); e.g. `FromBottom(DUP1)`
+// duplicates the bottom-most item. It is equivalent to specops.Inverted, with
+// the same caveats regarding stacks deeper than 16 items and SetDepth().
+type FromBottom vm.OpCode
+
+// Bytecode always returns an error.
+func (b FromBottom) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", b)
+}
@@ -2,6 +2,8 @@ package stack
 
 import (
 	"fmt"
+	"math"
+	"math/bits"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -17,14 +19,21 @@ const (
 )
 
 // A Transformation transforms the stack by modifying its order, growing, and/or
-// shrinking it.
+// shrinking it. Index 0 refers to the top of the stack. It can be used as an
+// argument to specops.Fn() to rearrange the other arguments, as demonstrated
+// in the examples.
 type Transformation struct {
-	typ      xFormType
-	depth    uint8
-	indices  []uint8
-	override []types.OpCode
+	typ         xFormType
+	depth       uint8
+	indices     []uint8
+	override    []types.OpCode
+	searchLimit uint64
 }
 
+// DefaultSearchLimit is the bound used by Transformation.Feasible() unless
+// overridden with Transformation.WithSearchLimit().
+const DefaultSearchLimit uint64 = 1e7
+
 // Permute returns a Transformation that permutes the order of the stack. The
 // indices MUST be a contiguous set of distinct values [0,n) in any order.
 //
@@ -71,21 +80,90 @@ func (t *Transformation) WithOps(ops ...types.OpCode) *Transformation {
 	return t
 }
 
-// Bytecode returns the stack-transforming opcodes (SWAP, DUP, etc) necessary to
-// achieve the transformation in the most efficient manner.
-func (t *Transformation) Bytecode() ([]byte, error) {
-	var sizer func() (int, error)
+// WithSearchLimit overrides DefaultSearchLimit for the purposes of t.Feasible().
+//
+// WithSearchLimit modifies t and then returns it.
+func (t *Transformation) WithSearchLimit(n uint64) *Transformation {
+	t.searchLimit = n
+	return t
+}
+
+// Feasible returns an error if t is invalid or if the search for the most
+// efficient opcodes is estimated to exceed the search limit (see
+// WithSearchLimit()), in which case WithOps() SHOULD be used instead. If
+// WithOps() has been called then no search is required so only the indices are
+// validated.
+//
+// The estimate is a coarse upper bound on the number of stack orders that may
+// be visited: n! for a permutation of n items, and k^k for a general
+// transformation where k is the greater of the depth and the number of
+// indices. Feasible doesn't perform the search and is therefore cheap to call
+// before Bytecode().
+func (t *Transformation) Feasible() error {
+	if _, err := t.size(); err != nil {
+		return err
+	}
+	if len(t.override) != 0 {
+		return nil
+	}
+
+	limit := t.searchLimit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if est := t.searchSpace(); est > limit {
+		return fmt.Errorf("estimated search space %d for %T%v exceeds limit %d; use WithOps()", est, t, t.indices, limit)
+	}
+	return nil
+}
+
+// searchSpace returns the estimate described by Feasible(), saturating at the
+// maximum uint64. It MUST NOT be called before t.size().
+func (t *Transformation) searchSpace() uint64 {
+	n := uint64(t.depth)
+	if t.typ == permutation {
+		var est uint64 = 1
+		for i := uint64(2); i <= n; i++ {
+			est = saturatingMul(est, i)
+		}
+		return est
+	}
+
+	if m := uint64(len(t.indices)); m > n {
+		n = m
+	}
+	var est uint64 = 1
+	for i := uint64(0); i < n; i++ {
+		est = saturatingMul(est, n)
+	}
+	return est
+}
 
+func saturatingMul(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
+
+// size returns the result of the transformation-type-specific sizing method,
+// which also validates t.
+func (t *Transformation) size() (int, error) {
 	switch t.typ {
 	case permutation:
-		sizer = t.permutationSize
+		return t.permutationSize()
 	case general:
-		sizer = t.generalSize
+		return t.generalSize()
 	default:
-		return nil, fmt.Errorf("invalid %T.typ = %d", t, t.typ)
+		return 0, fmt.Errorf("invalid %T.typ = %d", t, t.typ)
 	}
+}
 
-	size, err := sizer()
+// Bytecode returns the stack-transforming opcodes (SWAP, DUP, etc) necessary to
+// achieve the transformation in the most efficient manner.
+func (t *Transformation) Bytecode() ([]byte, error) {
+	size, err := t.size()
 	if err != nil {
 		return nil, err
 	}
@@ -5,14 +5,15 @@ package stack_test
 import (
 	"fmt"
 	"log"
+	"math"
+	"math/big"
 	"math/rand"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/core/vm"
-	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/arr4n/specops/evmdebug"
 	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
 
 	. "github.com/arr4n/specops"
 )
@@ -79,6 +80,52 @@ func ExampleTransformation() {
 	// Noop Permute []
 }
 
+func ExampleTransformation_fn() {
+	// Within an Fn(), a Transformation immediately following the function
+	// opcode rearranges the arguments that follow it, which are referred to by
+	// index in the order in which they're written, before the function
+	// consumes them.
+	egs := []struct {
+		desc string
+		fn   types.Bytecoder
+	}{
+		{
+			desc: "DIV(2, 10)",
+			fn:   Fn(DIV, PUSH(2), PUSH(10)),
+		},
+		{
+			desc: "DIV(2, 10) with arguments swapped",
+			fn:   Fn(DIV, stack.Permute(1, 0), PUSH(2), PUSH(10)),
+		},
+		{
+			desc: "MUL(7) with argument duplicated",
+			fn:   Fn(MUL, stack.Transform(1)(0, 0), PUSH(7)),
+		},
+		{
+			desc: "SUB(1, 2, 10) with first argument dropped and others swapped",
+			fn:   Fn(SUB, stack.Transform(3)(2, 1), PUSH(1), PUSH(2), PUSH(10)),
+		},
+	}
+
+	for _, eg := range egs {
+		code := Code{
+			Fn(MSTORE, PUSH0, eg.fn),
+			Fn(RETURN, PUSH0, PUSH(32)),
+		}
+		res, err := code.Run(nil)
+		if err != nil {
+			log.Fatalf("%s: %T.Run() error %v", eg.desc, code, err)
+		}
+		fmt.Println(eg.desc, "=", new(big.Int).SetBytes(res.ReturnData))
+	}
+
+	// Output:
+	// DIV(2, 10) = 0
+	// DIV(2, 10) with arguments swapped = 5
+	// MUL(7) with argument duplicated = 49
+	// SUB(1, 2, 10) with first argument dropped and others swapped = 8
+}
+
 func intPtr(x int) *int {
 	return &x
 }
@@ -279,21 +326,77 @@ func stackTest(dbg *evmdebug.Debugger, want8 []uint8) func(*testing.T) {
 			t.Fatalf("%T.State().Err = %v; want nil", dbg, st.Err)
 		}
 
-		var got []uint64
-		for i, n := 0, len(st.Context.StackData()); i < n; i++ {
-			g := st.StackBack(i)
-			if !g.IsUint64() {
-				t.Fatalf("%T.State().StackBack(%d) not representable as uint64", dbg, i)
-			}
-			got = append(got, g.Uint64())
-		}
-
 		want := make([]uint64, len(want8))
 		for i, w := range want8 {
 			want[i] = uint64(w)
 		}
-		if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
-			t.Errorf("Stack [top to bottom] diff (-want +got):\n%s", diff)
+		evmdebug.AssertStack(t, dbg, want)
+	}
+}
+
+func TestTransformationFeasible(t *testing.T) {
+	seq := func(n uint8) []uint8 {
+		s := make([]uint8, n)
+		for i := range s {
+			s[i] = n - 1 - uint8(i)
 		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		xform   *stack.Transformation
+		wantErr bool
+	}{
+		{
+			name:  "small Permute",
+			xform: stack.Permute(seq(8)...), // 8! = 40320
+		},
+		{
+			name:    "large Permute",
+			xform:   stack.Permute(seq(16)...),
+			wantErr: true,
+		},
+		{
+			name:  "large Permute with raised limit",
+			xform: stack.Permute(seq(16)...).WithSearchLimit(math.MaxUint64),
+		},
+		{
+			name:    "small Permute with lowered limit",
+			xform:   stack.Permute(seq(8)...).WithSearchLimit(40319),
+			wantErr: true,
+		},
+		{
+			name:  "large Permute WithOps",
+			xform: stack.Permute(seq(16)...).WithOps(SWAP1), // not validated
+		},
+		{
+			name:  "small Transform",
+			xform: stack.Transform(4)(0, 0, 3), // 4^4 = 256
+		},
+		{
+			name:    "deep Transform",
+			xform:   stack.Transform(16)(0),
+			wantErr: true,
+		},
+		{
+			name:    "invalid Permute",
+			xform:   stack.Permute(0, 0),
+			wantErr: true,
+		},
+		{
+			name:    "invalid Transform",
+			xform:   stack.Transform(2)(2),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.xform.Feasible()
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("%T.Feasible() got err %v; want err %t", tt.xform, err, tt.wantErr)
+			}
+		})
 	}
 }
@@ -0,0 +1,132 @@
+package specops
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+)
+
+// A stackModel tracks the names of items on the stack, as described by
+// stack.Assert(). The top of the stack is the last element, and unnamed items
+// are empty strings. Its length always equals the compiler's belief about the
+// stack depth.
+type stackModel []string
+
+// setDepth replaces the model with `depth` unnamed items.
+func (m *stackModel) setDepth(depth uint) {
+	*m = make(stackModel, depth)
+}
+
+// push adds an unnamed item to the top of the stack.
+func (m *stackModel) push() {
+	*m = append(*m, "")
+}
+
+// apply updates the model to reflect execution of op. The caller MUST have
+// already confirmed that there is sufficient depth, as defined by stackDeltas.
+func (m *stackModel) apply(op vm.OpCode) {
+	s := *m
+	n := len(s)
+
+	// The compiler only checks DUPs and SWAPs for a minimum depth of 1 (see
+	// stackDeltas), so deeper items may be beyond those modelled, in which
+	// case they are unnamed.
+	switch {
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		var name string
+		if i := n - 1 - int(op-vm.DUP1); i >= 0 {
+			name = s[i]
+		}
+		s = append(s, name)
+
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		if i := n - 2 - int(op-vm.SWAP1); i >= 0 {
+			s[i], s[n-1] = s[n-1], s[i]
+		} else {
+			s[n-1] = ""
+		}
+
+	default:
+		d := stackDeltas[op]
+		s = s[:n-int(d.pop)]
+		for i := uint(0); i < d.push; i++ {
+			s = append(s, "")
+		}
+	}
+	*m = s
+}
+
+// assert checks the model against the stack.Model, naming any unnamed items,
+// and returns an error describing any mismatch.
+func (m *stackModel) assert(want stack.Model) error {
+	s := *m
+	if len(s) != len(want) {
+		return fmt.Errorf("stack depth %d when asserting %d items %s", len(s), len(want), formatStackNames(want))
+	}
+
+	got := m.topFirst()
+	for i, w := range want {
+		if w != "" && got[i] != "" && got[i] != w {
+			return fmt.Errorf("stack %s when asserting %s; mismatch at index %d", formatStackNames(got), formatStackNames(want), i)
+		}
+	}
+
+	for i, w := range want {
+		if w != "" {
+			s[len(s)-1-i] = w
+		}
+	}
+	return nil
+}
+
+// name names the top items of the stack as described by stack.Name().
+func (m *stackModel) name(items stack.Names) error {
+	s := *m
+	if len(s) < len(items) {
+		return fmt.Errorf("stack depth %d when naming %d items %s", len(s), len(items), formatStackNames(items))
+	}
+	for i, n := range items {
+		if n != "" {
+			s[len(s)-1-i] = n
+		}
+	}
+	return nil
+}
+
+// find returns the index, counting from the top of the stack (i.e. 0 for the
+// top), of the item closest to the top with the name.
+func (m *stackModel) find(name string) (int, bool) {
+	s := *m
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == name {
+			return len(s) - 1 - i, true
+		}
+	}
+	return 0, false
+}
+
+// topFirst returns the names in the order used by stack.Assert(), with the top
+// of the stack first.
+func (m stackModel) topFirst() []string {
+	out := make([]string, len(m))
+	for i := range out {
+		out[i] = m[len(m)-1-i]
+	}
+	return out
+}
+
+// formatStackNames returns the names, top of the stack first, with unnamed
+// items as underscores.
+func formatStackNames(names []string) string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		if n == "" {
+			n = "_"
+		}
+		out[i] = n
+	}
+	return "[" + strings.Join(out, " ") + "]"
+}
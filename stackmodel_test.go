@@ -0,0 +1,220 @@
+package specops
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestStackAssert(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          Code
+		wantErrSubstr string // empty for no error
+	}{
+		{
+			name: "naming unnamed values",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Assert("value", "caller"),
+			},
+		},
+		{
+			name: "names tracked through DUP and SWAP",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Assert("value", "caller"),
+				DUP2, SWAP1,
+				stack.Assert("value", "caller", "caller"),
+				SWAP2,
+				stack.Assert("caller", "caller", "value"),
+			},
+		},
+		{
+			name: "wildcards",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Assert("value", "caller"),
+				SWAP1,
+				stack.Assert("", "value"),
+				stack.Assert("caller", ""),
+			},
+		},
+		{
+			name: "via Inverted and Fn",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Assert("value", "caller"),
+				Fn(ADD, Inverted(DUP1), Inverted(DUP1)), // caller + caller
+				stack.Assert("sum", "value", "caller"),
+			},
+		},
+		{
+			name: "reordering bug",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Assert("value", "caller"),
+				SWAP1,
+				stack.Assert("value", "caller"),
+			},
+			wantErrSubstr: "stack [caller value] when asserting [value caller]; mismatch at index 0",
+		},
+		{
+			name: "consumed values are unnamed",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Assert("value", "caller"),
+				ADD,
+				stack.Assert("sum"),
+				DUP1,
+				stack.Assert("sum", "value"),
+			},
+			wantErrSubstr: "stack [sum sum] when asserting [sum value]",
+		},
+		{
+			name: "depth mismatch",
+			code: Code{
+				CALLER,
+				stack.Assert("a", "b"),
+			},
+			wantErrSubstr: "stack depth 1 when asserting 2 items [a b]",
+		},
+		{
+			name: "SetDepth clears names",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Assert("value", "caller"),
+				Fn(JUMP, PUSH("x")),
+				JUMPDEST("x"), stack.SetDepth(2),
+				stack.Assert("caller", "value"),
+			},
+		},
+		{
+			name: "deep DUP beyond model",
+			code: Code{
+				JUMPDEST("x"), stack.SetDepth(1),
+				DUP16,
+				stack.Assert("a", "b"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.code.Compile()
+			if tt.wantErrSubstr == "" {
+				if err != nil {
+					t.Errorf("%T.Compile() error %v", tt.code, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%T.Compile() got error %v; want containing %q", tt.code, err, tt.wantErrSubstr)
+			}
+		})
+	}
+}
+
+func TestStackNameAndRef(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          Code
+		want          Code // equivalent without names; ignored if error expected
+		wantErrSubstr string
+	}{
+		{
+			name: "piped values",
+			code: Code{
+				Fn(CALLDATALOAD, PUSH0), stack.Name("x"),
+				CALLER, stack.Name("caller"),
+				Fn(MSTORE, PUSH0, stack.Ref("x")),
+				Fn(MSTORE, PUSH(32), stack.Ref("caller")),
+				Fn(ADD, stack.Ref("x"), stack.Ref("x")),
+			},
+			want: Code{
+				Fn(CALLDATALOAD, PUSH0),
+				CALLER,
+				Fn(MSTORE, PUSH0, DUP2),
+				Fn(MSTORE, PUSH(32), DUP1),
+				Fn(ADD, DUP1, DUP2),
+			},
+		},
+		{
+			name: "Name() leaves deeper items and empty names untouched",
+			code: Code{
+				CALLER, CALLVALUE,
+				stack.Name("value", "caller"),
+				stack.Name("", "sender"),
+				stack.Assert("value", "sender"),
+				stack.Ref("sender"),
+			},
+			want: Code{CALLER, CALLVALUE, DUP2},
+		},
+		{
+			name: "closest to top",
+			code: Code{
+				CALLER, stack.Name("a"),
+				DUP1, CALLVALUE,
+				stack.Ref("a"),
+			},
+			want: Code{CALLER, DUP1, CALLVALUE, DUP2},
+		},
+		{
+			name: "unknown name",
+			code: Code{
+				CALLER, stack.Name("caller"),
+				stack.Ref("value"),
+			},
+			wantErrSubstr: `stack.Ref("value") not on stack [caller]`,
+		},
+		{
+			name: "consumed",
+			code: Code{
+				CALLER, stack.Name("caller"),
+				POP,
+				stack.Ref("caller"),
+			},
+			wantErrSubstr: "not on stack []",
+		},
+		{
+			name: "naming beyond depth",
+			code: Code{
+				CALLER, stack.Name("a", "b"),
+			},
+			wantErrSubstr: "stack depth 1 when naming 2 items [a b]",
+		},
+		{
+			name: "beyond DUP16",
+			code: Code{
+				CALLER, stack.Name("deep"),
+				Code{PC, PC, PC, PC, PC, PC, PC, PC, PC, PC, PC, PC, PC, PC, PC, PC},
+				stack.Ref("deep"),
+			},
+			wantErrSubstr: "beyond reach of DUP16",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.code.Compile()
+			if tt.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+					t.Errorf("%T.Compile() got error %v; want containing %q", tt.code, err, tt.wantErrSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%T.Compile() error %v", tt.code, err)
+			}
+			want, err := tt.want.Compile()
+			if err != nil {
+				t.Fatalf("%T.Compile() of equivalent Code error %v", tt.want, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%T.Compile() got %#x; want %#x", tt.code, got, want)
+			}
+		})
+	}
+}
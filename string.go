@@ -0,0 +1,165 @@
+package specops
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+// String returns a human-readable representation of the Code, akin to the Go
+// source from which it was constructed. Fn() arguments are rendered in source
+// order, each followed by a comment describing the (flattened) order in which
+// they are emitted by the compiler.
+func (c Code) String() string {
+	return "Code{" + joinBytecoders(c) + "}"
+}
+
+// joinBytecoders returns the bytecoderString() of every element of bcs,
+// separated by commas.
+func joinBytecoders(bcs []types.Bytecoder) string {
+	parts := make([]string, len(bcs))
+	for i, bc := range bcs {
+		parts[i] = bytecoderString(bc)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bytecoderString returns a human-readable representation of bc, used by
+// Code.String().
+func bytecoderString(bc types.Bytecoder) string {
+	switch bc := bc.(type) {
+	case Code:
+		return bc.String()
+
+	case fnCall:
+		return fmt.Sprintf("Fn(%s) /* emits: %s */", joinBytecoders(bc), strings.Join(emitted(bc), " "))
+
+	case pipeCall:
+		return fmt.Sprintf("Pipe(%s) /* emits: %s */", joinBytecoders(bc.fnCall), strings.Join(emitted(bc), " "))
+
+	case types.OpCode:
+		return bc.String()
+
+	case types.StackPusher:
+		return fmt.Sprintf("PUSH(%#x)", bc.ToPush())
+
+	case Raw:
+		return fmt.Sprintf("Raw(%#x)", []byte(bc))
+
+	case JUMPDEST:
+		return fmt.Sprintf("JUMPDEST(%q)", string(bc))
+
+	case Label:
+		return fmt.Sprintf("Label(%q)", string(bc))
+
+	case pushTag:
+		return fmt.Sprintf("PUSH(%q)", string(bc))
+
+	case pushWide:
+		return fmt.Sprintf("PUSHWide(%q)", string(bc))
+
+	case pushTags:
+		ts := make([]string, len(bc))
+		for i, t := range bc {
+			ts[i] = fmt.Sprintf("%q", string(t))
+		}
+		return fmt.Sprintf("PUSH([]string{%s})", strings.Join(ts, ", "))
+
+	case pushSize:
+		return fmt.Sprintf("PUSHSize(%q, %q)", string(bc[0]), string(bc[1]))
+
+	case callF:
+		return fmt.Sprintf("CALLF(%q)", string(bc))
+
+	case eofCall:
+		return fmt.Sprintf("CALLF(%q)", string(bc.name))
+
+	case retF:
+		return "RETF"
+
+	case pushLinked:
+		return fmt.Sprintf("PUSHLinked(%q)", string(bc))
+
+	case linkedPush:
+		return fmt.Sprintf("PUSHLinked(%q) /* %v */", bc.name, bc.addr)
+
+	case rjump:
+		return fmt.Sprintf("RJUMP(%q)", string(bc))
+
+	case rjumpi:
+		return fmt.Sprintf("RJUMPI(%q)", string(bc))
+
+	case rjumpv:
+		ts := make([]string, len(bc))
+		for i, t := range bc {
+			ts[i] = fmt.Sprintf("%q", string(t))
+		}
+		return fmt.Sprintf("RJUMPV(%s)", strings.Join(ts, ", "))
+
+	case Inverted:
+		return fmt.Sprintf("Inverted(%v)", types.OpCode(bc))
+
+	case stack.FromTop:
+		return fmt.Sprintf("stack.FromTop(%v)", types.OpCode(bc))
+
+	case stack.FromBottom:
+		return fmt.Sprintf("stack.FromBottom(%v)", types.OpCode(bc))
+
+	case retainDepth:
+		return "/* stack depth retained */"
+
+	case tableWidth:
+		return fmt.Sprintf("/* %d-byte entries */", bc.width)
+
+	case stack.SetDepth:
+		return fmt.Sprintf("stack.SetDepth(%d)", uint(bc))
+
+	case stack.ExpectDepth:
+		return fmt.Sprintf("stack.ExpectDepth(%d)", uint(bc))
+
+	case stack.ExpectDepthRuntime:
+		return fmt.Sprintf("stack.ExpectDepthRuntime(%d)", uint(bc))
+
+	case stack.Names:
+		items := make([]string, len(bc))
+		for i, it := range bc {
+			items[i] = fmt.Sprintf("%q", it)
+		}
+		return fmt.Sprintf("stack.Name(%s)", strings.Join(items, ", "))
+
+	case stack.Ref:
+		return fmt.Sprintf("stack.Ref(%q)", string(bc))
+
+	case stack.Model:
+		items := make([]string, len(bc))
+		for i, it := range bc {
+			items[i] = fmt.Sprintf("%q", it)
+		}
+		return fmt.Sprintf("stack.Assert(%s)", strings.Join(items, ", "))
+
+	case types.BytecodeHolder:
+		return fmt.Sprintf("%T{%s}", bc, joinBytecoders(bc.Bytecoders()))
+
+	case fmt.Stringer:
+		return bc.String()
+
+	default:
+		return fmt.Sprintf("%T", bc)
+	}
+}
+
+// emitted returns the bytecoderString() of every non-BytecodeHolder in bc,
+// recursively, in the order in which they are emitted by the compiler.
+func emitted(bc types.BytecodeHolder) []string {
+	var out []string
+	for _, b := range bc.Bytecoders() {
+		if h, ok := b.(types.BytecodeHolder); ok {
+			out = append(out, emitted(h)...)
+		} else {
+			out = append(out, bytecoderString(b))
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package specops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+	"github.com/arr4n/specops/types"
+)
+
+func TestFnReversedBytecoders(t *testing.T) {
+	args := []types.Bytecoder{MSTORE, PUSH0, PUSH(42)}
+	fn := Fn(args...)
+
+	rev, ok := fn.(interface{ ReversedBytecoders() []types.Bytecoder })
+	if !ok {
+		t.Fatalf("Fn() returned %T without ReversedBytecoders() method", fn)
+	}
+	want := Code{PUSH(42), PUSH0, MSTORE}
+	if diff := cmp.Diff(want, Code(rev.ReversedBytecoders())); diff != "" {
+		t.Errorf("Fn(%v).ReversedBytecoders() diff (-want +got):\n%s", args, diff)
+	}
+	if diff := cmp.Diff(want, Code(fn.Bytecoders())); diff != "" {
+		t.Errorf("Fn(%v).Bytecoders() diff (-want +got):\n%s", args, diff)
+	}
+
+	if diff := cmp.Diff(Code{MSTORE, PUSH0, PUSH(42)}, Code(args)); diff != "" {
+		t.Errorf("Fn(args...) modified args; diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	tests := []struct {
+		code Code
+		want string
+	}{
+		{
+			code: Code{},
+			want: "Code{}",
+		},
+		{
+			code: Code{Fn(MSTORE, PUSH0, PUSH(42)), STOP},
+			want: "Code{Fn(MSTORE, PUSH0, PUSH(0x2a)) /* emits: PUSH(0x2a) PUSH0 MSTORE */, STOP}",
+		},
+		{
+			code: Code{Fn(JUMPI, PUSH("end"), Fn(ISZERO, CALLVALUE))},
+			want: `Code{Fn(JUMPI, PUSH("end"), Fn(ISZERO, CALLVALUE) /* emits: CALLVALUE ISZERO */) /* emits: CALLVALUE ISZERO PUSH("end") JUMPI */}`,
+		},
+		{
+			code: Code{Pipe(CALLER, PUSH0)},
+			want: "Code{Pipe(CALLER, PUSH0) /* emits: PUSH0 CALLER */}",
+		},
+		{
+			code: Code{
+				JUMPDEST("a"), stack.SetDepth(2), Label("b"),
+				PUSH([]string{"a", "b"}), PUSHSize("a", "b"),
+				Inverted(DUP1), stack.ExpectDepth(3), stack.Assert("x", "", "y"),
+				Raw{0xfe},
+			},
+			want: `Code{JUMPDEST("a"), stack.SetDepth(2), Label("b"), PUSH([]string{"a", "b"}), PUSHSize("a", "b"), Inverted(DUP1), stack.ExpectDepth(3), stack.Assert("x", "", "y"), Raw(0xfe)}`,
+		},
+		{
+			code: Code{RJUMP("a"), RJUMPI("b"), RJUMPV("a", "b")},
+			want: `Code{RJUMP("a"), RJUMPI("b"), RJUMPV("a", "b")}`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.code.String(); got != tt.want {
+			t.Errorf("%T.String() got:\n%s\nwant:\n%s", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package specops
+
+import (
+	"github.com/arr4n/specops/stack"
+)
+
+// Stripped returns a flattened equivalent of the Code with all debugging and
+// assertion hints removed, for compiling production bytecode from Code that was
+// developed with rich annotations. Specifically:
+//
+//   - Code.Assert() invariants and stack.Assert() models are removed; and
+//   - stack.ExpectDepthRuntime() is replaced by stack.ExpectDepth(), dropping
+//     its runtime guard but retaining the compile-time check.
+//
+// Hints that affect compilation, such as stack.SetDepth() and
+// stack.ExpectDepth(), are kept. The receiver is not modified.
+func (c Code) Stripped() Code {
+	flat := c.flatten()
+	out := make(Code, 0, len(flat))
+	for _, bc := range flat {
+		switch bc := bc.(type) {
+		case bytecodeAssertion, stack.Model:
+		case stack.ExpectDepthRuntime:
+			out = append(out, stack.ExpectDepth(bc))
+		default:
+			out = append(out, bc)
+		}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package specops
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestStripped(t *testing.T) {
+	code := Code{
+		PUSH(1),
+		Code{
+			stack.Assert("one"),
+			PUSH(2),
+			stack.ExpectDepthRuntime(2),
+		},
+		stack.ExpectDepth(2),
+		Fn(JUMP, PUSH("dest")),
+		JUMPDEST("dest"), stack.SetDepth(2),
+		ADD,
+	}.Assert(func([]byte) error {
+		return errors.New("always fails")
+	})
+
+	if _, err := code.Compile(); err == nil {
+		t.Fatalf("%T.Compile() before stripping; got nil error; want error from Assert()", code)
+	}
+
+	stripped := code.Stripped()
+	got, err := stripped.Compile()
+	if err != nil {
+		t.Fatalf("%T.Stripped().Compile() error %v", code, err)
+	}
+
+	want, err := Code{
+		PUSH(1), PUSH(2),
+		Fn(JUMP, PUSH("dest")),
+		JUMPDEST("dest"), stack.SetDepth(2),
+		ADD,
+	}.Compile()
+	if err != nil {
+		t.Fatalf("Compile() of expected Code; error %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%T.Stripped().Compile() diff (-want +got):\n%s", code, diff)
+	}
+
+	wantStripped := Code{
+		PUSH(1),
+		PUSH(2),
+		stack.ExpectDepth(2),
+		stack.ExpectDepth(2),
+	}
+	if diff := cmp.Diff(wantStripped, stripped[:len(wantStripped)]); diff != "" {
+		t.Errorf("%T.Stripped() prefix diff (-want +got):\n%s", code, diff)
+	}
+}
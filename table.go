@@ -0,0 +1,114 @@
+package specops
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/arr4n/specops/types"
+)
+
+// A Table is a sequence of equal-width entries packed into a single word, for
+// use with the BYTE-indexing pattern (or its equivalent for wider entries).
+// The Table is itself a BytecodeHolder that PUSHes the packed word, and its
+// Index() method returns Code that extracts a single entry, removing the need
+// for manual offset arithmetic like `PUSH(32-len(…))`.
+type Table struct {
+	push  types.Bytecoder
+	len   int
+	width int // bytes per entry
+
+	tags pushTags // only if constructed by TableOf()
+}
+
+// TableOf returns a Table of JUMPDEST / Label offsets, e.g. for a jump table.
+// Each entry is a single byte so all of the dests MUST be within the first 256
+// bytes of the compiled code, otherwise compilation fails. This differs from
+// PUSH(), which widens all offsets to 2 bytes if any is beyond the 256th byte.
+func TableOf[T ~string](dests ...T) Table {
+	tags := asPushTags(dests)
+	t := newTable(tags, len(dests), 1)
+	t.tags = tags
+	return t
+}
+
+// Uint8Table returns a Table of single-byte values.
+func Uint8Table(vals ...uint8) Table {
+	return newTable(PUSHBytes(vals...), len(vals), 1)
+}
+
+// Uint16Table returns a Table of two-byte values.
+func Uint16Table(vals ...uint16) Table {
+	buf := make([]byte, 2*len(vals))
+	for i, v := range vals {
+		binary.BigEndian.PutUint16(buf[2*i:], v)
+	}
+	return newTable(PUSHBytes(buf...), len(vals), 2)
+}
+
+// newTable panics if the Table would be empty or larger than a word, in the
+// same way that PUSH() panics on negative values.
+func newTable(push types.Bytecoder, n, width int) Table {
+	if n == 0 || n*width > 32 {
+		panic(fmt.Sprintf("Table of %d entries of %d byte(s) must be in (0,32] bytes", n, width))
+	}
+	return Table{
+		push:  push,
+		len:   n,
+		width: width,
+	}
+}
+
+// Len returns the number of entries in the Table.
+func (t Table) Len() int { return t.len }
+
+// Bytecode always returns an error; the Table must be included in a Code.
+func (t Table) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", t)
+}
+
+// Bytecoders returns a Bytecoder that PUSHes the Table's packed word.
+func (t Table) Bytecoders() []types.Bytecoder {
+	if t.tags == nil {
+		return []types.Bytecoder{t.push}
+	}
+	return []types.Bytecoder{t.push, tableWidth{tags: t.tags, width: t.width}}
+}
+
+// A tableWidth is a compiler hint that every one of the tags, as included in a
+// Table, MUST have an offset that fits in the width.
+type tableWidth struct {
+	tags  pushTags
+	width int // bytes
+}
+
+// Bytecode always returns an error; the tableWidth must be included in a Code.
+func (w tableWidth) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("call to %T.Bytecode()", w)
+}
+
+// check returns an error if any of w.tags has an offset that can't be
+// represented in w.width bytes.
+func (w tableWidth) check(offsets map[string]int) error {
+	limit := 1 << (8 * w.width)
+	for _, t := range w.tags {
+		if off := offsets[string(t)]; off >= limit {
+			return fmt.Errorf("%T entry %q at offset %d; MUST be < %d to fit in %d byte(s)", Table{}, string(t), off, limit, w.width)
+		}
+	}
+	return nil
+}
+
+// Index returns Code that pushes the i'th entry of the Table, where i is the
+// value pushed by index. Out-of-range indices result in zero for single-byte
+// entries but are otherwise undefined.
+func (t Table) Index(index types.Bytecoder) types.BytecodeHolder {
+	if t.width == 1 {
+		return Fn(BYTE, Fn(ADD, index, PUSH(32-t.len)), t)
+	}
+	// Entries are right-aligned in the word so the last one requires no
+	// shifting.
+	shift := Fn(MUL, PUSH(8*t.width), Fn(SUB, PUSH(t.len-1), index))
+	mask := PUSHBytes(bytes.Repeat([]byte{0xff}, t.width)...)
+	return Fn(AND, Fn(SHR, shift, t), mask)
+}
@@ -0,0 +1,128 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestTableIndex(t *testing.T) {
+	lookup := func(t *testing.T, table Table, i int) uint64 {
+		t.Helper()
+		code := Code{
+			Fn(MSTORE, PUSH0, table.Index(PUSH(i))),
+			Fn(RETURN, PUSH0, PUSH(32)),
+		}
+		res, err := code.Run(nil)
+		if err != nil {
+			t.Fatalf("%T.Run() error %v", code, err)
+		}
+		return new(uint256.Int).SetBytes(res.ReturnData).Uint64()
+	}
+
+	t.Run("Uint8Table", func(t *testing.T) {
+		vals := []uint8{0, 1, 42, 0xff, 7}
+		table := Uint8Table(vals...)
+		for i, want := range vals {
+			if got := lookup(t, table, i); got != uint64(want) {
+				t.Errorf("%T.Index(%d) got %d; want %d", table, i, got, want)
+			}
+		}
+	})
+
+	t.Run("Uint16Table", func(t *testing.T) {
+		vals := []uint16{0xbeef, 0, 1, 0xffff, 0x0100}
+		table := Uint16Table(vals...)
+		for i, want := range vals {
+			if got := lookup(t, table, i); got != uint64(want) {
+				t.Errorf("%T.Index(%d) got %d; want %d", table, i, got, want)
+			}
+		}
+	})
+
+	t.Run("TableOf", func(t *testing.T) {
+		dests := []string{"zero", "one", "two"}
+		table := TableOf(dests...)
+
+		for i := range dests {
+			code := Code{
+				Fn(JUMP, table.Index(PUSH(i))),
+				JUMPDEST("zero"), stack.SetDepth(0), Fn(RETURN, PUSH0, PUSH(0)),
+				JUMPDEST("one"), stack.SetDepth(0), Fn(RETURN, PUSH0, PUSH(1)),
+				JUMPDEST("two"), stack.SetDepth(0), Fn(RETURN, PUSH0, PUSH(2)),
+			}
+			res, err := code.Run(nil)
+			if err != nil {
+				t.Fatalf("%T.Run() error %v", code, err)
+			}
+			if got := len(res.ReturnData); got != i {
+				t.Errorf("JUMP to %T.Index(%d) returned %d bytes; want %d", table, i, got, i)
+			}
+		}
+	})
+}
+
+func TestTablePanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() Table
+	}{
+		{
+			name: "empty",
+			fn:   func() Table { return Uint8Table() },
+		},
+		{
+			name: "too many single-byte entries",
+			fn:   func() Table { return Uint8Table(make([]uint8, 33)...) },
+		},
+		{
+			name: "too many two-byte entries",
+			fn:   func() Table { return Uint16Table(make([]uint16, 17)...) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestTableOfWidth(t *testing.T) {
+	table := TableOf("near", "far")
+	withPadding := func(n int) Code {
+		return Code{
+			Fn(JUMP, table.Index(PUSH(1))),
+			JUMPDEST("near"), stack.SetDepth(0), STOP,
+			Raw(make([]byte, n)),
+			JUMPDEST("far"), stack.SetDepth(0), Fn(RETURN, PUSH0, PUSH(1)),
+		}
+	}
+
+	t.Run("within first 256 bytes", func(t *testing.T) {
+		code := withPadding(200)
+		res, err := code.Run(nil)
+		if err != nil {
+			t.Fatalf("%T.Run() error %v", code, err)
+		}
+		if got, want := len(res.ReturnData), 1; got != want {
+			t.Errorf("JUMP to %T.Index(1) returned %d bytes; want %d", table, got, want)
+		}
+	})
+
+	t.Run("beyond first 256 bytes", func(t *testing.T) {
+		code := withPadding(300)
+		_, err := code.Compile()
+		if err == nil || !strings.Contains(err.Error(), `entry "far" at offset`) {
+			t.Errorf("%T.Compile() with %T entry beyond byte 255; got err %v; want offset error", code, table, err)
+		}
+	})
+}
@@ -69,6 +69,41 @@ func asPushTags[T ~string](xs []T) pushTags {
 // accepted by the generic function.
 var _ = asPushTags[tag]
 
+// PUSHLabel returns a Bytecoder that pushes the location of the JUMPDEST or
+// Label with the name. It is equivalent to PUSH(name) but accepts any string
+// type, e.g. for use when building jump tables from variables.
+func PUSHLabel[T ~string](name T) types.Bytecoder {
+	return pushTag(name)
+}
+
+// PUSHLabels is the multi-name equivalent of PUSHLabel(), pushing the
+// concatenated locations in a single PUSH<N> (e.g. for a jump table). As with
+// PUSH([]string{}), it returns an empty Bytecoder if no names are provided.
+func PUSHLabels[T ~string](names ...T) types.Bytecoder {
+	if len(names) == 0 {
+		return Raw{}
+	}
+	return asPushTags(names)
+}
+
+// PUSHWide is equivalent to PUSHLabel() except that the location is always
+// pushed with a PUSH2, regardless of its value. The PUSH's width is therefore
+// pinned, which guarantees that the offsets of all later code are unaffected by
+// the location of the JUMPDEST or Label (e.g. as the Code grows), at the cost
+// of up to two bytes. It is useful for layout-critical code such as jump
+// tables that must not shift. Compilation fails if the location can't be
+// represented with 2 bytes.
+func PUSHWide[T ~string](name T) types.Bytecoder {
+	return pushWide(name)
+}
+
+// A pushWide is the equivalent of a pushTag but with a fixed width.
+type pushWide tag
+
+func (p pushWide) Bytecode() ([]byte, error) {
+	return nil, fmt.Errorf("direct call to %T.Bytecode()", p)
+}
+
 // PUSHSize pushes abs(loc(a),loc(b)), i.e. the size of the bytecode between the
 // corresponding JUMPDEST(s) / Label(s).
 func PUSHSize[T ~string, U ~string](a T, b U) types.Bytecoder {
@@ -52,26 +52,25 @@ type pusher struct {
 
 func (p pusher) Bytecode() ([]byte, error) {
 	buf := p.ToPush()
-	n := len(buf)
-	if n == 0 || n > 32 {
+	if n := len(buf); n == 0 || n > 32 {
 		return nil, fmt.Errorf("len(%T.ToPush()) == %d must be in [1,32]", p.StackPusher, n)
 	}
+	op, data := MinimalPush(buf)
+	return append([]byte{byte(op)}, data...), nil
+}
 
-	size := n
-	for _, b := range buf {
-		if b == 0 {
-			size--
-		} else {
-			break
-		}
+// MinimalPush returns the smallest PUSH<N> opcode capable of pushing buf,
+// interpreted as a big-endian integer, along with the N bytes to be pushed;
+// i.e. buf with leading zeros stripped. An empty or all-zero buf results in
+// PUSH0 and no data. MinimalPush panics if more than 32 bytes remain after
+// stripping.
+func MinimalPush(buf []byte) (vm.OpCode, []byte) {
+	for len(buf) > 0 && buf[0] == 0 {
+		buf = buf[1:]
 	}
-	if size == 0 {
-		return []byte{byte(vm.PUSH0)}, nil
+	if n := len(buf); n > 32 {
+		panic(fmt.Sprintf("%d significant bytes can't be pushed to the stack", n))
 	}
-
-	return append(
-		// PUSH0 to PUSH32 are contiguous, so we can perform arithmetic on them.
-		[]byte{byte(vm.PUSH0 + vm.OpCode(size))},
-		buf[n-size:]...,
-	), nil
+	// PUSH0 to PUSH32 are contiguous, so we can perform arithmetic on them.
+	return vm.PUSH0 + vm.OpCode(len(buf)), buf
 }
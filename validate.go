@@ -0,0 +1,74 @@
+package specops
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// Validate performs static checks on arbitrary bytecode, typically that
+// produced with Raw or otherwise bypassing the compiler's own checks, returning
+// an error describing every problem found, joined as with errors.Join(). The
+// checks are:
+//   - the code doesn't exceed the EIP-170 size limit of 24,576 bytes and
+//     doesn't begin with the 0xEF byte rejected by EIP-3541, either of which
+//     would cause deployment to fail;
+//   - every reachable instruction is a known opcode and isn't a PUSH<N>
+//     truncated by the end of the code; and
+//   - every JUMP or JUMPI immediately preceded by a PUSH has a destination
+//     that is a JUMPDEST opcode, excluding 0x5b bytes that are PUSH data.
+//
+// Code is considered unreachable from a halting opcode, unconditional JUMP, or
+// unknown opcode until the next JUMPDEST, allowing data to be appended to the
+// code. INVALID (0xfe) is a known opcode.
+func Validate(bytecode []byte) error {
+	var errs []error
+	if n := len(bytecode); n > params.MaxCodeSize {
+		errs = append(errs, fmt.Errorf("code length %d exceeds maximum of %d", n, params.MaxCodeSize))
+	}
+	if len(bytecode) > 0 && bytecode[0] == 0xef {
+		errs = append(errs, errors.New("code begins with 0xEF byte (EIP-3541)"))
+	}
+
+	instrs := disassemble(bytecode)
+	dests := make(map[uint64]bool)
+	for _, in := range instrs {
+		if in.op == vm.JUMPDEST {
+			dests[uint64(in.pc)] = true
+		}
+	}
+
+	reachable := true
+	for i, in := range instrs {
+		if in.op == vm.JUMPDEST {
+			reachable = true
+		}
+		if !reachable {
+			continue
+		}
+
+		switch _, ok := stackDeltas[in.op]; {
+		case !ok:
+			errs = append(errs, fmt.Errorf("pc %d: unknown opcode %#02x", in.pc, byte(in.op)))
+			reachable = false
+			continue
+		case in.truncated:
+			errs = append(errs, fmt.Errorf("pc %d: %v truncated by end of code", in.pc, in.op))
+		}
+
+		if (in.op == vm.JUMP || in.op == vm.JUMPI) && i > 0 && instrs[i-1].op.IsPush() {
+			dest := new(uint256.Int).SetBytes(instrs[i-1].data)
+			if !dest.IsUint64() || !dests[dest.Uint64()] {
+				errs = append(errs, fmt.Errorf("pc %d: %v to %s, which isn't a JUMPDEST", in.pc, in.op, dest.Dec()))
+			}
+		}
+
+		if isTerminal(in.op) {
+			reachable = false
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,101 @@
+package specops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/arr4n/specops/stack"
+)
+
+func TestValidate(t *testing.T) {
+	compile := func(t *testing.T, c Code) []byte {
+		t.Helper()
+		b, err := c.Compile()
+		if err != nil {
+			t.Fatalf("%T.Compile() error %v", c, err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		code    Code
+		wantErr []string // substrings, in order
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "compiled",
+			code: Code{
+				Fn(JUMPI, PUSH("end"), CALLVALUE),
+				Fn(RETURN, PUSH0, PUSH0),
+				JUMPDEST("end"), stack.SetDepth(0),
+				STOP,
+			},
+		},
+		{
+			name: "data after halting",
+			code: Code{STOP, Raw{0x0c, byte(vm.PUSH4), 1}},
+		},
+		{
+			name:    "unknown opcode",
+			code:    Code{PUSH0, Raw{0x0c}},
+			wantErr: []string{"pc 1: unknown opcode 0x0c"},
+		},
+		{
+			name:    "truncated PUSH",
+			code:    Code{PUSH0, Raw{byte(vm.PUSH2), 1}},
+			wantErr: []string{"pc 1: PUSH2 truncated"},
+		},
+		{
+			name:    "0xEF prefix",
+			code:    Code{Raw{0xef}},
+			wantErr: []string{"0xEF", "unknown opcode 0xef"},
+		},
+		{
+			name:    "too large",
+			code:    Code{Raw(make([]byte, 24577))},
+			wantErr: []string{"exceeds maximum of 24576"},
+		},
+		{
+			name: "bad jump destinations",
+			code: Code{
+				Raw{byte(vm.PUSH1), 5, byte(vm.JUMPI)}, // 0, 2
+				Raw{byte(vm.PUSH1), byte(vm.JUMPDEST)}, // 3; PUSH data
+				Raw{byte(vm.PUSH1), 3, byte(vm.JUMP)},  // 5, 7
+				JUMPDEST("ok"), stack.SetDepth(0),      // 8
+				Fn(JUMP, PUSH("ok")),
+			},
+			wantErr: []string{
+				"pc 2: JUMPI to 5, which isn't a JUMPDEST",
+				"pc 7: JUMP to 3, which isn't a JUMPDEST",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(compile(t, tt.code))
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Validate() got error %v; want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() got nil error; want containing %q", tt.wantErr)
+			}
+			got := err.Error()
+			for _, w := range tt.wantErr {
+				i := strings.Index(got, w)
+				if i == -1 {
+					t.Fatalf("Validate() got error %q; want containing %q, in order", err, tt.wantErr)
+				}
+				got = got[i+len(w):]
+			}
+		})
+	}
+}